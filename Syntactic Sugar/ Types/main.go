@@ -5,8 +5,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"time"
 	"unsafe"
+
+	"Syntactic_Sugar/config"
 )
 
 // ============================= 2. 静态强类型特性演示 ====================
@@ -150,9 +154,53 @@ type ConfigValue interface {
 	string | int | bool
 }
 
+// appConfig 分层配置：默认值 < 配置文件 < APP_ 环境变量 < 命令行flag
+var appConfig = config.New()
+
 func GetConfig[T ConfigValue](key string, defaultValue T) T {
-	// 模拟配置获取
-	return defaultValue
+	// 键不存在或类型不匹配时返回默认值
+	return config.GetOr(appConfig, key, defaultValue)
+}
+
+// 8.3 通过结构体标签绑定配置
+type ServerConfig struct {
+	Host         string        `config:"host" default:"localhost"`
+	Port         int           `config:"port,required"`
+	ReadTimeout  time.Duration `config:"read_timeout" default:"5s"`
+	AllowOrigins []string      `config:"allow_origins"`
+}
+
+type AppConfig struct {
+	Name   string       `config:"app.name" default:"gobase"`
+	Server ServerConfig `config:"server"`
+}
+
+func loadAppConfig() {
+	configFile := flag.String("config", "", "配置文件路径(YAML/JSON)")
+	flag.Int("server.port", 8080, "服务端口，flag名即配置键，显式设置时覆盖 server.port")
+	flag.Parse()
+
+	cfg, err := config.Load(config.Options{
+		File:      *configFile,
+		EnvPrefix: "APP",
+		FlagSet:   flag.CommandLine,
+		Defaults: map[string]any{
+			"app.name":    "default",
+			"server.port": 8080,
+		},
+	})
+	if err != nil {
+		fmt.Println("加载配置失败:", err)
+		return
+	}
+	appConfig = cfg
+
+	var app AppConfig
+	if err := appConfig.Bind(&app); err != nil {
+		fmt.Println("配置校验失败:", err)
+		return
+	}
+	fmt.Printf("绑定配置: %+v\n", app)
 }
 
 func main() {
@@ -177,12 +225,13 @@ func main() {
 	demonstrateTypeSwitch()
 
 	fmt.Println("\n7. 实际应用演示:")
+	loadAppConfig()
 	registerProcessor("test", func(s string) (int, error) {
 		return len(s), nil
 	})
 
 	strConfig := GetConfig("app.name", "default")
-	intConfig := GetConfig("app.port", 8080)
+	intConfig := GetConfig("server.port", 8080)
 	fmt.Printf("配置获取 - 字符串: %s, 整数: %d\n", strConfig, intConfig)
 
 	fmt.Println("\n=== 演示完成 ===")
@@ -197,3 +246,4 @@ func main() {
 // 6. 类型断言: 用于接口类型检查，安全方式使用ok判断
 // 7. 类型判断: 使用switch v.(type)处理多种类型情况
 // 8. 实际应用: 类型别名简化代码，泛型约束确保类型安全
+// 9. 分层配置: 默认值 < 文件 < 环境变量 < flag，结构体标签绑定并校验必填键
@@ -0,0 +1,231 @@
+// ============================= 1. Actor 模型 ====================
+// actor 只通过消息通信，内部状态只被自己的激活访问，因此不需要锁：
+// - Ref[M] 是类型化的邮箱地址，Send 只接受 M 类型的消息
+// - Ask 发送携带 Reply 的请求消息，等待actor回复（请求/响应）
+// - actor 组成监督树：子actor出错时由父actor的 Strategy 决定重启、恢复、停止或上报
+// - 可选实现 PreStart/PostStop/PreRestart/PostRestart 生命周期钩子
+// - System.Shutdown 从叶子开始停止整棵树，Options.Scheduler 可换成 Manual 做确定性测试
+
+package actor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrStopped actor 已停止（或正在停止），消息计入死信
+	ErrStopped = errors.New("actor: stopped")
+	// ErrMailboxFull 邮箱已满
+	ErrMailboxFull = errors.New("actor: mailbox full")
+	// ErrDuplicateName 同一个父actor下名字重复
+	ErrDuplicateName = errors.New("actor: duplicate name")
+)
+
+// Actor 处理 M 类型消息的actor，返回错误交给监督策略处理
+type Actor[M any] interface {
+	Receive(ctx *Context[M], msg M) error
+}
+
+// Func 把函数转换为无状态的 Actor
+type Func[M any] func(ctx *Context[M], msg M) error
+
+func (f Func[M]) Receive(ctx *Context[M], msg M) error {
+	return f(ctx, msg)
+}
+
+// 可选的生命周期钩子
+type (
+	// PreStarter 实例创建后、处理第一条消息前调用，可以在这里创建子actor
+	PreStarter[M any] interface {
+		PreStart(ctx *Context[M]) error
+	}
+	// PostStopper 子actor全部停止后、自己停止前调用
+	PostStopper[M any] interface {
+		PostStop(ctx *Context[M])
+	}
+	// PreRestarter 旧实例被替换前调用，reason 是导致重启的错误
+	PreRestarter[M any] interface {
+		PreRestart(ctx *Context[M], reason error)
+	}
+	// PostRestarter 新实例创建后调用；未实现时调用 PreStart
+	PostRestarter[M any] interface {
+		PostRestart(ctx *Context[M], reason error) error
+	}
+)
+
+// Props 创建actor的参数
+type Props[M any] struct {
+	New        func() Actor[M] // 每次启动和重启都创建新实例
+	Mailbox    int             // 邮箱容量，0 表示不限制
+	Supervisor Strategy        // 监督它的子actor的策略
+}
+
+// Options 系统配置
+type Options struct {
+	Scheduler  Scheduler // 默认每次激活一个goroutine
+	Throughput int       // 每次激活最多处理的消息数，默认16
+	Guardian   Strategy  // 顶层actor的监督策略
+
+	// Now 时间来源，用于统计 Strategy.Within 内的重启次数，默认 time.Now
+	// 和 Manual 调度器一起使用时可以换成手动推进的时钟
+	Now func() time.Time
+
+	// OnFailure actor出错时调用，用于记录日志
+	OnFailure func(path string, err error, d Directive)
+}
+
+// System actor 系统，所有顶层actor的根
+type System struct {
+	sched       Scheduler
+	throughput  int
+	onFailure   func(path string, err error, d Directive)
+	now         func() time.Time
+	root        *cell
+	deadLetters atomic.Int64
+}
+
+// NewSystem 创建actor系统
+func NewSystem(opts Options) *System {
+	if opts.Scheduler == nil {
+		opts.Scheduler = goScheduler{}
+	}
+	if opts.Throughput <= 0 {
+		opts.Throughput = 16
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	s := &System{
+		sched:      opts.Scheduler,
+		throughput: opts.Throughput,
+		onFailure:  opts.OnFailure,
+		now:        opts.Now,
+	}
+	s.root = newCell(s, nil, "", 0, opts.Guardian)
+	return s
+}
+
+func (s *System) cell() *cell {
+	return s.root
+}
+
+// Stop 异步停止所有actor
+func (s *System) Stop() {
+	s.root.sendSystem(sysMsg{kind: sysStop})
+}
+
+// Done 所有actor都停止后关闭
+func (s *System) Done() <-chan struct{} {
+	return s.root.done
+}
+
+// Shutdown 停止所有actor并等待，ctx 结束时返回 ctx.Err()
+// 使用 Manual 调度器时先 Stop，再 RunUntilIdle
+func (s *System) Shutdown(ctx context.Context) error {
+	s.Stop()
+	select {
+	case <-s.root.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DeadLetters 发给已停止actor或停止时未处理的消息数
+func (s *System) DeadLetters() int64 {
+	return s.deadLetters.Load()
+}
+
+// Parent 可以创建子actor的对象：*System 或 *Context
+type Parent interface {
+	cell() *cell
+}
+
+// Ref actor 的地址，可以在goroutine之间任意传递
+type Ref[M any] struct {
+	c *cell
+}
+
+// Send 投递消息，不等待处理
+func (r *Ref[M]) Send(msg M) error {
+	return r.c.send(msg)
+}
+
+// Stop 异步停止actor及其子actor，已在邮箱中的消息不再处理
+func (r *Ref[M]) Stop() {
+	r.c.sendSystem(sysMsg{kind: sysStop})
+}
+
+// Done actor 停止后关闭
+func (r *Ref[M]) Done() <-chan struct{} {
+	return r.c.done
+}
+
+// Path actor 在监督树中的路径，例如 /bank/account-1
+func (r *Ref[M]) Path() string {
+	return r.c.path
+}
+
+// Context actor 处理消息时的上下文，在重启之间保持不变
+type Context[M any] struct {
+	c    *cell
+	self *Ref[M]
+}
+
+func (ctx *Context[M]) cell() *cell {
+	return ctx.c
+}
+
+// Self 当前actor的地址，可以放进消息里让其他actor回复
+func (ctx *Context[M]) Self() *Ref[M] {
+	return ctx.self
+}
+
+// System 当前actor所属的系统
+func (ctx *Context[M]) System() *System {
+	return ctx.c.sys
+}
+
+// Spawn 在 parent 下创建actor，name 为空时自动生成
+func Spawn[M any](parent Parent, name string, props Props[M]) (*Ref[M], error) {
+	p := parent.cell()
+	c := newCell(p.sys, p, name, props.Mailbox, props.Supervisor)
+	ctx := &Context[M]{c: c, self: &Ref[M]{c: c}}
+	c.newBehavior = func() *behavior { return newBehavior(ctx, props.New()) }
+
+	if err := p.addChild(c); err != nil {
+		return nil, err
+	}
+	c.sendSystem(sysMsg{kind: sysStart})
+	return ctx.self, nil
+}
+
+// newBehavior 把类型化的实例和它实现的钩子包装成 behavior
+func newBehavior[M any](ctx *Context[M], a Actor[M]) *behavior {
+	b := &behavior{
+		receive: func(msg any) error { return a.Receive(ctx, msg.(M)) },
+	}
+	if h, ok := a.(PreStarter[M]); ok {
+		b.preStart = func() error { return h.PreStart(ctx) }
+	}
+	if h, ok := a.(PostStopper[M]); ok {
+		b.postStop = func() { h.PostStop(ctx) }
+	}
+	if h, ok := a.(PreRestarter[M]); ok {
+		b.preRestart = func(reason error) { h.PreRestart(ctx, reason) }
+	}
+	if h, ok := a.(PostRestarter[M]); ok {
+		b.postRestart = func(reason error) error { return h.PostRestart(ctx, reason) }
+	} else {
+		b.postRestart = func(error) error {
+			if b.preStart == nil {
+				return nil
+			}
+			return b.preStart()
+		}
+	}
+	return b
+}
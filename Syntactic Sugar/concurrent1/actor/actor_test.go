@@ -0,0 +1,190 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// 测试用的消息：inc 累加，get 通过 reply 返回当前值，fail 返回错误，panic 触发panic
+type msg struct {
+	op    string
+	reply Reply[int]
+}
+
+func get(reply Reply[int]) msg { return msg{op: "get", reply: reply} }
+
+// journal 记录生命周期事件，Manual 调度器下所有actor在同一个goroutine中运行
+type journal struct{ events []string }
+
+func (j *journal) add(e string) { j.events = append(j.events, e) }
+
+type counter struct {
+	name string
+	j    *journal
+	n    int
+
+	startErr error // PreStart 返回的错误
+}
+
+func (a *counter) PreStart(ctx *Context[msg]) error {
+	a.j.add(a.name + ":start")
+	return a.startErr
+}
+
+func (a *counter) PostStop(ctx *Context[msg]) { a.j.add(a.name + ":stop") }
+
+func (a *counter) PreRestart(ctx *Context[msg], reason error) {
+	a.j.add(a.name + ":pre-restart")
+}
+
+func (a *counter) Receive(ctx *Context[msg], m msg) error {
+	switch m.op {
+	case "inc":
+		a.n++
+	case "get":
+		m.reply.Resolve(a.n)
+	case "fail":
+		return errors.New("boom")
+	case "panic":
+		panic("boom")
+	}
+	return nil
+}
+
+func spawnCounter(t *testing.T, parent Parent, name string, j *journal, sup Strategy) *Ref[msg] {
+	t.Helper()
+	ref, err := Spawn(parent, name, Props[msg]{
+		New:        func() Actor[msg] { return &counter{name: name, j: j} },
+		Supervisor: sup,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+// askManual 在 Manual 调度器上发起请求并运行到空闲，返回回复
+func askManual(t *testing.T, sched *Manual, ref *Ref[msg]) (int, error) {
+	t.Helper()
+	f, err := AskAsync(ref, get)
+	if err != nil {
+		return 0, err
+	}
+	sched.RunUntilIdle()
+	v, err, ok := f.TryGet()
+	if !ok {
+		t.Fatal("no reply after RunUntilIdle")
+	}
+	return v, err
+}
+
+func TestAskManual(t *testing.T) {
+	sched := NewManual()
+	sys := NewSystem(Options{Scheduler: sched})
+	ref := spawnCounter(t, sys, "c", &journal{}, Strategy{})
+
+	for range 3 {
+		ref.Send(msg{op: "inc"})
+	}
+	if v, err := askManual(t, sched, ref); err != nil || v != 3 {
+		t.Fatalf("Ask = %d, %v; want 3, nil", v, err)
+	}
+}
+
+func TestAskGoroutines(t *testing.T) {
+	sys := NewSystem(Options{})
+	ref := spawnCounter(t, sys, "c", &journal{}, Strategy{})
+	defer sys.Shutdown(context.Background())
+
+	for range 100 {
+		ref.Send(msg{op: "inc"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if v, err := Ask(ctx, ref, get); err != nil || v != 100 {
+		t.Fatalf("Ask = %d, %v; want 100, nil", v, err)
+	}
+}
+
+// TestAskCrashNoReply 处理请求时崩溃不会回复，调用方靠ctx超时返回
+func TestAskCrashNoReply(t *testing.T) {
+	sys := NewSystem(Options{})
+	defer sys.Shutdown(context.Background())
+	ref, _ := Spawn(sys, "crash", Props[msg]{New: func() Actor[msg] {
+		return Func[msg](func(ctx *Context[msg], m msg) error { panic("boom") })
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := Ask(ctx, ref, get); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Ask = %v; want DeadlineExceeded", err)
+	}
+}
+
+// TestShutdownLeavesFirst 停止从叶子开始，父actor在所有子actor停止后才停止
+func TestShutdownLeavesFirst(t *testing.T) {
+	sched := NewManual()
+	sys := NewSystem(Options{Scheduler: sched})
+	j := &journal{}
+	parent, _ := Spawn(sys, "parent", Props[msg]{New: func() Actor[msg] {
+		return &spawner{counter: counter{name: "parent", j: j}, children: []string{"a", "b"}}
+	}})
+	sched.RunUntilIdle()
+
+	sys.Stop()
+	sched.RunUntilIdle()
+
+	select {
+	case <-sys.Done():
+	default:
+		t.Fatal("system not stopped after RunUntilIdle")
+	}
+	stops := slices.DeleteFunc(slices.Clone(j.events), func(e string) bool { return e[len(e)-5:] != ":stop" })
+	if want := []string{"a:stop", "b:stop", "parent:stop"}; !slices.Equal(stops, want) {
+		t.Fatalf("stop order %v; want %v", stops, want)
+	}
+	if err := parent.Send(msg{op: "inc"}); !errors.Is(err, ErrStopped) {
+		t.Fatalf("Send after Shutdown = %v; want ErrStopped", err)
+	}
+	if n := sys.DeadLetters(); n != 1 {
+		t.Fatalf("DeadLetters = %d; want 1", n)
+	}
+}
+
+func TestShutdownGoroutines(t *testing.T) {
+	sys := NewSystem(Options{})
+	j := &journal{}
+	Spawn(sys, "parent", Props[msg]{New: func() Actor[msg] {
+		return &spawner{counter: counter{name: "parent", j: j}, children: []string{"a"}}
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := sys.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// spawner 在 PreStart 中创建子actor
+type spawner struct {
+	counter
+	children []string
+	sup      Strategy
+	refs     []*Ref[msg]
+}
+
+func (a *spawner) PreStart(ctx *Context[msg]) error {
+	a.j.add(a.name + ":start")
+	a.refs = nil
+	for _, name := range a.children {
+		ref, err := Spawn(ctx, name, Props[msg]{New: func() Actor[msg] { return &counter{name: name, j: a.j} }})
+		if err != nil {
+			return err
+		}
+		a.refs = append(a.refs, ref)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// ============================= 5. 请求/响应 ====================
+// 请求消息携带 Reply，actor 处理完后通过它回复；调用方通过 Future 等待
+
+package actor
+
+import "context"
+
+// Reply 请求消息中携带的回复句柄，只有第一次回复有效
+type Reply[R any] struct {
+	ch chan result[R]
+}
+
+type result[R any] struct {
+	val R
+	err error
+}
+
+// Resolve 回复结果
+func (r Reply[R]) Resolve(v R) {
+	r.send(result[R]{val: v})
+}
+
+// Reject 回复错误
+func (r Reply[R]) Reject(err error) {
+	r.send(result[R]{err: err})
+}
+
+func (r Reply[R]) send(res result[R]) {
+	select {
+	case r.ch <- res:
+	default: // 已经回复过
+	}
+}
+
+// Future 异步请求的结果，只能取一次
+type Future[R any] struct {
+	ch chan result[R]
+}
+
+// Get 等待回复，ctx 结束时返回 ctx.Err()
+func (f *Future[R]) Get(ctx context.Context) (R, error) {
+	select {
+	case res := <-f.ch:
+		return res.val, res.err
+	case <-ctx.Done():
+		var zero R
+		return zero, ctx.Err()
+	}
+}
+
+// TryGet 不等待，尚未回复时 ok 为 false；用于 Manual 调度器
+func (f *Future[R]) TryGet() (val R, err error, ok bool) {
+	select {
+	case res := <-f.ch:
+		return res.val, res.err, true
+	default:
+		return val, nil, false
+	}
+}
+
+// AskAsync 发送由 build 构造的请求消息，返回等待回复的 Future
+func AskAsync[M, R any](ref *Ref[M], build func(reply Reply[R]) M) (*Future[R], error) {
+	ch := make(chan result[R], 1)
+	if err := ref.Send(build(Reply[R]{ch: ch})); err != nil {
+		return nil, err
+	}
+	return &Future[R]{ch: ch}, nil
+}
+
+// Ask 发送请求并等待回复；actor 处理请求时崩溃不会回复，ctx 应带超时
+func Ask[M, R any](ctx context.Context, ref *Ref[M], build func(reply Reply[R]) M) (R, error) {
+	f, err := AskAsync(ref, build)
+	if err != nil {
+		var zero R
+		return zero, err
+	}
+	return f.Get(ctx)
+}
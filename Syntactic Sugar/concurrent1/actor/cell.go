@@ -0,0 +1,360 @@
+// ============================= 2. actor 运行时 ====================
+// 每个actor对应一个 cell：邮箱、子actor和当前实例
+// - 用户消息和系统消息（启动、停止、重启、子actor停止、上报错误）分两个队列，系统消息优先
+// - 同一时刻最多一个激活在运行，actor的状态只在激活中访问，不需要加锁
+// - 停止和重启都先停止所有子actor，等它们都停止后再继续，整棵子树按从下到上的顺序结束
+
+package actor
+
+import (
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+)
+
+type cellState int
+
+const (
+	running    cellState = iota
+	restarting           // 等待子actor停止后创建新实例
+	stopping             // 等待子actor停止后结束
+	stopped
+)
+
+type sysKind int
+
+const (
+	sysStart        sysKind = iota
+	sysStop                 // 停止
+	sysRestart              // AllForOne 中兄弟出错，跟随重启
+	sysFailed               // 子actor上报（Escalate）的错误
+	sysChildStopped         // 子actor已经停止
+	sysRecreate             // 重启时没有子actor需要等待，创建新实例
+)
+
+type sysMsg struct {
+	kind  sysKind
+	err   error
+	child *cell
+}
+
+// behavior 一个actor实例的消息处理和生命周期钩子
+type behavior struct {
+	receive     func(msg any) error
+	preStart    func() error
+	postStop    func()
+	preRestart  func(reason error)
+	postRestart func(reason error) error
+}
+
+type cell struct {
+	sys      *System
+	parent   *cell // 根监督者为 nil
+	name     string
+	path     string
+	capacity int      // 邮箱容量，0 表示不限制
+	strategy Strategy // 监督子actor的策略
+
+	newBehavior func() *behavior // 根监督者为 nil
+
+	mu        sync.Mutex
+	mailbox   []any
+	sysbox    []sysMsg
+	scheduled bool
+	state     cellState
+	children  []*cell // 按创建顺序，停止和重启时依次通知
+	autoName  int
+
+	// 以下字段只在激活中访问
+	behavior      *behavior
+	restarts      []time.Time
+	restartReason error
+
+	done chan struct{} // 停止后关闭
+}
+
+func newCell(sys *System, parent *cell, name string, capacity int, strategy Strategy) *cell {
+	path := "/" + name
+	if parent != nil && parent.parent != nil {
+		path = parent.path + "/" + name
+	}
+	return &cell{
+		sys:      sys,
+		parent:   parent,
+		name:     name,
+		path:     path,
+		capacity: capacity,
+		strategy: strategy,
+		done:     make(chan struct{}),
+	}
+}
+
+// addChild 登记子actor，name 为空时自动生成
+func (c *cell) addChild(child *cell) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state != running {
+		return ErrStopped
+	}
+	if child.name == "" {
+		c.autoName++
+		child.name = fmt.Sprintf("$%d", c.autoName)
+		child.path += child.name
+	}
+	if slices.ContainsFunc(c.children, func(other *cell) bool { return other.name == child.name }) {
+		return fmt.Errorf("%w: %s", ErrDuplicateName, child.path)
+	}
+	c.children = append(c.children, child)
+	return nil
+}
+
+// send 投递用户消息
+func (c *cell) send(msg any) error {
+	c.mu.Lock()
+	if c.state == stopping || c.state == stopped {
+		c.mu.Unlock()
+		c.sys.deadLetters.Add(1)
+		return ErrStopped
+	}
+	if c.capacity > 0 && len(c.mailbox) >= c.capacity {
+		c.mu.Unlock()
+		return ErrMailboxFull
+	}
+	c.mailbox = append(c.mailbox, msg)
+	c.scheduleLocked()
+	c.mu.Unlock()
+	return nil
+}
+
+// sendSystem 投递系统消息，已停止的actor忽略
+func (c *cell) sendSystem(m sysMsg) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == stopped {
+		return
+	}
+	c.sysbox = append(c.sysbox, m)
+	c.scheduleLocked()
+}
+
+func (c *cell) scheduleLocked() {
+	if !c.scheduled {
+		c.scheduled = true
+		c.sys.sched.Schedule(c.run)
+	}
+}
+
+// run 一次激活：先处理系统消息，运行状态下再处理用户消息
+func (c *cell) run() {
+	for range c.sys.throughput {
+		c.mu.Lock()
+		if len(c.sysbox) > 0 {
+			m := c.sysbox[0]
+			c.sysbox = c.sysbox[1:]
+			c.mu.Unlock()
+			c.handleSystem(m)
+			continue
+		}
+		if c.state != running || len(c.mailbox) == 0 {
+			c.mu.Unlock()
+			break
+		}
+		msg := c.mailbox[0]
+		c.mailbox[0] = nil
+		c.mailbox = c.mailbox[1:]
+		c.mu.Unlock()
+
+		if err := c.invoke(func() error { return c.behavior.receive(msg) }); err != nil {
+			c.fail(err)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.sysbox) > 0 || (c.state == running && len(c.mailbox) > 0) {
+		c.sys.sched.Schedule(c.run) // 还有消息，重新排队让其他actor也有机会运行
+		return
+	}
+	c.scheduled = false
+}
+
+// invoke 调用实例的方法，把panic转换为错误
+func (c *cell) invoke(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+func (c *cell) handleSystem(m sysMsg) {
+	switch m.kind {
+	case sysStart:
+		c.start()
+	case sysStop:
+		c.beginStop()
+	case sysRestart:
+		if c.currentState() == running {
+			c.beginRestart(m.err)
+		}
+	case sysFailed:
+		if c.currentState() == running {
+			c.fail(fmt.Errorf("child %s: %w", m.child.path, m.err))
+		}
+	case sysRecreate:
+		if c.currentState() == restarting {
+			c.finishRestart()
+		}
+	case sysChildStopped:
+		c.mu.Lock()
+		if i := slices.Index(c.children, m.child); i >= 0 {
+			c.children = slices.Delete(c.children, i, i+1)
+		}
+		remaining := len(c.children)
+		state := c.state
+		c.mu.Unlock()
+		if remaining > 0 {
+			return
+		}
+		switch state {
+		case restarting:
+			c.finishRestart()
+		case stopping:
+			c.finalize()
+		}
+	}
+}
+
+func (c *cell) currentState() cellState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// start 创建第一个实例并调用 PreStart
+func (c *cell) start() {
+	if c.newBehavior == nil || c.currentState() != running {
+		return
+	}
+	c.behavior = c.newBehavior()
+	if c.behavior.preStart != nil {
+		if err := c.invoke(c.behavior.preStart); err != nil {
+			c.fail(err)
+		}
+	}
+}
+
+// fail 按父actor的策略处理错误；只在激活中调用
+func (c *cell) fail(err error) {
+	if c.parent == nil {
+		c.beginStop() // 根监督者出错：停止整个系统
+		return
+	}
+
+	s := c.parent.strategy
+	d := s.decide(err)
+	if d == Restart && !c.allowRestart(s) {
+		d = Stop
+	}
+	if c.sys.onFailure != nil {
+		c.sys.onFailure(c.path, err, d)
+	}
+
+	switch d {
+	case Resume:
+	case Restart:
+		if s.AllForOne {
+			c.parent.mu.Lock()
+			for _, sibling := range c.parent.children {
+				if sibling != c {
+					sibling.sendSystem(sysMsg{kind: sysRestart, err: err})
+				}
+			}
+			c.parent.mu.Unlock()
+		}
+		c.beginRestart(err)
+	case Stop:
+		c.beginStop()
+	case Escalate:
+		c.parent.sendSystem(sysMsg{kind: sysFailed, err: err, child: c})
+	}
+}
+
+// beginRestart 通知旧实例，停止子actor；子actor都停止后创建新实例
+// 新实例总是在之后的系统消息中创建：PostRestart 再次失败时不会在同一个调用栈里递归重启
+func (c *cell) beginRestart(reason error) {
+	if c.behavior != nil && c.behavior.preRestart != nil {
+		c.invoke(func() error { c.behavior.preRestart(reason); return nil })
+	}
+	c.behavior = nil
+	c.restartReason = reason
+
+	c.mu.Lock()
+	c.state = restarting
+	children := slices.Clone(c.children)
+	c.mu.Unlock()
+	if len(children) == 0 {
+		c.sendSystem(sysMsg{kind: sysRecreate})
+		return
+	}
+	for _, child := range children {
+		child.sendSystem(sysMsg{kind: sysStop})
+	}
+}
+
+// finishRestart 创建新实例，调用 PostRestart（默认调用 PreStart）
+func (c *cell) finishRestart() {
+	c.mu.Lock()
+	c.state = running
+	c.mu.Unlock()
+
+	c.behavior = c.newBehavior()
+	reason := c.restartReason
+	c.restartReason = nil
+	if err := c.invoke(func() error { return c.behavior.postRestart(reason) }); err != nil {
+		c.fail(err)
+	}
+}
+
+// beginStop 停止子actor，子actor都停止后结束自己
+func (c *cell) beginStop() {
+	c.mu.Lock()
+	if c.state == stopping || c.state == stopped {
+		c.mu.Unlock()
+		return
+	}
+	c.state = stopping
+	children := slices.Clone(c.children)
+	c.mu.Unlock()
+
+	if len(children) == 0 {
+		c.finalize()
+		return
+	}
+	for _, child := range children {
+		child.sendSystem(sysMsg{kind: sysStop})
+	}
+}
+
+// finalize 调用 PostStop，剩余消息计入死信，通知父actor
+func (c *cell) finalize() {
+	if c.behavior != nil && c.behavior.postStop != nil {
+		c.invoke(func() error { c.behavior.postStop(); return nil })
+	}
+	c.behavior = nil
+
+	c.mu.Lock()
+	c.state = stopped
+	c.sys.deadLetters.Add(int64(len(c.mailbox)))
+	c.mailbox = nil
+	c.sysbox = nil
+	c.mu.Unlock()
+
+	close(c.done)
+	if c.parent != nil {
+		c.parent.sendSystem(sysMsg{kind: sysChildStopped, child: c})
+	}
+}
@@ -0,0 +1,69 @@
+// ============================= 4. 调度器 ====================
+// actor 平时不占用goroutine：邮箱从空变为非空时才提交一次"激活"给调度器，
+// 激活中最多处理 Throughput 条消息，处理不完再重新提交，保证actor之间的公平
+// Manual 调度器把激活排成队列，由调用者逐个执行，用于确定性的测试
+
+package actor
+
+import "sync"
+
+// Scheduler 执行actor的激活
+type Scheduler interface {
+	Schedule(run func())
+}
+
+// goScheduler 每次激活一个goroutine
+type goScheduler struct{}
+
+func (goScheduler) Schedule(run func()) {
+	go run()
+}
+
+// Manual 手动调度器：Schedule 只入队，Step/RunUntilIdle 在调用者的goroutine中按提交顺序执行
+// 所有actor都在同一个goroutine中运行，消息处理顺序完全确定
+type Manual struct {
+	mu    sync.Mutex
+	tasks []func()
+}
+
+// NewManual 创建手动调度器
+func NewManual() *Manual {
+	return &Manual{}
+}
+
+func (m *Manual) Schedule(run func()) {
+	m.mu.Lock()
+	m.tasks = append(m.tasks, run)
+	m.mu.Unlock()
+}
+
+// Step 执行一个激活，没有待执行的激活时返回 false
+func (m *Manual) Step() bool {
+	m.mu.Lock()
+	if len(m.tasks) == 0 {
+		m.mu.Unlock()
+		return false
+	}
+	run := m.tasks[0]
+	m.tasks = m.tasks[1:]
+	m.mu.Unlock()
+
+	run()
+	return true
+}
+
+// RunUntilIdle 执行激活直到没有actor需要运行，返回执行的激活数
+func (m *Manual) RunUntilIdle() int {
+	n := 0
+	for m.Step() {
+		n++
+	}
+	return n
+}
+
+// Pending 待执行的激活数
+func (m *Manual) Pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.tasks)
+}
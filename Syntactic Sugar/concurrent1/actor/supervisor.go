@@ -0,0 +1,89 @@
+// ============================= 3. 监督策略 ====================
+// actor 处理消息返回错误或panic时，由父actor的策略决定如何处理：
+// - Restart: 丢弃出错的消息，停止它的子actor，用新实例替换（状态重置）
+// - Resume:  丢弃出错的消息，保留当前状态继续处理
+// - Stop:    停止该actor及其子actor
+// - Escalate: 把错误交给父actor，按祖父的策略处理父actor
+// AllForOne 时一个子actor重启，所有兄弟一起重启，适合互相依赖的一组actor
+
+package actor
+
+import (
+	"fmt"
+	"time"
+)
+
+// Directive 监督指令
+type Directive int
+
+const (
+	Restart Directive = iota
+	Resume
+	Stop
+	Escalate
+)
+
+func (d Directive) String() string {
+	switch d {
+	case Restart:
+		return "restart"
+	case Resume:
+		return "resume"
+	case Stop:
+		return "stop"
+	case Escalate:
+		return "escalate"
+	default:
+		return fmt.Sprintf("Directive(%d)", int(d))
+	}
+}
+
+// Strategy 父actor监督子actor的策略，零值为 OneForOne、总是重启、不限次数
+type Strategy struct {
+	AllForOne bool
+	Decide    func(err error) Directive // 为 nil 时总是 Restart
+
+	// Within 时间内重启超过 MaxRestarts 次时改为停止，防止崩溃循环
+	// MaxRestarts<=0 不限次数，Within<=0 不限时间窗口；时间取自 Options.Now
+	MaxRestarts int
+	Within      time.Duration
+}
+
+func (s Strategy) decide(err error) Directive {
+	if s.Decide == nil {
+		return Restart
+	}
+	return s.Decide(err)
+}
+
+// PanicError Receive 或生命周期钩子panic被恢复后转换成的错误
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("actor: panic: %v", e.Value)
+}
+
+// allowRestart 记录一次重启，超出 MaxRestarts 时返回 false；只在actor自己的激活中调用
+func (c *cell) allowRestart(s Strategy) bool {
+	if s.MaxRestarts <= 0 {
+		return true
+	}
+	now := c.sys.now()
+	if s.Within > 0 {
+		kept := c.restarts[:0]
+		for _, t := range c.restarts {
+			if now.Sub(t) < s.Within {
+				kept = append(kept, t)
+			}
+		}
+		c.restarts = kept
+	}
+	if len(c.restarts) >= s.MaxRestarts {
+		return false
+	}
+	c.restarts = append(c.restarts, now)
+	return true
+}
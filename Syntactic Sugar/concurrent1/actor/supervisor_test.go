@@ -0,0 +1,185 @@
+package actor
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// failures 记录 OnFailure 收到的指令
+type failures struct{ directives []Directive }
+
+func (f *failures) record(path string, err error, d Directive) {
+	f.directives = append(f.directives, d)
+}
+
+func newManualSystem(f *failures, guardian Strategy, now func() time.Time) (*System, *Manual) {
+	sched := NewManual()
+	return NewSystem(Options{Scheduler: sched, Guardian: guardian, OnFailure: f.record, Now: now}), sched
+}
+
+func isStopped(ref *Ref[msg]) bool {
+	select {
+	case <-ref.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	tests := []struct {
+		directive Directive
+		count     int  // 出错后 get 的结果
+		stopped   bool // 是否已停止
+	}{
+		{Restart, 0, false}, // 新实例，状态重置
+		{Resume, 2, false},  // 保留状态
+		{Stop, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.directive.String(), func(t *testing.T) {
+			var f failures
+			sys, sched := newManualSystem(&f, Strategy{Decide: func(error) Directive { return tt.directive }}, nil)
+			ref := spawnCounter(t, sys, "c", &journal{}, Strategy{})
+
+			ref.Send(msg{op: "inc"})
+			ref.Send(msg{op: "inc"})
+			ref.Send(msg{op: "panic"})
+			sched.RunUntilIdle()
+
+			if !slices.Equal(f.directives, []Directive{tt.directive}) {
+				t.Fatalf("OnFailure directives = %v; want [%v]", f.directives, tt.directive)
+			}
+			if got := isStopped(ref); got != tt.stopped {
+				t.Fatalf("stopped = %t; want %t", got, tt.stopped)
+			}
+			if tt.stopped {
+				return
+			}
+			if v, _ := askManual(t, sched, ref); v != tt.count {
+				t.Fatalf("count after failure = %d; want %d", v, tt.count)
+			}
+		})
+	}
+}
+
+// TestRestartLifecycle 重启先调用旧实例的 PreRestart、停止子actor，再由新实例重新创建子actor
+func TestRestartLifecycle(t *testing.T) {
+	var f failures
+	sys, sched := newManualSystem(&f, Strategy{}, nil)
+	j := &journal{}
+	parent, _ := Spawn(sys, "p", Props[msg]{New: func() Actor[msg] {
+		return &spawner{counter: counter{name: "p", j: j}, children: []string{"a"}}
+	}})
+	sched.RunUntilIdle()
+
+	j.events = nil
+	parent.Send(msg{op: "fail"})
+	sched.RunUntilIdle()
+
+	want := []string{"p:pre-restart", "a:stop", "p:start", "a:start"}
+	if !slices.Equal(j.events, want) {
+		t.Fatalf("events %v; want %v", j.events, want)
+	}
+}
+
+// TestEscalate 子actor上报错误，按祖父的策略重启父actor
+func TestEscalate(t *testing.T) {
+	var f failures
+	sys, sched := newManualSystem(&f, Strategy{}, nil)
+	j := &journal{}
+	var p *spawner
+	Spawn(sys, "p", Props[msg]{
+		New: func() Actor[msg] {
+			p = &spawner{counter: counter{name: "p", j: j}, children: []string{"a"}}
+			return p
+		},
+		Supervisor: Strategy{Decide: func(error) Directive { return Escalate }},
+	})
+	sched.RunUntilIdle()
+
+	j.events = nil
+	p.refs[0].Send(msg{op: "fail"})
+	sched.RunUntilIdle()
+
+	if want := []Directive{Escalate, Restart}; !slices.Equal(f.directives, want) {
+		t.Fatalf("directives %v; want %v", f.directives, want)
+	}
+	if want := []string{"p:pre-restart", "a:stop", "p:start", "a:start"}; !slices.Equal(j.events, want) {
+		t.Fatalf("events %v; want %v", j.events, want)
+	}
+}
+
+// TestAllForOne 一个子actor重启时兄弟一起重启
+func TestAllForOne(t *testing.T) {
+	var f failures
+	sys, sched := newManualSystem(&f, Strategy{AllForOne: true}, nil)
+	j := &journal{}
+	a := spawnCounter(t, sys, "a", j, Strategy{})
+	b := spawnCounter(t, sys, "b", j, Strategy{})
+	b.Send(msg{op: "inc"})
+	sched.RunUntilIdle()
+
+	a.Send(msg{op: "fail"})
+	sched.RunUntilIdle()
+
+	if v, _ := askManual(t, sched, b); v != 0 {
+		t.Fatalf("sibling count = %d; want 0 after restart", v)
+	}
+	restarts := slices.DeleteFunc(slices.Clone(j.events), func(e string) bool { return e != "a:pre-restart" && e != "b:pre-restart" })
+	if len(restarts) != 2 {
+		t.Fatalf("pre-restart events %v; want both a and b", restarts)
+	}
+}
+
+// TestMaxRestartsWithin 用注入的时钟控制重启窗口：窗口内超过次数改为停止，窗口过后重新计数
+func TestMaxRestartsWithin(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var f failures
+	sys, sched := newManualSystem(&f, Strategy{MaxRestarts: 2, Within: time.Minute}, func() time.Time { return now })
+	ref := spawnCounter(t, sys, "c", &journal{}, Strategy{})
+	sched.RunUntilIdle()
+
+	for range 2 {
+		ref.Send(msg{op: "fail"})
+		sched.RunUntilIdle()
+	}
+	now = now.Add(2 * time.Minute) // 之前的重启移出窗口
+	for range 2 {
+		ref.Send(msg{op: "fail"})
+		sched.RunUntilIdle()
+	}
+	if isStopped(ref) {
+		t.Fatalf("stopped after 4 restarts spread over two windows; directives %v", f.directives)
+	}
+
+	ref.Send(msg{op: "fail"})
+	sched.RunUntilIdle()
+	if !isStopped(ref) {
+		t.Fatal("still running after exceeding MaxRestarts within the window")
+	}
+	want := []Directive{Restart, Restart, Restart, Restart, Stop}
+	if !slices.Equal(f.directives, want) {
+		t.Fatalf("directives %v; want %v", f.directives, want)
+	}
+}
+
+// TestRestartHookAlwaysFails PreStart 每次都失败时逐次重启直到超出次数，不会在同一个调用栈里递归
+func TestRestartHookAlwaysFails(t *testing.T) {
+	const maxRestarts = 10000
+	var f failures
+	sys, sched := newManualSystem(&f, Strategy{MaxRestarts: maxRestarts}, nil)
+	ref, _ := Spawn(sys, "c", Props[msg]{New: func() Actor[msg] {
+		return &counter{name: "c", j: &journal{}, startErr: errors.New("no database")}
+	}})
+	sched.RunUntilIdle()
+
+	if !isStopped(ref) {
+		t.Fatal("actor with always-failing PreStart still running")
+	}
+	if n := len(f.directives); n != maxRestarts+1 || f.directives[n-1] != Stop {
+		t.Fatalf("%d failures, last %v; want %d restarts then stop", n, f.directives[n-1], maxRestarts)
+	}
+}
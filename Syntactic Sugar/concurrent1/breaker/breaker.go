@@ -0,0 +1,315 @@
+// ============================= 1. 熔断器状态机 ====================
+// Closed(闭合)   正常放行，统计失败；达到跳闸条件后转为 Open
+// Open(断开)     直接拒绝调用，冷却 CoolDown 后转为 HalfOpen
+// HalfOpen(半开) 只放行少量探测请求：全部成功则 Closed，任一失败则重新 Open
+//
+// 跳闸条件（满足任一即可）：
+// - 连续失败次数达到 ConsecutiveFailures
+// - 统计窗口内请求数不少于 MinRequests 且失败率达到 FailureRatio
+
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrOpen 熔断器处于断开状态，调用被拒绝
+var ErrOpen = errors.New("breaker: circuit open")
+
+// ErrTooManyProbes 半开状态下探测请求已满
+var ErrTooManyProbes = errors.New("breaker: too many half-open probes")
+
+// State 熔断器状态
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Settings 熔断器配置，零值字段使用默认值
+type Settings struct {
+	Name                string
+	ConsecutiveFailures int           // 连续失败多少次跳闸，默认5，<0 表示不启用
+	FailureRatio        float64       // 失败率阈值(0,1]，0 表示不启用
+	MinRequests         int           // 失败率生效的最少请求数，默认10
+	Window              time.Duration // Closed 状态的统计窗口，默认10秒
+	CoolDown            time.Duration // Open 持续多久后开始探测，默认5秒
+	HalfOpenProbes      int           // 半开状态最多同时放行的探测数，默认1
+
+	// IsFailure 判断错误是否计为失败，默认 err != nil
+	// 调用方主动取消(context.Canceled)的调用既不算成功也不算失败
+	IsFailure func(err error) bool
+	// OnStateChange 状态变化回调，在锁外同步调用
+	OnStateChange func(name string, from, to State)
+}
+
+// Counts 当前统计窗口内的计数
+type Counts struct {
+	Requests             int
+	Successes            int
+	Failures             int
+	ConsecutiveFailures  int
+	ConsecutiveSuccesses int
+}
+
+// Breaker 熔断器，并发安全
+type Breaker struct {
+	settings Settings
+	now      func() time.Time
+
+	mu         sync.Mutex
+	state      State
+	generation uint64 // 每次状态变化或窗口重置时递增，过期的结果会被忽略
+	counts     Counts
+	expiry     time.Time // Closed: 窗口结束时间；Open: 冷却结束时间
+	probes     int       // 半开状态下进行中的探测数
+	pending    []func()  // 待触发的状态变化回调，解锁后执行
+}
+
+// New 创建熔断器
+func New(s Settings) *Breaker {
+	if s.ConsecutiveFailures == 0 {
+		s.ConsecutiveFailures = 5
+	}
+	if s.MinRequests <= 0 {
+		s.MinRequests = 10
+	}
+	if s.Window <= 0 {
+		s.Window = 10 * time.Second
+	}
+	if s.CoolDown <= 0 {
+		s.CoolDown = 5 * time.Second
+	}
+	if s.HalfOpenProbes <= 0 {
+		s.HalfOpenProbes = 1
+	}
+	if s.IsFailure == nil {
+		s.IsFailure = func(err error) bool {
+			return err != nil
+		}
+	}
+
+	b := &Breaker{settings: s, now: time.Now}
+	b.expiry = b.now().Add(s.Window)
+	return b
+}
+
+// ============================= 2. 执行调用 ====================
+
+// Execute 在熔断器保护下执行 fn
+func (b *Breaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	gen, err := b.before()
+	if err != nil {
+		return err
+	}
+
+	var panicked = true
+	defer func() {
+		if panicked {
+			b.after(gen, false) // panic 计为失败后继续向上传播
+		}
+	}()
+
+	err = fn(ctx)
+	panicked = false
+	if ctx.Err() != nil && errors.Is(err, context.Canceled) {
+		b.release(gen)
+	} else {
+		b.after(gen, !b.settings.IsFailure(err))
+	}
+	return err
+}
+
+// Call Execute 的泛型版本，返回 fn 的结果
+func Call[T any](ctx context.Context, b *Breaker, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := b.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
+
+// before 调用前检查状态，返回本次调用所属的代
+func (b *Breaker) before() (uint64, error) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	b.refresh(b.now())
+	switch b.state {
+	case StateOpen:
+		return b.generation, ErrOpen
+	case StateHalfOpen:
+		if b.probes >= b.settings.HalfOpenProbes {
+			return b.generation, ErrTooManyProbes
+		}
+		b.probes++
+	}
+	b.counts.Requests++
+	return b.generation, nil
+}
+
+// after 记录调用结果，已过期的代直接忽略
+func (b *Breaker) after(gen uint64, success bool) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	now := b.now()
+	b.refresh(now)
+	if gen != b.generation {
+		return
+	}
+
+	if success {
+		b.counts.Successes++
+		b.counts.ConsecutiveSuccesses++
+		b.counts.ConsecutiveFailures = 0
+		if b.state == StateHalfOpen {
+			b.probes--
+			if b.counts.ConsecutiveSuccesses >= b.settings.HalfOpenProbes {
+				b.setState(StateClosed, now)
+			}
+		}
+		return
+	}
+
+	b.counts.Failures++
+	b.counts.ConsecutiveFailures++
+	b.counts.ConsecutiveSuccesses = 0
+	switch b.state {
+	case StateHalfOpen:
+		b.setState(StateOpen, now)
+	case StateClosed:
+		if b.shouldTrip() {
+			b.setState(StateOpen, now)
+		}
+	}
+}
+
+// release 归还调用名额但不计入成功或失败
+func (b *Breaker) release(gen uint64) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	if gen != b.generation {
+		return
+	}
+	b.counts.Requests--
+	if b.state == StateHalfOpen {
+		b.probes--
+	}
+}
+
+// ============================= 3. 状态转换 ====================
+
+// shouldTrip 判断 Closed 状态是否满足跳闸条件
+func (b *Breaker) shouldTrip() bool {
+	s, c := b.settings, b.counts
+	if s.ConsecutiveFailures > 0 && c.ConsecutiveFailures >= s.ConsecutiveFailures {
+		return true
+	}
+	if s.FailureRatio > 0 && c.Requests >= s.MinRequests {
+		return float64(c.Failures)/float64(c.Requests) >= s.FailureRatio
+	}
+	return false
+}
+
+// refresh 处理时间驱动的变化：Closed 窗口到期重置计数，Open 冷却结束转为 HalfOpen
+func (b *Breaker) refresh(now time.Time) {
+	if now.Before(b.expiry) {
+		return
+	}
+	switch b.state {
+	case StateClosed:
+		b.resetCounts(now.Add(b.settings.Window))
+	case StateOpen:
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+func (b *Breaker) setState(to State, now time.Time) {
+	from := b.state
+	if from == to {
+		return
+	}
+	b.state = to
+
+	switch to {
+	case StateClosed:
+		b.resetCounts(now.Add(b.settings.Window))
+	case StateOpen:
+		b.resetCounts(now.Add(b.settings.CoolDown))
+	case StateHalfOpen:
+		b.resetCounts(time.Time{})
+	}
+
+	if hook := b.settings.OnStateChange; hook != nil {
+		name := b.settings.Name
+		b.pending = append(b.pending, func() { hook(name, from, to) })
+	}
+}
+
+// resetCounts 开始新的一代，之前发起的调用结果将被忽略
+func (b *Breaker) resetCounts(expiry time.Time) {
+	b.generation++
+	b.counts = Counts{}
+	b.probes = 0
+	b.expiry = expiry
+}
+
+// unlock 解锁后再触发状态回调，回调中可以安全地调用 State 等方法
+func (b *Breaker) unlock() {
+	pending := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	for _, fn := range pending {
+		fn()
+	}
+}
+
+// ============================= 4. 状态查询 ====================
+
+// State 返回当前状态（会先处理到期的状态变化）
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.unlock()
+	b.refresh(b.now())
+	return b.state
+}
+
+// Counts 返回当前统计窗口内的计数
+func (b *Breaker) Counts() Counts {
+	b.mu.Lock()
+	defer b.unlock()
+	b.refresh(b.now())
+	return b.counts
+}
+
+// Name 熔断器名称
+func (b *Breaker) Name() string {
+	return b.settings.Name
+}
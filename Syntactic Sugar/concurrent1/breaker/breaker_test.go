@@ -0,0 +1,248 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+var errFail = errors.New("fail")
+
+// fakeNow 可手动推进的时间来源
+type fakeNow struct {
+	t time.Time
+}
+
+func (f *fakeNow) now() time.Time { return f.t }
+
+func (f *fakeNow) advance(d time.Duration) { f.t = f.t.Add(d) }
+
+// newFake 创建使用假时间的熔断器
+func newFake(s Settings) (*Breaker, *fakeNow) {
+	f := &fakeNow{t: time.Unix(1000, 0)}
+	b := New(s)
+	b.now = f.now
+	b.expiry = f.now().Add(b.settings.Window)
+	return b, f
+}
+
+// run 执行一次调用，fn 返回给定的错误
+func run(b *Breaker, err error) error {
+	return b.Execute(context.Background(), func(context.Context) error { return err })
+}
+
+func TestTripPolicies(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings Settings
+		results  []error // 依次执行的调用结果
+		want     State
+	}{
+		{"Consecutive", Settings{ConsecutiveFailures: 3}, []error{errFail, errFail, errFail}, StateOpen},
+		{"ConsecutiveReset", Settings{ConsecutiveFailures: 3}, []error{errFail, errFail, nil, errFail, errFail}, StateClosed},
+		{"DefaultFive", Settings{}, []error{errFail, errFail, errFail, errFail}, StateClosed},
+		{"RatioBelowMin", Settings{ConsecutiveFailures: -1, FailureRatio: 0.5, MinRequests: 4}, []error{nil, errFail, errFail}, StateClosed},
+		{"Ratio", Settings{ConsecutiveFailures: -1, FailureRatio: 0.5, MinRequests: 4}, []error{nil, errFail, nil, errFail}, StateOpen},
+		{"RatioNotReached", Settings{ConsecutiveFailures: -1, FailureRatio: 0.5, MinRequests: 4}, []error{nil, nil, errFail, nil, errFail}, StateClosed},
+		{"Disabled", Settings{ConsecutiveFailures: -1}, []error{errFail, errFail, errFail, errFail, errFail, errFail}, StateClosed},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, _ := newFake(c.settings)
+			for _, err := range c.results {
+				run(b, err)
+			}
+			if got := b.State(); got != c.want {
+				t.Errorf("State = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestWindowReset(t *testing.T) {
+	b, f := newFake(Settings{ConsecutiveFailures: 3, Window: time.Second})
+	run(b, errFail)
+	run(b, errFail)
+	if c := b.Counts(); c.Requests != 2 || c.Failures != 2 {
+		t.Fatalf("Counts = %+v", c)
+	}
+
+	// 窗口到期后计数清零，之前的失败不再累计
+	f.advance(time.Second)
+	if c := b.Counts(); c != (Counts{}) {
+		t.Fatalf("Counts after window = %+v, want zero", c)
+	}
+	run(b, errFail)
+	if got := b.State(); got != StateClosed {
+		t.Errorf("State = %v, want closed", got)
+	}
+}
+
+func TestOpenAndCoolDown(t *testing.T) {
+	var transitions []string
+	b, f := newFake(Settings{
+		Name:                "db",
+		ConsecutiveFailures: 1,
+		CoolDown:            5 * time.Second,
+		OnStateChange: func(name string, from, to State) {
+			transitions = append(transitions, name+":"+from.String()+"->"+to.String())
+		},
+	})
+	run(b, errFail)
+
+	// 断开时不执行 fn
+	called := false
+	err := b.Execute(context.Background(), func(context.Context) error {
+		called = true
+		return nil
+	})
+	if err != ErrOpen || called {
+		t.Fatalf("Execute while open = %v, called %t; want ErrOpen without calling", err, called)
+	}
+
+	f.advance(5*time.Second - time.Nanosecond)
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("State before cool-down ends = %v", got)
+	}
+	f.advance(time.Nanosecond)
+	if got := b.State(); got != StateHalfOpen {
+		t.Fatalf("State after cool-down = %v", got)
+	}
+
+	// 探测失败：重新断开并开始新的冷却
+	run(b, errFail)
+	f.advance(4 * time.Second)
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("State after failed probe = %v, want open", got)
+	}
+	f.advance(time.Second)
+	if err := run(b, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("State after successful probe = %v, want closed", got)
+	}
+
+	want := []string{
+		"db:closed->open", "db:open->half-open", "db:half-open->open",
+		"db:open->half-open", "db:half-open->closed",
+	}
+	if !slices.Equal(transitions, want) {
+		t.Errorf("transitions = %v, want %v", transitions, want)
+	}
+}
+
+func TestHalfOpenProbes(t *testing.T) {
+	b, f := newFake(Settings{ConsecutiveFailures: 1, CoolDown: time.Second, HalfOpenProbes: 2})
+	run(b, errFail)
+	f.advance(time.Second)
+
+	// 探测在 fn 中嵌套发起，模拟同时进行的请求
+	var third error
+	err := b.Execute(context.Background(), func(context.Context) error {
+		return b.Execute(context.Background(), func(context.Context) error {
+			third = run(b, nil)
+			return nil
+		})
+	})
+	if err != nil || third != ErrTooManyProbes {
+		t.Fatalf("probes = %v, third = %v; want nil, ErrTooManyProbes", err, third)
+	}
+	if got := b.State(); got != StateClosed {
+		t.Errorf("State after 2 successful probes = %v, want closed", got)
+	}
+
+	// 只有一个探测成功时保持半开
+	run(b, errFail)
+	f.advance(time.Second)
+	run(b, nil)
+	if got := b.State(); got != StateHalfOpen {
+		t.Errorf("State after 1 of 2 probes = %v, want half-open", got)
+	}
+}
+
+func TestCanceledNotCounted(t *testing.T) {
+	b, f := newFake(Settings{ConsecutiveFailures: 1, CoolDown: time.Second})
+
+	// 调用方取消：既不算成功也不算失败
+	ctx, cancel := context.WithCancel(context.Background())
+	err := b.Execute(ctx, func(ctx context.Context) error {
+		cancel()
+		return ctx.Err()
+	})
+	if err != context.Canceled {
+		t.Fatalf("Execute = %v, want context.Canceled", err)
+	}
+	if c := b.Counts(); c != (Counts{}) || b.State() != StateClosed {
+		t.Fatalf("after cancel: Counts = %+v, State = %v", c, b.State())
+	}
+
+	// 已取消的 ctx 直接返回，不占用名额
+	if err := b.Execute(ctx, func(context.Context) error { return nil }); err != context.Canceled {
+		t.Fatalf("Execute with canceled ctx = %v", err)
+	}
+
+	// 超时计为失败
+	dctx, dcancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer dcancel()
+	<-dctx.Done()
+	b.Execute(context.Background(), func(context.Context) error { return dctx.Err() })
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("State after deadline exceeded = %v, want open", got)
+	}
+
+	// 半开状态下取消的探测归还名额
+	f.advance(time.Second)
+	ctx, cancel = context.WithCancel(context.Background())
+	b.Execute(ctx, func(ctx context.Context) error {
+		cancel()
+		return ctx.Err()
+	})
+	if err := run(b, nil); err != nil {
+		t.Fatalf("probe after canceled probe = %v", err)
+	}
+	if got := b.State(); got != StateClosed {
+		t.Errorf("State = %v, want closed", got)
+	}
+}
+
+func TestStaleResultIgnored(t *testing.T) {
+	b, f := newFake(Settings{ConsecutiveFailures: 1, CoolDown: time.Second})
+
+	// 慢调用在 Closed 时发起，返回前熔断器已经历跳闸→探测→恢复；
+	// 它的失败属于旧的代，不会让恢复后的熔断器再次跳闸
+	b.Execute(context.Background(), func(context.Context) error {
+		run(b, errFail)
+		f.advance(time.Second)
+		run(b, nil)
+		return errFail
+	})
+	if got := b.State(); got != StateClosed {
+		t.Errorf("State = %v, want closed", got)
+	}
+}
+
+func TestPanicCountsAsFailure(t *testing.T) {
+	b, _ := newFake(Settings{ConsecutiveFailures: 1})
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recover = %v, want boom", r)
+			}
+		}()
+		b.Execute(context.Background(), func(context.Context) error { panic("boom") })
+	}()
+	if got := b.State(); got != StateOpen {
+		t.Errorf("State after panic = %v, want open", got)
+	}
+}
+
+func TestCall(t *testing.T) {
+	b, _ := newFake(Settings{})
+	v, err := Call(context.Background(), b, func(context.Context) (int, error) { return 42, nil })
+	if v != 42 || err != nil {
+		t.Errorf("Call = %d, %v", v, err)
+	}
+}
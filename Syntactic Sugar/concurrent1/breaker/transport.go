@@ -0,0 +1,70 @@
+// ============================= 5. HTTP 传输层包装 ====================
+// Transport 实现 http.RoundTripper，让 http.Client 的所有请求都经过熔断器
+// 默认把网络错误和 5xx 响应计为失败
+
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Transport 带熔断保护的 http.RoundTripper
+type Transport struct {
+	Breaker *Breaker
+	Base    http.RoundTripper // 为 nil 时使用 http.DefaultTransport
+
+	// IsFailure 判断一次往返是否计为失败，默认网络错误或状态码 >= 500
+	// err 为 RoundTrip 返回的传输错误，此时 resp 通常为 nil；
+	// 对传输错误返回 false 时该次调用计为成功，错误仍原样返回给调用方
+	IsFailure func(resp *http.Response, err error) bool
+}
+
+// statusError 把失败的响应转换为错误，供熔断器统计
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("breaker: upstream status %d", e.code)
+}
+
+// RoundTrip 在熔断器保护下发送请求；熔断时返回 ErrOpen，不会发出请求
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	isFailure := t.IsFailure
+	if isFailure == nil {
+		isFailure = func(resp *http.Response, err error) bool {
+			return err != nil || resp.StatusCode >= http.StatusInternalServerError
+		}
+	}
+
+	var (
+		resp  *http.Response
+		rtErr error
+	)
+	err := t.Breaker.Execute(req.Context(), func(ctx context.Context) error {
+		resp, rtErr = base.RoundTrip(req)
+		if !isFailure(resp, rtErr) {
+			return nil
+		}
+		if rtErr != nil {
+			return rtErr
+		}
+		return &statusError{code: resp.StatusCode}
+	})
+
+	// 失败的响应仍然交给调用方处理，只有传输错误和熔断才返回错误
+	if _, ok := err.(*statusError); ok {
+		return resp, nil
+	}
+	if err == nil && rtErr != nil {
+		// IsFailure 认为不算失败的传输错误
+		return resp, rtErr
+	}
+	return resp, err
+}
@@ -0,0 +1,113 @@
+package breaker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// roundTripFunc 用函数实现 http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// stubTransport 按请求路径返回状态码，"/err" 返回传输错误
+func stubTransport(hits *int) http.RoundTripper {
+	return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		*hits++
+		switch r.URL.Path {
+		case "/err":
+			return nil, errFail
+		case "/500":
+			return &http.Response{StatusCode: 500, Request: r}, nil
+		case "/503":
+			return &http.Response{StatusCode: 503, Request: r}, nil
+		case "/404":
+			return &http.Response{StatusCode: 404, Request: r}, nil
+		}
+		return &http.Response{StatusCode: 200, Request: r}, nil
+	})
+}
+
+func get(rt http.RoundTripper, path string) (*http.Response, error) {
+	return rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://upstream"+path, nil))
+}
+
+func TestTransportClassification(t *testing.T) {
+	cases := []struct {
+		path    string
+		failure bool
+		err     error
+	}{
+		{"/200", false, nil},
+		{"/404", false, nil},
+		{"/500", true, nil},
+		{"/503", true, nil},
+		{"/err", true, errFail},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			var hits int
+			b, _ := newFake(Settings{ConsecutiveFailures: 1})
+			rt := &Transport{Breaker: b, Base: stubTransport(&hits)}
+
+			resp, err := get(rt, c.path)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("RoundTrip err = %v, want %v", err, c.err)
+			}
+			// 5xx 响应原样交给调用方
+			if c.err == nil && (resp == nil || resp.Request.URL.Path != c.path) {
+				t.Fatalf("RoundTrip resp = %v", resp)
+			}
+			if got := b.State() == StateOpen; got != c.failure {
+				t.Errorf("counted as failure = %t, want %t", got, c.failure)
+			}
+		})
+	}
+}
+
+func TestTransportOpen(t *testing.T) {
+	var hits int
+	b, _ := newFake(Settings{ConsecutiveFailures: 2})
+	rt := &Transport{Breaker: b, Base: stubTransport(&hits)}
+	get(rt, "/503")
+	get(rt, "/500")
+
+	// 熔断后不再发出请求
+	resp, err := get(rt, "/200")
+	if err != ErrOpen || resp != nil || hits != 2 {
+		t.Errorf("RoundTrip while open = %v, %v, hits %d; want ErrOpen, nil, 2", resp, err, hits)
+	}
+}
+
+func TestTransportCustomIsFailure(t *testing.T) {
+	var hits int
+	b, _ := newFake(Settings{ConsecutiveFailures: 1})
+	var sawErr error
+	rt := &Transport{
+		Breaker: b,
+		Base:    stubTransport(&hits),
+		// 只把 503 计为失败，传输错误不计
+		IsFailure: func(resp *http.Response, err error) bool {
+			if err != nil {
+				sawErr = err
+				return false
+			}
+			return resp.StatusCode == http.StatusServiceUnavailable
+		},
+	}
+
+	// 不计为失败的传输错误仍原样返回
+	if _, err := get(rt, "/err"); err != errFail || sawErr != errFail {
+		t.Fatalf("RoundTrip = %v, IsFailure saw %v; want %v", err, sawErr, errFail)
+	}
+	get(rt, "/500")
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("State after ignored failures = %v, want closed", got)
+	}
+	get(rt, "/503")
+	if got := b.State(); got != StateOpen {
+		t.Errorf("State after 503 = %v, want open", got)
+	}
+}
@@ -2,9 +2,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"Syntactic_Sugar/concurrent1/actor"
+	"Syntactic_Sugar/concurrent1/eventbus"
+	"Syntactic_Sugar/concurrent1/leakcheck"
+	"Syntactic_Sugar/concurrent1/pipeline"
+	"Syntactic_Sugar/concurrent1/workerpool"
 )
 
 func unbufferedChannelDemo() {
@@ -211,6 +221,7 @@ func channelAsSyncTool() {
 	}
 
 	time.Sleep(500 * time.Millisecond) // 等待所有goroutine完成
+	lock <- struct{}{}                 // 读取前同样要获取锁，只靠 Sleep 不能保证看到其他goroutine的写入
 	fmt.Printf("最终计数: %d\n", counter)
 	close(lock)
 }
@@ -281,8 +292,327 @@ func goodWorker(wg *sync.WaitGroup) {
 	fmt.Println("goodWorker执行")
 }
 
+// ============================= 10. WorkerPool工作池 ====================
+func workerPoolDemo() {
+	fmt.Println("\n=== WorkerPool工作池演示 ===")
+
+	// 10.1 2个常驻worker，最多扩容到4个，队列容量4，单任务超时300ms
+	pool := workerpool.New[int](workerpool.Options{
+		Workers:     2,
+		MaxWorkers:  4,
+		QueueSize:   4,
+		TaskTimeout: 300 * time.Millisecond,
+	})
+
+	// 10.2 提交任务，代替手动 wg.Add + go func
+	ctx := context.Background()
+	var futures []workerpool.Future[int]
+	for i := 0; i < 6; i++ {
+		future, err := pool.Submit(ctx, func(ctx context.Context) (int, error) {
+			cost := time.Duration(100+i*50) * time.Millisecond
+			select {
+			case <-time.After(cost):
+				return i * i, nil
+			case <-ctx.Done():
+				return 0, ctx.Err() // 超过单任务超时
+			}
+		})
+		if err != nil {
+			fmt.Println("提交失败:", err)
+			continue
+		}
+		futures = append(futures, future)
+	}
+	fmt.Printf("提交后指标: %+v\n", pool.Stats())
+
+	// 10.3 通过Future获取结果
+	for i, future := range futures {
+		val, err := future.Get(ctx)
+		if errors.Is(err, context.DeadlineExceeded) {
+			fmt.Printf("任务 %d 超时\n", i)
+			continue
+		}
+		fmt.Printf("任务 %d 结果: %d\n", i, val)
+	}
+
+	// 10.4 优雅关闭：排空队列后退出
+	shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := pool.Shutdown(shutdownCtx); err != nil {
+		fmt.Println("关闭超时:", err)
+	}
+	if _, err := pool.Submit(ctx, func(context.Context) (int, error) { return 0, nil }); err != nil {
+		fmt.Println("关闭后提交:", err)
+	}
+	fmt.Printf("最终指标: %+v\n", pool.Stats())
+}
+
+// ============================= 11. Pipeline多阶段流水线 ====================
+func pipelineDemo() {
+	fmt.Println("\n=== Pipeline多阶段流水线演示 ===")
+
+	// 11.1 Source → 平方(3个worker，有序) → 格式化 → Sink
+	p := pipeline.New(context.Background())
+	lines := pipeline.FromSlice(p, "source", []string{"1", "2", "3", "4", "5", "6"})
+
+	squares := pipeline.Stage(lines, "square", pipeline.Options{Workers: 3, Buffer: 2, Ordered: true},
+		func(ctx context.Context, line string) (int, error) {
+			var n int
+			fmt.Sscan(line, &n)
+			time.Sleep(time.Duration(7-n) * 10 * time.Millisecond) // 前面的元素更慢
+			return n * n, nil
+		})
+
+	formatted := pipeline.Stage(squares, "format", pipeline.Options{},
+		func(ctx context.Context, n int) (string, error) {
+			return fmt.Sprintf("<%d>", n), nil
+		})
+
+	var results []string
+	pipeline.Sink(formatted, "sink", pipeline.Options{}, func(ctx context.Context, s string) error {
+		results = append(results, s)
+		return nil
+	})
+
+	if err := p.Wait(); err != nil {
+		fmt.Println("流水线失败:", err)
+	}
+	fmt.Println("有序输出:", strings.Join(results, " "))
+	for _, st := range p.Stats() {
+		fmt.Printf("  阶段 %-6s 输入=%d 输出=%d 错误=%d 吞吐=%.0f/s\n",
+			st.Name, st.In, st.Out, st.Errors, st.Throughput)
+	}
+
+	// 11.2 错误短路：任一阶段出错会取消整个流水线
+	p2 := pipeline.New(context.Background())
+	nums := pipeline.Generate(p2, "counter", pipeline.Options{}, func(ctx context.Context, emit func(int) bool) error {
+		for i := 0; ; i++ {
+			if !emit(i) {
+				return nil // 下游出错，停止生产
+			}
+		}
+	})
+	checked := pipeline.Stage(nums, "check", pipeline.Options{Workers: 2}, func(ctx context.Context, n int) (int, error) {
+		if n == 100 {
+			return 0, errors.New("遇到非法数据 100")
+		}
+		return n, nil
+	})
+	pipeline.Sink(checked, "discard", pipeline.Options{}, func(ctx context.Context, n int) error { return nil })
+	fmt.Println("短路错误:", p2.Wait())
+}
+
+// ============================= 12. 事件总线 ====================
+// 组件之间通过主题解耦，代替到处传递的管道
+
+type OrderCreated struct {
+	ID     string
+	Amount int
+}
+
+type OrderPaid struct {
+	ID string
+}
+
+var (
+	orderCreated = eventbus.Topic[OrderCreated]("order.created")
+	orderPaid    = eventbus.Topic[OrderPaid]("order.paid")
+)
+
+func eventBusDemo() {
+	fmt.Println("\n=== 事件总线演示 ===")
+	bus := eventbus.New(eventbus.Options{
+		OnError: func(topic string, event any, err error) {
+			fmt.Printf("  死信: %s %+v: %v\n", topic, event, err)
+		},
+	})
+
+	// 12.1 同步订阅：在 Publish 的goroutine中执行，错误直接返回给发布者
+	eventbus.Subscribe(bus, orderCreated, func(ctx context.Context, ev eventbus.Event[OrderCreated]) error {
+		if ev.Data.Amount <= 0 {
+			return fmt.Errorf("订单 %s 金额非法", ev.Data.ID)
+		}
+		fmt.Printf("  [校验] %s 金额 %d\n", ev.Data.ID, ev.Data.Amount)
+		return nil
+	}, eventbus.SubscribeOptions{})
+
+	// 12.2 异步订阅 + 重试：前两次失败，第三次成功（至少一次投递）
+	var emailAttempts atomic.Int32
+	eventbus.Subscribe(bus, orderCreated, func(ctx context.Context, ev eventbus.Event[OrderCreated]) error {
+		if ev.Data.ID == "A1" && emailAttempts.Add(1) < 3 {
+			return errors.New("邮件服务超时")
+		}
+		fmt.Printf("  [邮件] %s 第%d次尝试发送成功\n", ev.Data.ID, ev.Attempt)
+		return nil
+	}, eventbus.SubscribeOptions{
+		Async: true,
+		Retry: eventbus.RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond},
+	})
+
+	// 12.3 通配符：T 为 any 时接收 order 下所有类型的事件
+	var audited atomic.Int32
+	audit, _ := eventbus.Subscribe(bus, eventbus.Topic[any]("order.*"), func(ctx context.Context, ev eventbus.Event[any]) error {
+		audited.Add(1)
+		return nil
+	}, eventbus.SubscribeOptions{Async: true})
+
+	// 12.4 慢消费者：队列只有2个位置，满了丢弃最旧的事件，不拖慢发布者
+	var latest atomic.Value
+	eventbus.Subscribe(bus, orderPaid, func(ctx context.Context, ev eventbus.Event[OrderPaid]) error {
+		time.Sleep(20 * time.Millisecond)
+		latest.Store(ev.Data.ID)
+		return nil
+	}, eventbus.SubscribeOptions{Async: true, QueueSize: 2, Overflow: eventbus.DropOldest})
+
+	ctx := context.Background()
+	eventbus.Publish(ctx, bus, orderCreated, OrderCreated{ID: "A1", Amount: 99})
+	if err := eventbus.Publish(ctx, bus, orderCreated, OrderCreated{ID: "A2", Amount: 0}); err != nil {
+		fmt.Println("  发布失败:", err)
+	}
+	for i := range 10 {
+		eventbus.Publish(ctx, bus, orderPaid, OrderPaid{ID: fmt.Sprintf("P%d", i)})
+	}
+	if err := eventbus.Publish(ctx, bus, eventbus.Topic[OrderPaid]("order.*"), OrderPaid{}); err != nil {
+		fmt.Println("  非法主题:", err)
+	}
+
+	audit.Unsubscribe() // 等待审计队列处理完
+	fmt.Printf("  [审计] 收到 %d 个事件\n", audited.Load())
+
+	// Close 排空所有异步队列
+	shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := bus.Close(shutdownCtx); err != nil {
+		fmt.Println("  关闭超时:", err)
+	}
+	fmt.Println("  最后处理的支付:", latest.Load())
+	for _, topic := range []string{"order.created", "order.paid"} {
+		fmt.Printf("  %-14s %+v\n", topic, bus.Stats()[topic])
+	}
+	if err := eventbus.Publish(ctx, bus, orderPaid, OrderPaid{ID: "P99"}); err != nil {
+		fmt.Println("  关闭后发布:", err)
+	}
+}
+
+// ============================= 13. Actor模型 ====================
+// 账户状态只被自己的actor访问，不需要锁；余额不足时panic由监督者重启
+
+// AccountMsg 账户actor的消息，Reply 不为零值时表示请求/响应
+type AccountMsg struct {
+	Op     string // deposit/withdraw/balance
+	Amount int
+	Reply  actor.Reply[int]
+}
+
+type account struct {
+	balance int
+}
+
+func (a *account) PreStart(ctx *actor.Context[AccountMsg]) error {
+	fmt.Printf("  [%s] 启动，余额 %d\n", ctx.Self().Path(), a.balance)
+	return nil
+}
+
+func (a *account) PreRestart(ctx *actor.Context[AccountMsg], reason error) {
+	fmt.Printf("  [%s] 重启前余额 %d，原因: %v\n", ctx.Self().Path(), a.balance, reason)
+}
+
+func (a *account) PostStop(ctx *actor.Context[AccountMsg]) {
+	fmt.Printf("  [%s] 停止\n", ctx.Self().Path())
+}
+
+func (a *account) Receive(ctx *actor.Context[AccountMsg], msg AccountMsg) error {
+	switch msg.Op {
+	case "deposit":
+		a.balance += msg.Amount
+	case "withdraw":
+		if msg.Amount > a.balance {
+			return fmt.Errorf("余额不足: %d < %d", a.balance, msg.Amount)
+		}
+		a.balance -= msg.Amount
+	case "balance":
+		msg.Reply.Resolve(a.balance)
+	case "corrupt":
+		panic("账本损坏")
+	}
+	return nil
+}
+
+// balanceRequest 构造查询余额的请求，用于 Ask
+func balanceRequest(reply actor.Reply[int]) AccountMsg {
+	return AccountMsg{Op: "balance", Reply: reply}
+}
+
+func actorDemo() {
+	fmt.Println("\n=== Actor模型演示 ===")
+
+	// 13.1 业务错误恢复继续（Resume），panic重启（Restart，状态重置）
+	system := actor.NewSystem(actor.Options{
+		Guardian: actor.Strategy{
+			Decide: func(err error) actor.Directive {
+				var panicErr *actor.PanicError
+				if errors.As(err, &panicErr) {
+					return actor.Restart
+				}
+				return actor.Resume
+			},
+			MaxRestarts: 3,
+			Within:      time.Minute,
+		},
+		OnFailure: func(path string, err error, d actor.Directive) {
+			fmt.Printf("  [监督] %s 出错: %v → %s\n", path, err, d)
+		},
+	})
+	props := actor.Props[AccountMsg]{New: func() actor.Actor[AccountMsg] { return &account{} }}
+	acc, _ := actor.Spawn(system, "account-1", props)
+
+	// 100个goroutine并发存款，actor串行处理，不需要锁
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			acc.Send(AccountMsg{Op: "deposit", Amount: 10})
+		}()
+	}
+	wg.Wait()
+	acc.Send(AccountMsg{Op: "withdraw", Amount: 5000})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	balance, err := actor.Ask(ctx, acc, balanceRequest)
+	fmt.Printf("  余额: %d, %v\n", balance, err)
+
+	acc.Send(AccountMsg{Op: "corrupt"})
+	balance, err = actor.Ask(ctx, acc, balanceRequest)
+	fmt.Printf("  重启后余额: %d, %v\n", balance, err)
+
+	if err := system.Shutdown(ctx); err != nil {
+		fmt.Println("  关闭超时:", err)
+	}
+	fmt.Println("  停止后发送:", acc.Send(AccountMsg{Op: "deposit", Amount: 1}))
+
+	// 13.2 手动调度器：所有actor在当前goroutine中按确定的顺序运行，适合测试
+	sched := actor.NewManual()
+	testSystem := actor.NewSystem(actor.Options{Scheduler: sched})
+	acc2, _ := actor.Spawn(testSystem, "account-2", props)
+	acc2.Send(AccountMsg{Op: "deposit", Amount: 30})
+	future, _ := actor.AskAsync(acc2, balanceRequest)
+	if _, _, ok := future.TryGet(); !ok {
+		fmt.Printf("  调度前尚未回复，待执行激活: %d\n", sched.Pending())
+	}
+	sched.RunUntilIdle()
+	balance, _, _ = future.TryGet()
+	fmt.Println("  调度后余额:", balance)
+	testSystem.Stop()
+	sched.RunUntilIdle()
+}
+
 // ============================= 主函数入口 ====================
 func main() {
+	// 记录启动时的goroutine，所有示例结束后检查是否有泄漏
+	snap := leakcheck.Take()
+
 	unbufferedChannelDemo()
 	bufferedChannelDemo()
 	channelBlockingConditions()
@@ -292,6 +622,16 @@ func main() {
 	channelAsSyncTool()
 	waitGroupDemo()
 	waitGroupPrecautions()
+	workerPoolDemo()
+	pipelineDemo()
+	eventBusDemo()
+	actorDemo()
+
+	if err := snap.Check(leakcheck.Options{Timeout: 2 * time.Second}); err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println("\n没有goroutine泄漏")
+	}
 
 	fmt.Println("\n=== 所有示例执行完成 ===")
 }
@@ -341,9 +681,34 @@ func main() {
    - Wait(): 阻塞等待所有完成
    - 必须传递指针，不能复制
 
-9. 最佳实践：
+9. WorkerPool工作池：
+   - 固定/弹性worker数量 + 有界提交队列
+   - Submit返回Future，支持单任务超时
+   - Shutdown先排空队列，超时则取消剩余任务
+   - Stats提供排队/执行中/完成/失败指标
+
+10. Pipeline流水线：
+   - Source → Stage → ... → Sink，阶段之间用带缓冲管道连接
+   - Workers扇出并发处理，Ordered按输入顺序重排输出
+   - 任一阶段出错通过ctx取消所有阶段
+   - Stats统计每个阶段的输入/输出/吞吐量
+
+11. 事件总线：
+   - Topic[T] 绑定事件类型，Subscribe/Publish 由编译器检查类型
+   - 同步订阅在发布者goroutine执行；异步订阅有界队列，满时阻塞/丢新/丢旧
+   - * 匹配一段、# 匹配剩余所有段；重试保证至少一次投递，处理器要幂等
+   - Unsubscribe/Close 排空队列后返回，Stats 按主题统计投递、重试、丢弃
+
+12. Actor模型：
+   - 状态只在actor自己的激活中访问，Send/Ask 通信代替共享内存和锁
+   - 父actor的Strategy决定子actor出错时 Restart/Resume/Stop/Escalate
+   - PreStart/PostStop/PreRestart 钩子；Shutdown 从叶子开始停止整棵树
+   - Manual 调度器让消息处理顺序完全确定，便于测试
+
+13. 最佳实践：
    - 发送方负责关闭管道
    - 使用defer确保资源释放
    - WaitGroup传递指针而非值
    - 避免死锁和竞态条件
+   - 用leakcheck检查goroutine泄漏，而不是time.Sleep等待
 */
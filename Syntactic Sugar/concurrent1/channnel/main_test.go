@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+)
+
+// TestDemosNoLeak 每个示例返回后不能留下仍在运行的goroutine
+func TestDemosNoLeak(t *testing.T) {
+	demos := []struct {
+		name string
+		fn   func()
+	}{
+		{"unbuffered", unbufferedChannelDemo},
+		{"buffered", bufferedChannelDemo},
+		{"blocking", channelBlockingConditions},
+		{"panic", channelPanicCases},
+		{"directional", directionalChannelsDemo},
+		{"range", channelRangeDemo},
+		{"sync", channelAsSyncTool},
+		{"waitgroup", waitGroupDemo},
+		{"waitgroupPrecautions", waitGroupPrecautions},
+		{"workerPool", workerPoolDemo},
+		{"pipeline", pipelineDemo},
+		{"eventBus", eventBusDemo},
+		{"actor", actorDemo},
+	}
+	for _, d := range demos {
+		t.Run(d.name, func(t *testing.T) {
+			defer leakcheck.Verify(t, leakcheck.Options{Timeout: 2 * time.Second})()
+			d.fn()
+		})
+	}
+}
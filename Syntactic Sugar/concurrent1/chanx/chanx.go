@@ -0,0 +1,246 @@
+// ============================= 1. 非阻塞与超时操作 ====================
+// selectandlock 中 trySend/tryRecv 的泛型版本，以及常用的管道组合工具
+// 所有启动goroutine的函数都会在输入管道关闭或ctx取消时退出，不会泄漏
+
+package chanx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TrySend 非阻塞发送，管道满或无接收者时返回 false
+func TrySend[T any](ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+// TryRecv 非阻塞接收，没有数据或管道已关闭时 ok 为 false
+func TryRecv[T any](ch <-chan T) (v T, ok bool) {
+	select {
+	case v, ok = <-ch:
+		return v, ok
+	default:
+		return v, false
+	}
+}
+
+// SendTimeout 在 timeout 内发送成功返回 true
+func SendTimeout[T any](ch chan<- T, v T, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case ch <- v:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// SendContext 发送数据，ctx 取消时返回 ctx.Err()
+func SendContext[T any](ctx context.Context, ch chan<- T, v T) error {
+	select {
+	case ch <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RecvContext 接收数据；管道关闭时 ok 为 false，ctx 取消时返回 ctx.Err()
+func RecvContext[T any](ctx context.Context, ch <-chan T) (v T, ok bool, err error) {
+	select {
+	case v, ok = <-ch:
+		return v, ok, nil
+	case <-ctx.Done():
+		return v, false, ctx.Err()
+	}
+}
+
+// ============================= 2. 管道组合 ====================
+
+// OrDone 包装输入管道，ctx 取消时输出管道立即关闭
+func OrDone[T any](ctx context.Context, in <-chan T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Merge 扇入：合并多个管道，全部关闭（或ctx取消）后关闭输出管道
+func Merge[T any](ctx context.Context, chans ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	wg.Add(len(chans))
+	for _, ch := range chans {
+		go func() {
+			defer wg.Done()
+			for v := range OrDone(ctx, ch) {
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// ============================= 3. 时间相关的流控 ====================
+
+// Debounce 防抖：输入静默 wait 之后才输出最后一个值，输入关闭时会先输出待发送的值
+func Debounce[T any](ctx context.Context, in <-chan T, wait time.Duration) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(wait)
+		timer.Stop()
+		defer timer.Stop()
+
+		var (
+			last    T
+			pending bool
+		)
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					if pending {
+						select {
+						case out <- last:
+						case <-ctx.Done():
+						}
+					}
+					return
+				}
+				last, pending = v, true
+				timer.Reset(wait)
+			case <-timer.C:
+				if pending {
+					select {
+					case out <- last:
+					case <-ctx.Done():
+						return
+					}
+					pending = false
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Throttle 节流：每个 interval 内最多输出一个值，窗口内的其余值被丢弃
+func Throttle[T any](ctx context.Context, in <-chan T, interval time.Duration) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+
+		var next time.Time
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				now := time.Now()
+				if now.Before(next) {
+					continue // 仍在冷却窗口内
+				}
+				next = now.Add(interval)
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Batch 攒批：凑满 size 个或距第一个元素超过 maxWait 时输出一批
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxWait time.Duration) <-chan []T {
+	if size <= 0 {
+		size = 1
+	}
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(maxWait)
+		timer.Stop()
+		defer timer.Stop()
+
+		batch := make([]T, 0, size)
+		flush := func() bool {
+			timer.Stop()
+			if len(batch) == 0 {
+				return true
+			}
+			select {
+			case out <- batch:
+				batch = make([]T, 0, size)
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				if len(batch) == 0 {
+					timer.Reset(maxWait)
+				}
+				batch = append(batch, v)
+				if len(batch) >= size && !flush() {
+					return
+				}
+			case <-timer.C:
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,196 @@
+package chanx
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+)
+
+func TestTrySendTryRecv(t *testing.T) {
+	ch := make(chan int, 1)
+	if !TrySend(ch, 1) {
+		t.Fatal("TrySend on empty buffered channel failed")
+	}
+	if TrySend(ch, 2) {
+		t.Fatal("TrySend on full channel succeeded")
+	}
+	if v, ok := TryRecv(ch); !ok || v != 1 {
+		t.Fatalf("TryRecv = %d, %t; want 1, true", v, ok)
+	}
+	if _, ok := TryRecv(ch); ok {
+		t.Fatal("TryRecv on empty channel succeeded")
+	}
+	close(ch)
+	if _, ok := TryRecv(ch); ok {
+		t.Fatal("TryRecv on closed channel reported ok")
+	}
+}
+
+func TestSendRecvContext(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+
+	ch := make(chan int)
+	if SendTimeout(ch, 1, 10*time.Millisecond) {
+		t.Fatal("SendTimeout without receiver succeeded")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := SendContext(ctx, ch, 1); err != context.Canceled {
+		t.Fatalf("SendContext = %v; want context.Canceled", err)
+	}
+	if _, _, err := RecvContext(ctx, ch); err != context.Canceled {
+		t.Fatalf("RecvContext = %v; want context.Canceled", err)
+	}
+
+	go func() { ch <- 42 }()
+	v, ok, err := RecvContext(context.Background(), ch)
+	if v != 42 || !ok || err != nil {
+		t.Fatalf("RecvContext = %d, %t, %v; want 42, true, nil", v, ok, err)
+	}
+}
+
+// TestMergeStress 多个生产者并发写入，合并后每个值恰好出现一次
+func TestMergeStress(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+
+	const producers, perProducer = 8, 2000
+	chans := make([]<-chan int, producers)
+	for p := range producers {
+		ch := make(chan int)
+		chans[p] = ch
+		go func() {
+			defer close(ch)
+			for i := range perProducer {
+				ch <- p*perProducer + i
+			}
+		}()
+	}
+
+	seen := make([]int, producers*perProducer)
+	for v := range Merge(context.Background(), chans...) {
+		seen[v]++
+	}
+	for v, n := range seen {
+		if n != 1 {
+			t.Fatalf("value %d received %d times", v, n)
+		}
+	}
+}
+
+// TestMergeCancel 输入永不关闭时，取消ctx后所有内部goroutine退出
+func TestMergeCancel(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int) // 永不关闭
+	out := Merge(ctx, in, OrDone(ctx, in))
+	cancel()
+	for range out {
+	}
+}
+
+func TestDebounceEmitsLast(t *testing.T) {
+	in := make(chan int)
+	out := Debounce(context.Background(), in, time.Hour)
+	go func() {
+		for i := range 5 {
+			in <- i
+		}
+		close(in) // 关闭时输出待发送的值
+	}()
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []int{4}) {
+		t.Fatalf("Debounce = %v; want [4]", got)
+	}
+}
+
+func TestThrottleDropsWithinInterval(t *testing.T) {
+	in := make(chan int)
+	out := Throttle(context.Background(), in, time.Hour)
+	go func() {
+		for i := range 100 {
+			in <- i
+		}
+		close(in)
+	}()
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []int{0}) {
+		t.Fatalf("Throttle = %v; want [0]", got)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	t.Run("size", func(t *testing.T) {
+		in := make(chan int)
+		out := Batch(context.Background(), in, 3, time.Hour)
+		go func() {
+			for i := range 10 {
+				in <- i
+			}
+			close(in)
+		}()
+		var sizes []int
+		for b := range out {
+			sizes = append(sizes, len(b))
+		}
+		if !slices.Equal(sizes, []int{3, 3, 3, 1}) {
+			t.Fatalf("batch sizes = %v; want [3 3 3 1]", sizes)
+		}
+	})
+
+	t.Run("maxWait", func(t *testing.T) {
+		in := make(chan int)
+		out := Batch(context.Background(), in, 100, 10*time.Millisecond)
+		in <- 1
+		select {
+		case b := <-out:
+			if !slices.Equal(b, []int{1}) {
+				t.Fatalf("batch = %v; want [1]", b)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("partial batch not flushed after maxWait")
+		}
+		close(in)
+		for range out {
+		}
+	})
+}
+
+// TestConcurrentTrySend 并发非阻塞收发：发送成功的值要么被取走，要么留在缓冲区，不会丢失或重复
+func TestConcurrentTrySend(t *testing.T) {
+	ch := make(chan int, 64)
+	var (
+		wg             sync.WaitGroup
+		sent, received atomic.Int64
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				if TrySend(ch, i) {
+					sent.Add(1)
+				}
+				if _, ok := TryRecv(ch); ok {
+					received.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got, want := received.Load()+int64(len(ch)), sent.Load(); got != want {
+		t.Fatalf("received+buffered = %d; want %d sent", got, want)
+	}
+}
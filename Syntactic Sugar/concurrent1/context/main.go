@@ -3,9 +3,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"time"
+
+	"Syntactic_Sugar/concurrent1/breaker"
+	"Syntactic_Sugar/concurrent1/ctxutil"
+	"Syntactic_Sugar/concurrent1/leakcheck"
+	"Syntactic_Sugar/concurrent1/taskgroup"
 )
 
 // Context接口的四个核心方法：
@@ -32,33 +40,33 @@ func contextInterfaceDemo() {
 }
 
 // ============================= 2. valueCtx使用 ====================
-type keyType string
-
-const (
-	userKey      keyType = "user"
-	requestIDKey keyType = "requestID"
+// 类型安全的键：值的类型由键决定，取值不需要类型断言
+var (
+	userKey      = ctxutil.NewKey[string]("user")
+	requestIDKey = ctxutil.NewKey[string]("requestID")
+	retryKey     = ctxutil.NewKey[int]("retry")
 )
 
 func valueContextDemo() {
 	fmt.Println("\n=== valueCtx值传递演示 ===")
 
-	// 现在使用包级别的常量
-	ctx := context.WithValue(context.Background(), userKey, "Alice")
-	ctx = context.WithValue(ctx, requestIDKey, "12345")
+	// 底层仍是 context.WithValue，键是包级别的指针
+	ctx := userKey.With(context.Background(), "Alice")
+	ctx = requestIDKey.With(ctx, "12345")
 
 	processRequest(ctx)
 }
 
 func processRequest(ctx context.Context) {
-	// 使用包级别的常量，类型一致
-	user := ctx.Value(userKey)
-	requestID := ctx.Value(requestIDKey)
+	// From 直接返回 string，无需断言
+	user, _ := userKey.From(ctx)
+	requestID, _ := requestIDKey.From(ctx)
 
-	fmt.Printf("处理请求 - 用户: %v, 请求ID: %v\n", user, requestID)
+	fmt.Printf("处理请求 - 用户: %s, 请求ID: %s\n", user, requestID)
 
-	// 获取不存在的键
-	notFound := ctx.Value("nonexistent")
-	fmt.Printf("不存在的键: %v\n", notFound)
+	// 获取不存在的键：ok 为 false，值为零值
+	retry, ok := retryKey.From(ctx)
+	fmt.Printf("不存在的键: %v, 存在: %t\n", retry, ok)
 }
 
 // ============================= 3. cancelCtx使用 ====================
@@ -208,51 +216,48 @@ func httpHandler(ctx context.Context, wg *sync.WaitGroup, path string) {
 
 	fmt.Printf("开始处理请求: %s\n", path)
 
-	// 为不同的服务创建子上下文
-	authCtx, authCancel := context.WithTimeout(ctx, 2*time.Second)
-	defer authCancel()
-
-	dbCtx, dbCancel := context.WithTimeout(ctx, 3*time.Second)
-	defer dbCancel()
-
-	// 并行执行认证和数据库操作
-	authCh := make(chan bool, 1)
-	dbCh := make(chan string, 1)
-
-	go authenticate(authCtx, authCh)
-	go queryDatabase(dbCtx, dbCh)
+	// 并行执行认证和数据库操作：任一失败会取消另一个，panic也会转换为错误
+	// 超时按父ctx剩余时间的比例分配（5秒时分别为2秒和3秒），而不是写死
+	g, _ := taskgroup.WithContext(ctx)
+	auth := taskgroup.Go(g, func(ctx context.Context) (bool, error) {
+		authCtx, cancel := ctxutil.WithBudget(ctx, 0.4)
+		defer cancel()
+		return authenticate(authCtx)
+	})
+	user := taskgroup.Go(g, func(ctx context.Context) (string, error) {
+		dbCtx, cancel := ctxutil.WithBudget(ctx, 0.6)
+		defer cancel()
+		return queryDatabase(dbCtx)
+	})
 
-	// 等待结果或超时
-	select {
-	case <-ctx.Done():
-		fmt.Printf("请求超时或取消: %v\n", ctx.Err())
-	case authSuccess := <-authCh:
-		if !authSuccess {
-			fmt.Println("认证失败")
-			return
-		}
-		userData := <-dbCh
-		fmt.Printf("请求成功: %s\n", userData)
+	if err := g.Wait(); err != nil {
+		fmt.Printf("请求超时或取消: %v\n", err)
+		return
+	}
+	if !auth.Value() {
+		fmt.Println("认证失败")
+		return
 	}
+	fmt.Printf("请求成功: %s\n", user.Value())
 }
 
-func authenticate(ctx context.Context, ch chan<- bool) {
+func authenticate(ctx context.Context) (bool, error) {
 	// 模拟认证过程
 	select {
 	case <-time.After(1 * time.Second):
-		ch <- true // 认证成功
+		return true, nil // 认证成功
 	case <-ctx.Done():
-		ch <- false // 认证超时
+		return false, ctx.Err() // 认证超时
 	}
 }
 
-func queryDatabase(ctx context.Context, ch chan<- string) {
+func queryDatabase(ctx context.Context) (string, error) {
 	// 模拟数据库查询
 	select {
 	case <-time.After(2 * time.Second):
-		ch <- "用户数据" // 查询成功
+		return "用户数据", nil // 查询成功
 	case <-ctx.Done():
-		ch <- "" // 查询超时
+		return "", ctx.Err() // 查询超时
 	}
 }
 
@@ -262,23 +267,126 @@ func bestPractices() {
 
 	// 7.1 总是传递Context作为第一个参数
 	// 7.2 在超时或取消时及时释放资源
-	// 7.3 使用WithValue时定义自定义键类型
+	// 7.3 使用WithValue时定义类型安全的键
 
-	type contextKey string
-	const traceIDKey contextKey = "traceID"
+	ctx := traceIDKey.With(context.Background(), "trace-123")
+	processWithTrace(ctx)
+}
 
-	ctx := context.WithValue(context.Background(), traceIDKey, "trace-123")
-	processWithTrace(ctx, traceIDKey)
+var traceIDKey = ctxutil.NewKey[string]("traceID")
+
+func processWithTrace(ctx context.Context) {
+	if traceID, ok := traceIDKey.From(ctx); ok {
+		fmt.Printf("处理带追踪ID的请求: %s\n", traceID)
+	}
 }
 
-func processWithTrace(ctx context.Context, key interface{}) {
-	if traceID := ctx.Value(key); traceID != nil {
-		fmt.Printf("处理带追踪ID的请求: %v\n", traceID)
+// ============================= 8. 熔断器保护下游依赖 ====================
+// httpHandler 中的下游调用即使一直超时也会被反复调用
+// 熔断器在连续失败后直接拒绝，冷却后再放行探测请求
+func circuitBreakerDemo() {
+	fmt.Println("\n=== 熔断器演示 ===")
+
+	dbBreaker := breaker.New(breaker.Settings{
+		Name:                "database",
+		ConsecutiveFailures: 3,
+		CoolDown:            300 * time.Millisecond,
+		OnStateChange: func(name string, from, to breaker.State) {
+			fmt.Printf("熔断器 %s: %s -> %s\n", name, from, to)
+		},
+	})
+
+	// 8.1 数据库前5次查询超时，之后恢复
+	var calls int
+	flakyQuery := func(ctx context.Context) (string, error) {
+		calls++
+		if calls <= 5 {
+			<-ctx.Done() // 模拟下游卡住直到超时
+			return "", ctx.Err()
+		}
+		return "用户数据", nil
+	}
+
+	for i := 1; i <= 6; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		data, err := breaker.Call(ctx, dbBreaker, flakyQuery)
+		cancel()
+
+		switch {
+		case errors.Is(err, breaker.ErrOpen):
+			fmt.Printf("请求%d: 熔断中，快速失败\n", i)
+		case err != nil:
+			fmt.Printf("请求%d: 查询失败: %v\n", i, err)
+		default:
+			fmt.Printf("请求%d: %s\n", i, data)
+		}
+	}
+	fmt.Printf("实际调用下游次数: %d\n", calls)
+
+	// 8.2 冷却结束后半开探测，成功则闭合
+	time.Sleep(350 * time.Millisecond)
+	calls = 5
+	data, err := breaker.Call(context.Background(), dbBreaker, flakyQuery)
+	fmt.Printf("探测请求: %s, %v, 当前状态: %s\n", data, err, dbBreaker.State())
+
+	// 8.3 包装 http.RoundTripper：5xx 响应计入失败
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "服务不可用", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Timeout: time.Second,
+		Transport: &breaker.Transport{
+			Breaker: breaker.New(breaker.Settings{Name: "upstream", ConsecutiveFailures: 2}),
+		},
+	}
+	for i := 1; i <= 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			fmt.Printf("HTTP请求%d: %v\n", i, err)
+			continue
+		}
+		resp.Body.Close()
+		fmt.Printf("HTTP请求%d: 状态码 %d\n", i, resp.StatusCode)
 	}
 }
 
+// ============================= 9. Context工具 ====================
+func contextUtilDemo() {
+	fmt.Println("\n=== Context工具演示 ===")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	ctx = traceIDKey.With(ctx, "trace-456")
+
+	// 9.1 按权重切分剩余时间给依次执行的子调用
+	budgets := ctxutil.Split(ctx, 1, 2)
+	fmt.Printf("预算分配: 校验 ≈%v, 写入 ≈%v\n",
+		budgets[0].Round(10*time.Millisecond), budgets[1].Round(10*time.Millisecond))
+
+	// 9.2 ctx结束时按后进先出执行清理（不额外启动goroutine）
+	var cleanupWG sync.WaitGroup
+	cleanupWG.Add(2)
+	cleanup := ctxutil.OnDone(ctx)
+	cleanup.Add(func() { defer cleanupWG.Done(); fmt.Println("清理: 关闭数据库连接") })
+	cleanup.Add(func() { defer cleanupWG.Done(); fmt.Println("清理: 删除临时文件") })
+
+	// 9.3 请求结束后仍需完成的审计日志：保留追踪ID，但不随请求取消
+	auditCtx, auditCancel := ctxutil.DetachWithTimeout(ctx, time.Second)
+	defer auditCancel()
+
+	cancel() // 请求结束
+	cleanupWG.Wait()
+
+	traceID, _ := traceIDKey.From(auditCtx)
+	fmt.Printf("请求ctx: %v, 审计ctx: %v, 审计追踪ID: %s\n", ctx.Err(), auditCtx.Err(), traceID)
+}
+
 // ============================= 主函数入口 ====================
 func main() {
+	// 记录启动时的goroutine，所有示例结束后检查是否有泄漏
+	snap := leakcheck.Take()
+
 	contextInterfaceDemo()
 	valueContextDemo()
 	cancelContextDemo()
@@ -286,6 +394,14 @@ func main() {
 	timeoutContextDemo()
 	practicalExample()
 	bestPractices()
+	circuitBreakerDemo()
+	contextUtilDemo()
+
+	if err := snap.Check(leakcheck.Options{Timeout: 2 * time.Second}); err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println("\n没有goroutine泄漏")
+	}
 
 	fmt.Println("\n=== 所有Context示例执行完成 ===")
 }
@@ -332,8 +448,27 @@ Context核心知识点总结：
    - 检查Context是否已取消
    - 避免上下文泄漏
 
-7. 注意事项：
+7. 熔断器：
+   - Closed/Open/HalfOpen 三种状态
+   - 连续失败次数或失败率达到阈值时跳闸
+   - 冷却后放行探测请求，成功则恢复
+   - 调用方主动取消不计入失败，超时计入失败
+
+8. 任务组(taskgroup)：
+   - WithContext创建共享ctx的任务组，第一个错误取消其他任务
+   - SetLimit限制并发数，panic被恢复为错误
+   - taskgroup.Go收集每个任务的返回值
+
+9. Context工具(ctxutil)：
+   - ContextKey[T]以指针作为键，With/From类型安全且不会冲突
+   - Detach/DetachWithTimeout保留值但不继承取消，用于后台后续工作
+   - Merge合并两个ctx的取消信号，Cause保留先结束一方的原因
+   - WithBudget/Split按比例分配父ctx的剩余时间
+   - OnDone基于AfterFunc在ctx结束时执行清理
+
+10. 注意事项：
    - 不要存储Context在结构体中，应该显式传递
-   - 相同的键在不同包中可能冲突，使用自定义类型
+   - 相同的键在不同包中可能冲突，使用ctxutil.NewKey创建键
    - 取消上下文会释放相关资源，确保及时取消
+   - 忘记取消会让等待ctx的goroutine泄漏，可用leakcheck检测
 */
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+)
+
+// TestDemosNoLeak 每个示例返回后不能留下仍在运行的goroutine
+// 依赖真实计时的示例较慢，-short 时跳过
+func TestDemosNoLeak(t *testing.T) {
+	demos := []struct {
+		name string
+		fn   func()
+		slow bool
+	}{
+		{"interface", contextInterfaceDemo, false},
+		{"value", valueContextDemo, false},
+		{"cancel", cancelContextDemo, true},
+		{"nestedCancel", nestedCancelDemo, true},
+		{"timeout", timeoutContextDemo, true},
+		{"practical", practicalExample, true},
+		{"bestPractices", bestPractices, false},
+		{"circuitBreaker", circuitBreakerDemo, false},
+		{"ctxutil", contextUtilDemo, false},
+	}
+	for _, d := range demos {
+		t.Run(d.name, func(t *testing.T) {
+			if d.slow && testing.Short() {
+				t.Skip("uses real timers")
+			}
+			defer leakcheck.Verify(t, leakcheck.Options{Timeout: 2 * time.Second})()
+			d.fn()
+		})
+	}
+}
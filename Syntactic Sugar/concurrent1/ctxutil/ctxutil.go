@@ -0,0 +1,171 @@
+// ============================= 1. 类型安全的Context键 ====================
+// context.WithValue 的键和值都是 any，取值时需要类型断言，且字符串键容易冲突
+// ContextKey[T] 以指针身份作为键，值的类型在编译期确定
+
+package ctxutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ContextKey 类型安全的Context键，必须通过 NewKey 创建
+type ContextKey[T any] struct {
+	name string
+}
+
+// NewKey 创建新键；即使名称相同，不同 NewKey 返回的键也互不冲突
+func NewKey[T any](name string) *ContextKey[T] {
+	return &ContextKey[T]{name: name}
+}
+
+// With 返回携带值 v 的子Context
+func (k *ContextKey[T]) With(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k, v)
+}
+
+// From 读取值，不存在时 ok 为 false
+func (k *ContextKey[T]) From(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k).(T)
+	return v, ok
+}
+
+// MustFrom 读取值，不存在时panic，用于中间件保证一定设置过的键
+func (k *ContextKey[T]) MustFrom(ctx context.Context) T {
+	v, ok := k.From(ctx)
+	if !ok {
+		panic(fmt.Sprintf("ctxutil: key %s not found in context", k.name))
+	}
+	return v
+}
+
+// String 便于调试输出
+func (k *ContextKey[T]) String() string {
+	var zero T
+	return fmt.Sprintf("ctxutil.ContextKey[%T](%s)", zero, k.name)
+}
+
+// ============================= 2. 脱离父Context ====================
+
+// Detach 保留父Context的值，但不继承取消和截止时间
+// 用于请求结束后仍需完成的后续工作（写审计日志、异步通知等）
+func Detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// DetachWithTimeout 脱离父Context后设置新的超时，避免后台任务无限运行
+func DetachWithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), timeout)
+}
+
+// Merge 返回的Context在 ctx 或 other 任一结束时结束，context.Cause 为先结束一方的原因
+// 值和截止时间只继承自 ctx，other 只提供取消信号；由 other 结束时 Err() 为 context.Canceled
+// 用于把服务关闭信号并入请求ctx等场景，返回的 cancel 必须调用以解除对 other 的监听
+func Merge(ctx, other context.Context) (context.Context, context.CancelFunc) {
+	merged, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(other, func() {
+		cancel(context.Cause(other))
+	})
+	return merged, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// ============================= 3. 截止时间预算 ====================
+
+// Remaining 父Context剩余的时间，没有截止时间时 ok 为 false
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// WithBudget 子调用只能使用父Context剩余时间的 fraction 部分
+// 父Context没有截止时间时只返回可取消的子Context
+func WithBudget(ctx context.Context, fraction float64) (context.Context, context.CancelFunc) {
+	remaining, ok := Remaining(ctx)
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	fraction = min(max(fraction, 0), 1)
+	return context.WithTimeout(ctx, time.Duration(float64(remaining)*fraction))
+}
+
+// Split 按权重把剩余时间分配给依次执行的多个子调用
+// 例如 Split(ctx, 1, 2) 在剩余3秒时返回 [1s, 2s]；没有截止时间时返回 nil
+func Split(ctx context.Context, weights ...float64) []time.Duration {
+	remaining, ok := Remaining(ctx)
+	if !ok || remaining <= 0 {
+		return nil
+	}
+
+	var total float64
+	for _, w := range weights {
+		total += max(w, 0)
+	}
+	budgets := make([]time.Duration, len(weights))
+	if total == 0 {
+		return budgets
+	}
+	for i, w := range weights {
+		budgets[i] = time.Duration(float64(remaining) * max(w, 0) / total)
+	}
+	return budgets
+}
+
+// ============================= 4. 取消后的清理 ====================
+
+// Cleanup 在Context结束时按后进先出顺序执行清理函数
+type Cleanup struct {
+	mu   sync.Mutex
+	fns  []func()
+	ran  bool
+	stop func() bool
+}
+
+// OnDone 创建绑定到 ctx 的清理器（基于 context.AfterFunc，不额外占用goroutine）
+func OnDone(ctx context.Context) *Cleanup {
+	c := &Cleanup{}
+	c.stop = context.AfterFunc(ctx, c.run)
+	return c
+}
+
+// Add 注册清理函数；Context已经结束时立即执行
+func (c *Cleanup) Add(fn func()) {
+	c.mu.Lock()
+	if c.ran {
+		c.mu.Unlock()
+		fn()
+		return
+	}
+	c.fns = append(c.fns, fn)
+	c.mu.Unlock()
+}
+
+// Stop 取消绑定，已注册的清理函数不再执行；已经开始执行时返回 false
+func (c *Cleanup) Stop() bool {
+	if !c.stop() {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fns = nil
+	return true
+}
+
+func (c *Cleanup) run() {
+	c.mu.Lock()
+	fns := c.fns
+	c.fns = nil
+	c.ran = true
+	c.mu.Unlock()
+
+	for i := len(fns) - 1; i >= 0; i-- {
+		fns[i]()
+	}
+}
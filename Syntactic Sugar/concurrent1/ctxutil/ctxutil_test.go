@@ -0,0 +1,222 @@
+package ctxutil
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+)
+
+// near 判断 got 与 want 的差距在 tolerance 以内
+func near(got, want, tolerance time.Duration) bool {
+	return got > want-tolerance && got <= want+tolerance
+}
+
+func TestContextKey(t *testing.T) {
+	user := NewKey[string]("user")
+	other := NewKey[string]("user") // 同名的键互不冲突
+	ctx := user.With(context.Background(), "alice")
+
+	if v, ok := user.From(ctx); v != "alice" || !ok {
+		t.Errorf("From = %q, %t", v, ok)
+	}
+	if v, ok := other.From(ctx); ok {
+		t.Errorf("same-name key read %q", v)
+	}
+	if s := user.String(); s != "ctxutil.ContextKey[string](user)" {
+		t.Errorf("String = %q", s)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustFrom on missing key did not panic")
+		}
+	}()
+	other.MustFrom(ctx)
+}
+
+func TestDetach(t *testing.T) {
+	key := NewKey[int]("n")
+	parent, cancel := context.WithTimeout(key.With(context.Background(), 7), time.Hour)
+	detached := Detach(parent)
+	timed, timedCancel := DetachWithTimeout(parent, time.Minute)
+	defer timedCancel()
+	cancel()
+
+	// 保留值，但不继承取消和截止时间
+	for _, ctx := range []context.Context{detached, timed} {
+		if v, _ := key.From(ctx); v != 7 {
+			t.Errorf("detached value = %d, want 7", v)
+		}
+		if ctx.Err() != nil {
+			t.Errorf("detached ctx canceled with parent: %v", ctx.Err())
+		}
+	}
+	if _, ok := detached.Deadline(); ok {
+		t.Error("Detach kept the parent deadline")
+	}
+	if d, ok := Remaining(timed); !ok || !near(d, time.Minute, time.Second) {
+		t.Errorf("DetachWithTimeout remaining = %v, %t; want ~1m", d, ok)
+	}
+}
+
+func TestWithBudget(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cases := []struct {
+		fraction float64
+		want     time.Duration
+	}{
+		{0.4, 4 * time.Second},
+		{0.6, 6 * time.Second},
+		{2, 10 * time.Second}, // 超过1按1处理
+		{-1, 0},               // 小于0按0处理
+	}
+	for _, c := range cases {
+		ctx, cancel := WithBudget(parent, c.fraction)
+		if d, ok := Remaining(ctx); !ok || !near(d, c.want, 100*time.Millisecond) {
+			t.Errorf("WithBudget(%v) remaining = %v, want ~%v", c.fraction, d, c.want)
+		}
+		cancel()
+	}
+
+	// 没有截止时间：只返回可取消的子ctx；父ctx取消原因向下传递
+	errStop := errors.New("stop")
+	root, rootCancel := context.WithCancelCause(context.Background())
+	ctx, cancel := WithBudget(root, 0.5)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("budget without parent deadline has a deadline")
+	}
+	rootCancel(errStop)
+	<-ctx.Done()
+	if cause := context.Cause(ctx); cause != errStop {
+		t.Errorf("budget cause = %v, want %v", cause, errStop)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	cases := []struct {
+		weights []float64
+		want    []time.Duration
+	}{
+		{[]float64{1, 2}, []time.Duration{time.Second, 2 * time.Second}},
+		{[]float64{1, -5, 1}, []time.Duration{1500 * time.Millisecond, 0, 1500 * time.Millisecond}},
+		{[]float64{0, 0}, []time.Duration{0, 0}},
+	}
+	for _, c := range cases {
+		got := Split(ctx, c.weights...)
+		if len(got) != len(c.want) {
+			t.Fatalf("Split(%v) = %v", c.weights, got)
+		}
+		for i := range got {
+			if !near(got[i], c.want[i], 100*time.Millisecond) {
+				t.Errorf("Split(%v)[%d] = %v, want ~%v", c.weights, i, got[i], c.want[i])
+			}
+		}
+	}
+
+	if got := Split(context.Background(), 1); got != nil {
+		t.Errorf("Split without deadline = %v, want nil", got)
+	}
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if got := Split(expired, 1); got != nil {
+		t.Errorf("Split after deadline = %v, want nil", got)
+	}
+}
+
+func TestMergeCause(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	errShutdown := errors.New("shutdown")
+	errRequest := errors.New("request aborted")
+	key := NewKey[string]("id")
+
+	// other 先结束：Cause 为 other 的原因，值来自 ctx
+	req := key.With(context.Background(), "r1")
+	server, stopServer := context.WithCancelCause(context.Background())
+	merged, cancel := Merge(req, server)
+	stopServer(errShutdown)
+	<-merged.Done()
+	if cause := context.Cause(merged); cause != errShutdown || merged.Err() != context.Canceled {
+		t.Errorf("Cause = %v, Err = %v; want %v, context.Canceled", cause, merged.Err(), errShutdown)
+	}
+	if v, _ := key.From(merged); v != "r1" {
+		t.Errorf("merged value = %q, want r1", v)
+	}
+	cancel()
+
+	// ctx 先结束：Cause 为 ctx 的原因，之后 other 结束不会改写
+	reqCtx, abort := context.WithCancelCause(context.Background())
+	server, stopServer = context.WithCancelCause(context.Background())
+	merged, cancel = Merge(reqCtx, server)
+	abort(errRequest)
+	<-merged.Done()
+	stopServer(errShutdown)
+	if cause := context.Cause(merged); cause != errRequest {
+		t.Errorf("Cause = %v, want %v", cause, errRequest)
+	}
+	cancel()
+
+	// other 超时：Cause 为 DeadlineExceeded
+	timeout, timeoutCancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer timeoutCancel()
+	merged, cancel = Merge(context.Background(), timeout)
+	<-merged.Done()
+	if cause := context.Cause(merged); cause != context.DeadlineExceeded {
+		t.Errorf("Cause = %v, want context.DeadlineExceeded", cause)
+	}
+	cancel()
+
+	// 调用 cancel：Cause 为 context.Canceled，并解除对 other 的监听
+	server, stopServer = context.WithCancelCause(context.Background())
+	merged, cancel = Merge(context.Background(), server)
+	cancel()
+	stopServer(errShutdown)
+	if cause := context.Cause(merged); cause != context.Canceled {
+		t.Errorf("Cause after cancel = %v, want context.Canceled", cause)
+	}
+}
+
+func TestOnDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := OnDone(ctx)
+	ran := make(chan int, 4)
+	for i := range 3 {
+		c.Add(func() { ran <- i })
+	}
+	cancel()
+
+	// 后进先出
+	var order []int
+	for range 3 {
+		order = append(order, <-ran)
+	}
+	if !slices.Equal(order, []int{2, 1, 0}) {
+		t.Errorf("cleanup order = %v, want [2 1 0]", order)
+	}
+
+	// 结束后注册的清理函数立即执行，Stop 返回 false
+	c.Add(func() { ran <- 3 })
+	if v := <-ran; v != 3 {
+		t.Errorf("late Add ran %d", v)
+	}
+	if c.Stop() {
+		t.Error("Stop after run = true")
+	}
+
+	// Stop 后不再执行
+	ctx, cancel = context.WithCancel(context.Background())
+	c = OnDone(ctx)
+	c.Add(func() { t.Error("cleanup ran after Stop") })
+	if !c.Stop() {
+		t.Error("Stop = false before ctx done")
+	}
+	cancel()
+}
@@ -0,0 +1,213 @@
+// ============================= 1. 进程内事件总线 ====================
+// 组件之间不再直接传递管道，而是按主题发布和订阅事件：
+// - Topic[T] 把事件类型绑定到主题名，发布和订阅的类型由编译器检查
+// - 主题名用 . 分段，订阅时 * 匹配一段，# 匹配剩余的零段或多段，例如 "order.*"、"order.#"
+// - 同步订阅在 Publish 的goroutine中执行；异步订阅有自己的有界队列和goroutine
+// - 处理失败（返回错误或panic）按重试策略重试，保证至少一次投递
+// - 每个主题统计发布、投递、重试、失败、丢弃的次数
+
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrClosed 总线已关闭
+var ErrClosed = errors.New("eventbus: bus closed")
+
+// Topic 携带事件类型的主题名，例如 Topic[OrderCreated]("order.created")
+// 订阅时可以包含通配符；T 为 any 时接收匹配主题上的所有事件
+type Topic[T any] string
+
+// Options 总线配置
+type Options struct {
+	// OnError 事件重试用尽后仍然失败时调用（死信），为 nil 时忽略
+	OnError func(topic string, event any, err error)
+}
+
+// TopicStats 单个主题的指标
+type TopicStats struct {
+	Published int64 // Publish 调用次数
+	Delivered int64 // 处理成功的次数（每个订阅者各算一次）
+	Retried   int64 // 重试次数
+	Failed    int64 // 重试用尽后仍失败的次数
+	Dropped   int64 // 队列满或订阅已取消而没有投递的次数
+}
+
+type topicCounters struct {
+	published, delivered, retried, failed, dropped atomic.Int64
+}
+
+// Bus 事件总线，并发安全
+type Bus struct {
+	opts Options
+
+	ctx    context.Context // 异步处理器的ctx，Close 超时时取消
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	subs   []*subscriber // 按订阅顺序
+	nextID uint64
+	closed bool
+
+	statsMu sync.Mutex
+	stats   map[string]*topicCounters
+}
+
+// New 创建事件总线
+func New(opts Options) *Bus {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Bus{
+		opts:   opts,
+		ctx:    ctx,
+		cancel: cancel,
+		stats:  make(map[string]*topicCounters),
+	}
+}
+
+// Close 停止接收新事件，等待异步队列中的事件处理完
+// ctx 结束时取消仍在运行的处理器（这一次计为 Failed）并返回 ctx.Err()，
+// 队列中尚未开始处理的事件不再调用处理器，计为 Dropped
+// 在异步处理器中调用时不等待该订阅者自己的队列，其中剩余的事件计为 Dropped
+func (b *Bus) Close(ctx context.Context) error {
+	caller := goroutineID()
+	b.mu.Lock()
+	b.closed = true
+	subs := b.subs
+	b.subs = nil
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, s := range subs {
+			s.stop(caller)
+		}
+	}()
+
+	select {
+	case <-done:
+		b.cancel()
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Stats 所有主题的指标，键为发布时的具体主题名
+func (b *Bus) Stats() map[string]TopicStats {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+	out := make(map[string]TopicStats, len(b.stats))
+	for topic, c := range b.stats {
+		out[topic] = TopicStats{
+			Published: c.published.Load(),
+			Delivered: c.delivered.Load(),
+			Retried:   c.retried.Load(),
+			Failed:    c.failed.Load(),
+			Dropped:   c.dropped.Load(),
+		}
+	}
+	return out
+}
+
+func (b *Bus) counters(topic string) *topicCounters {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+	c, ok := b.stats[topic]
+	if !ok {
+		c = &topicCounters{}
+		b.stats[topic] = c
+	}
+	return c
+}
+
+// matching 订阅了 topic 的订阅者快照
+func (b *Bus) matching(topic string) ([]*subscriber, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	var subs []*subscriber
+	segments := strings.Split(topic, ".")
+	for _, s := range b.subs {
+		if match(s.pattern, segments) {
+			subs = append(subs, s)
+		}
+	}
+	return subs, nil
+}
+
+func (b *Bus) add(s *subscriber) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	b.nextID++
+	s.id = b.nextID
+	b.subs = append(b.subs, s)
+	return nil
+}
+
+func (b *Bus) remove(s *subscriber) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	i := slices.Index(b.subs, s)
+	if i < 0 {
+		return false
+	}
+	b.subs = slices.Delete(b.subs, i, i+1)
+	return true
+}
+
+// ============================= 2. 主题通配符 ====================
+
+// parsePattern 校验订阅的主题：不能有空段，# 只能是最后一段
+func parsePattern(pattern string) ([]string, error) {
+	segments := strings.Split(pattern, ".")
+	for i, seg := range segments {
+		switch {
+		case seg == "":
+			return nil, fmt.Errorf("eventbus: empty segment in topic %q", pattern)
+		case seg == "#" && i != len(segments)-1:
+			return nil, fmt.Errorf("eventbus: # must be the last segment in topic %q", pattern)
+		case seg != "*" && seg != "#" && strings.ContainsAny(seg, "*#"):
+			return nil, fmt.Errorf("eventbus: wildcard must be a whole segment in topic %q", pattern)
+		}
+	}
+	return segments, nil
+}
+
+// validTopic 发布的主题不能包含通配符
+func validTopic(topic string) error {
+	segments, err := parsePattern(topic)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(segments, func(s string) bool { return s == "*" || s == "#" }) {
+		return fmt.Errorf("eventbus: cannot publish to wildcard topic %q", topic)
+	}
+	return nil
+}
+
+func match(pattern, topic []string) bool {
+	for i, seg := range pattern {
+		if seg == "#" {
+			return true
+		}
+		if i >= len(topic) || (seg != "*" && seg != topic[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(topic)
+}
@@ -0,0 +1,110 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		ok      bool
+	}{
+		{"order.created", true},
+		{"order.*", true},
+		{"order.#", true},
+		{"*.created", true},
+		{"#", true},
+		{"", false},
+		{"order..created", false},
+		{"order.", false},
+		{"order.#.created", false},
+		{"order.cre*", false},
+		{"order.a#", false},
+	}
+	for _, tt := range tests {
+		if _, err := parsePattern(tt.pattern); (err == nil) != tt.ok {
+			t.Errorf("parsePattern(%q) error = %v; want ok=%t", tt.pattern, err, tt.ok)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"order.created", "order.created", true},
+		{"order.created", "order.paid", false},
+		{"order.*", "order.created", true},
+		{"order.*", "order", false},
+		{"order.*", "order.created.v2", false},
+		{"*.created", "user.created", true},
+		{"*.*", "order.created", true},
+		{"order.#", "order", true}, // # 匹配零段
+		{"order.#", "order.created", true},
+		{"order.#", "order.created.v2", true},
+		{"order.#", "user.created", false},
+		{"#", "anything.at.all", true},
+		{"order.*.v2", "order.created.v2", true},
+		{"order.*.v2", "order.created.v1", false},
+	}
+	for _, tt := range tests {
+		pattern, err := parsePattern(tt.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := match(pattern, strings.Split(tt.topic, ".")); got != tt.want {
+			t.Errorf("match(%q, %q) = %t; want %t", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestPublishWildcardRejected(t *testing.T) {
+	b := New(Options{})
+	for _, topic := range []Topic[int]{"order.*", "order.#", "order..x"} {
+		if err := Publish(context.Background(), b, topic, 1); err == nil {
+			t.Errorf("Publish(%q) succeeded; want error", topic)
+		}
+	}
+}
+
+// TestCloseTimeoutDropsQueued Close 超时后正在处理的事件计为失败，排队的事件计为丢弃
+func TestCloseTimeoutDropsQueued(t *testing.T) {
+	b := New(Options{})
+	started := make(chan struct{}, 1)
+	calls := 0
+	Subscribe(b, Topic[int]("job"), func(ctx context.Context, ev Event[int]) error {
+		calls++
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}, SubscribeOptions{Async: true, QueueSize: 8})
+
+	for i := range 5 {
+		if err := Publish(context.Background(), b, Topic[int]("job"), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close = %v; want DeadlineExceeded", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times; want 1", calls)
+	}
+	st := b.Stats()["job"]
+	if st.Failed != 1 || st.Dropped != 4 || st.Delivered != 0 {
+		t.Fatalf("stats = %+v; want Failed 1, Dropped 4", st)
+	}
+	if err := Publish(context.Background(), b, Topic[int]("job"), 0); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Publish after Close = %v; want ErrClosed", err)
+	}
+}
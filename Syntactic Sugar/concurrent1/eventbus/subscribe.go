@@ -0,0 +1,310 @@
+// ============================= 3. 订阅与发布 ====================
+
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Overflow 异步队列满时的策略
+type Overflow int
+
+const (
+	Block      Overflow = iota // 阻塞 Publish 直到有空位或ctx结束（不丢事件）
+	DropNewest                 // 丢弃正在发布的事件
+	DropOldest                 // 丢弃队列中最旧的事件，为新事件腾出位置
+)
+
+// RetryPolicy 处理失败时的重试策略，零值表示不重试
+type RetryPolicy struct {
+	MaxAttempts int           // 最多尝试次数（包括第一次），<=1 不重试
+	Backoff     time.Duration // 第一次重试前的等待，之后每次翻倍
+	MaxBackoff  time.Duration // 等待上限，0 表示不限制
+}
+
+// SubscribeOptions 订阅选项，零值为同步、不重试
+type SubscribeOptions struct {
+	Async     bool     // 异步投递：事件进入订阅者自己的队列
+	QueueSize int      // 异步队列容量，默认64
+	Overflow  Overflow // 异步队列满时的策略，默认 Block
+	Retry     RetryPolicy
+}
+
+// Event 交给处理器的事件
+type Event[T any] struct {
+	Topic   string // 发布时的具体主题
+	Data    T
+	Attempt int // 第几次尝试，从1开始；至少一次投递可能重复，处理器应保证幂等
+}
+
+// Handler 事件处理器，返回错误时按重试策略重试
+type Handler[T any] func(ctx context.Context, ev Event[T]) error
+
+// PanicError 处理器panic被恢复后转换成的错误
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("eventbus: handler panic: %v", e.Value)
+}
+
+// Subscription 订阅句柄
+type Subscription struct {
+	bus *Bus
+	sub *subscriber
+}
+
+// envelope 队列中的事件
+type envelope struct {
+	topic string
+	data  any
+}
+
+type subscriber struct {
+	bus     *Bus
+	id      uint64
+	pattern []string
+	opts    SubscribeOptions
+
+	accepts func(data any) bool // 事件类型是否为 T
+	handle  func(ctx context.Context, topic string, data any, attempt int) error
+
+	// 异步投递
+	mu       sync.RWMutex // 发布者持读锁发送，stop 持写锁关闭队列
+	closed   bool
+	queue    chan envelope
+	done     chan struct{} // stop 开始时关闭，唤醒阻塞的发布者
+	finished chan struct{} // 队列排空、goroutine退出后关闭
+	stopOnce sync.Once
+	runner   atomic.Uint64 // 处理队列的goroutine的ID，在自己的处理器中停止时不能等待自己
+}
+
+// Subscribe 订阅 topic（可以包含通配符），只接收类型为 T 的事件
+func Subscribe[T any](b *Bus, topic Topic[T], handler Handler[T], opts SubscribeOptions) (*Subscription, error) {
+	pattern, err := parsePattern(string(topic))
+	if err != nil {
+		return nil, err
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 64
+	}
+
+	s := &subscriber{
+		bus:     b,
+		pattern: pattern,
+		opts:    opts,
+		accepts: func(data any) bool {
+			_, ok := data.(T)
+			return ok
+		},
+		handle: func(ctx context.Context, topic string, data any, attempt int) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = &PanicError{Value: r, Stack: debug.Stack()}
+				}
+			}()
+			return handler(ctx, Event[T]{Topic: topic, Data: data.(T), Attempt: attempt})
+		},
+	}
+	if opts.Async {
+		s.queue = make(chan envelope, opts.QueueSize)
+		s.done = make(chan struct{})
+		s.finished = make(chan struct{})
+	}
+
+	if err := b.add(s); err != nil {
+		return nil, err
+	}
+	if opts.Async {
+		go s.run()
+	}
+	return &Subscription{bus: b, sub: s}, nil
+}
+
+// Unsubscribe 取消订阅：不再接收新事件，等待队列中已有的事件处理完
+// 在异步订阅者自己的处理器中调用时不等待，剩余事件在处理器返回后继续处理
+// 重复调用返回 false
+func (s *Subscription) Unsubscribe() bool {
+	if !s.bus.remove(s.sub) {
+		return false
+	}
+	s.sub.stop(goroutineID())
+	return true
+}
+
+// Publish 发布事件到 topic
+// 同步订阅者在当前goroutine中依次处理，返回它们重试用尽后的错误；
+// 异步订阅者只入队，Block 策略下队列满时等待，ctx 结束返回 ctx.Err()
+func Publish[T any](ctx context.Context, b *Bus, topic Topic[T], data T) error {
+	name := string(topic)
+	if err := validTopic(name); err != nil {
+		return err
+	}
+	subs, err := b.matching(name)
+	if err != nil {
+		return err
+	}
+	c := b.counters(name)
+	c.published.Add(1)
+
+	var errs []error
+	for _, s := range subs {
+		if !s.accepts(data) {
+			continue // 通配符订阅了其他类型的事件
+		}
+		if s.opts.Async {
+			if err := s.enqueue(ctx, c, envelope{topic: name, data: data}); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := s.deliver(ctx, c, name, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver 按重试策略调用处理器，重试用尽后交给 OnError
+func (s *subscriber) deliver(ctx context.Context, c *topicCounters, topic string, data any) error {
+	attempts := max(s.opts.Retry.MaxAttempts, 1)
+	wait := s.opts.Retry.Backoff
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			c.retried.Add(1)
+			if !sleep(ctx, wait) {
+				err = errors.Join(err, ctx.Err())
+				break
+			}
+			wait *= 2
+			if s.opts.Retry.MaxBackoff > 0 {
+				wait = min(wait, s.opts.Retry.MaxBackoff)
+			}
+		}
+		if err = s.handle(ctx, topic, data, attempt); err == nil {
+			c.delivered.Add(1)
+			return nil
+		}
+	}
+
+	c.failed.Add(1)
+	err = fmt.Errorf("eventbus: %s handler #%d: %w", topic, s.id, err)
+	if onError := s.bus.opts.OnError; onError != nil {
+		onError(topic, data, err)
+	}
+	return err
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// enqueue 按溢出策略放入异步队列；订阅已取消时静默忽略
+func (s *subscriber) enqueue(ctx context.Context, c *topicCounters, ev envelope) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		c.dropped.Add(1) // 匹配之后、入队之前订阅被取消
+		return nil
+	}
+
+	switch s.opts.Overflow {
+	case DropNewest:
+		select {
+		case s.queue <- ev:
+		default:
+			c.dropped.Add(1)
+		}
+	case DropOldest:
+		for {
+			select {
+			case s.queue <- ev:
+				return nil
+			default:
+			}
+			select {
+			case old := <-s.queue:
+				s.bus.counters(old.topic).dropped.Add(1) // 被挤掉的旧事件可能属于另一个主题
+			default:
+			}
+		}
+	default:
+		select {
+		case s.queue <- ev:
+		case <-s.done:
+			c.dropped.Add(1) // 等待期间订阅被取消
+		case <-ctx.Done():
+			c.dropped.Add(1)
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// run 异步订阅者的goroutine，队列关闭后处理完剩余事件再退出
+// Close 超时取消总线ctx后，剩余事件直接丢弃，不再用已取消的ctx逐个调用处理器
+func (s *subscriber) run() {
+	defer close(s.finished)
+	s.runner.Store(goroutineID())
+	for ev := range s.queue {
+		c := s.bus.counters(ev.topic)
+		if s.bus.ctx.Err() != nil {
+			c.dropped.Add(1)
+			continue
+		}
+		s.deliver(s.bus.ctx, c, ev.topic, ev.data)
+	}
+}
+
+// stop 关闭队列并等待剩余事件处理完，可以重复调用
+// caller 是调用者的goroutine ID：由自己的处理器调用时等待会死锁，直接返回
+func (s *subscriber) stop(caller uint64) {
+	if !s.opts.Async {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.mu.Lock()
+		s.closed = true
+		close(s.queue)
+		s.mu.Unlock()
+	})
+	if s.runner.Load() == caller {
+		return
+	}
+	<-s.finished
+}
+
+// goroutineID 当前goroutine的ID，从调用栈的第一行 "goroutine 7 [running]:" 解析
+// 只用于判断调用者是否为订阅者自己的goroutine
+func goroutineID() uint64 {
+	var buf [64]byte
+	line := string(buf[:runtime.Stack(buf[:], false)])
+	line, _ = strings.CutPrefix(line, "goroutine ")
+	id, _, _ := strings.Cut(line, " ")
+	n, _ := strconv.ParseUint(id, 10, 64)
+	return n
+}
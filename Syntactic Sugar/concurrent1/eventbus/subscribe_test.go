@@ -0,0 +1,325 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+)
+
+// recorder 记录异步处理器收到的事件；第一个事件阻塞到 release 关闭，用来把队列填满
+type recorder struct {
+	mu      sync.Mutex
+	got     []int
+	started chan struct{}
+	release chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{started: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (r *recorder) handle(ctx context.Context, ev Event[int]) error {
+	select {
+	case r.started <- struct{}{}:
+		<-r.release
+	default:
+	}
+	r.mu.Lock()
+	r.got = append(r.got, ev.Data)
+	r.mu.Unlock()
+	return nil
+}
+
+func TestOverflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		overflow Overflow
+		want     []int
+	}{
+		{"DropNewest", DropNewest, []int{1, 2, 3}},
+		{"DropOldest", DropOldest, []int{1, 4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(Options{})
+			r := newRecorder()
+			sub, _ := Subscribe(b, Topic[int]("n"), r.handle,
+				SubscribeOptions{Async: true, QueueSize: 2, Overflow: tt.overflow})
+
+			Publish(context.Background(), b, Topic[int]("n"), 1)
+			<-r.started // 1 正在处理，之后的事件只能进队列
+			for i := 2; i <= 5; i++ {
+				if err := Publish(context.Background(), b, Topic[int]("n"), i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			close(r.release)
+			sub.Unsubscribe()
+
+			if !slices.Equal(r.got, tt.want) {
+				t.Fatalf("delivered %v; want %v", r.got, tt.want)
+			}
+			if st := b.Stats()["n"]; st.Dropped != 2 || st.Delivered != 3 {
+				t.Fatalf("stats = %+v; want Dropped 2, Delivered 3", st)
+			}
+		})
+	}
+}
+
+// TestOverflowBlock Block 策略下队列满时 Publish 等待，ctx 结束时返回错误并计为丢弃
+func TestOverflowBlock(t *testing.T) {
+	b := New(Options{})
+	r := newRecorder()
+	sub, _ := Subscribe(b, Topic[int]("n"), r.handle, SubscribeOptions{Async: true, QueueSize: 2})
+
+	Publish(context.Background(), b, Topic[int]("n"), 1)
+	<-r.started
+	Publish(context.Background(), b, Topic[int]("n"), 2)
+	Publish(context.Background(), b, Topic[int]("n"), 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := Publish(ctx, b, Topic[int]("n"), 4); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Publish to full queue = %v; want DeadlineExceeded", err)
+	}
+
+	// 放行后阻塞的发布者能继续入队
+	published := make(chan error, 1)
+	go func() { published <- Publish(context.Background(), b, Topic[int]("n"), 5) }()
+	close(r.release)
+	if err := <-published; err != nil {
+		t.Fatal(err)
+	}
+	sub.Unsubscribe()
+
+	if want := []int{1, 2, 3, 5}; !slices.Equal(r.got, want) {
+		t.Fatalf("delivered %v; want %v", r.got, want)
+	}
+	if st := b.Stats()["n"]; st.Dropped != 1 {
+		t.Fatalf("Dropped = %d; want 1", st.Dropped)
+	}
+}
+
+// TestRetryAtLeastOnce 处理失败按重试策略重试，Attempt 递增
+func TestRetryAtLeastOnce(t *testing.T) {
+	b := New(Options{})
+	var attempts []int
+	Subscribe(b, Topic[string]("mail"), func(ctx context.Context, ev Event[string]) error {
+		attempts = append(attempts, ev.Attempt)
+		if ev.Attempt < 3 {
+			return errors.New("smtp unavailable")
+		}
+		return nil
+	}, SubscribeOptions{Retry: RetryPolicy{MaxAttempts: 5, Backoff: time.Millisecond}})
+
+	if err := Publish(context.Background(), b, Topic[string]("mail"), "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(attempts, []int{1, 2, 3}) {
+		t.Fatalf("attempts = %v; want [1 2 3]", attempts)
+	}
+	if st := b.Stats()["mail"]; st.Retried != 2 || st.Delivered != 1 || st.Failed != 0 {
+		t.Fatalf("stats = %+v; want Retried 2, Delivered 1", st)
+	}
+}
+
+// TestRetryExhausted 重试用尽后返回错误并交给 OnError（死信），panic 同样计为失败
+func TestRetryExhausted(t *testing.T) {
+	var dead []any
+	b := New(Options{OnError: func(topic string, event any, err error) {
+		dead = append(dead, event)
+	}})
+	Subscribe(b, Topic[int]("n"), func(ctx context.Context, ev Event[int]) error {
+		panic("boom")
+	}, SubscribeOptions{Retry: RetryPolicy{MaxAttempts: 2}})
+
+	err := Publish(context.Background(), b, Topic[int]("n"), 7)
+	var p *PanicError
+	if !errors.As(err, &p) || p.Value != "boom" {
+		t.Fatalf("Publish = %v; want PanicError(boom)", err)
+	}
+	if !slices.Equal(dead, []any{7}) {
+		t.Fatalf("OnError events = %v; want [7]", dead)
+	}
+	if st := b.Stats()["n"]; st.Retried != 1 || st.Failed != 1 {
+		t.Fatalf("stats = %+v; want Retried 1, Failed 1", st)
+	}
+}
+
+// TestUnsubscribeDrains Unsubscribe 等待队列中已有的事件处理完，之后不再接收
+func TestUnsubscribeDrains(t *testing.T) {
+	b := New(Options{})
+	var (
+		mu  sync.Mutex
+		got []int
+	)
+	sub, _ := Subscribe(b, Topic[int]("n"), func(ctx context.Context, ev Event[int]) error {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		got = append(got, ev.Data)
+		mu.Unlock()
+		return nil
+	}, SubscribeOptions{Async: true, QueueSize: 16})
+
+	for i := range 10 {
+		Publish(context.Background(), b, Topic[int]("n"), i)
+	}
+	if !sub.Unsubscribe() {
+		t.Fatal("Unsubscribe = false")
+	}
+	if len(got) != 10 {
+		t.Fatalf("handled %d events before Unsubscribe returned; want 10", len(got))
+	}
+	if sub.Unsubscribe() {
+		t.Fatal("second Unsubscribe = true")
+	}
+	Publish(context.Background(), b, Topic[int]("n"), 99)
+	if len(got) != 10 {
+		t.Fatal("event delivered after Unsubscribe")
+	}
+}
+
+// TestTypedWildcard 通配符订阅只接收类型为 T 的事件，T 为 any 时接收全部
+func TestTypedWildcard(t *testing.T) {
+	b := New(Options{})
+	var ints, all int
+	Subscribe(b, Topic[int]("#"), func(context.Context, Event[int]) error { ints++; return nil }, SubscribeOptions{})
+	Subscribe(b, Topic[any]("#"), func(context.Context, Event[any]) error { all++; return nil }, SubscribeOptions{})
+
+	Publish(context.Background(), b, Topic[int]("a.b"), 1)
+	Publish(context.Background(), b, Topic[string]("a.c"), "x")
+	if ints != 1 || all != 2 {
+		t.Fatalf("int subscriber got %d, any subscriber got %d; want 1, 2", ints, all)
+	}
+}
+
+// TestBlockDroppedOnUnsubscribe Block 策略下等待入队的事件因订阅取消而丢失时计为 Dropped
+func TestBlockDroppedOnUnsubscribe(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	b := New(Options{})
+	r := newRecorder()
+	sub, _ := Subscribe(b, Topic[int]("n"), r.handle, SubscribeOptions{Async: true, QueueSize: 1})
+
+	Publish(context.Background(), b, Topic[int]("n"), 1)
+	<-r.started
+	Publish(context.Background(), b, Topic[int]("n"), 2) // 填满队列
+
+	published := make(chan error, 1)
+	go func() { published <- Publish(context.Background(), b, Topic[int]("n"), 3) }()
+	for !blockedIn("eventbus.(*subscriber).enqueue") {
+		time.Sleep(time.Millisecond)
+	}
+
+	unsubscribed := make(chan bool, 1)
+	go func() { unsubscribed <- sub.Unsubscribe() }()
+	if err := <-published; err != nil {
+		t.Fatalf("Publish = %v; want nil", err)
+	}
+	if st := b.Stats()["n"]; st.Dropped != 1 {
+		t.Fatalf("Dropped = %d; want 1", st.Dropped)
+	}
+	close(r.release)
+	<-unsubscribed
+	if want := []int{1, 2}; !slices.Equal(r.got, want) {
+		t.Fatalf("delivered %v; want %v", r.got, want)
+	}
+}
+
+// TestUnsubscribeFromHandler 异步处理器取消自己的订阅不会死锁，队列中剩余的事件照常处理
+func TestUnsubscribeFromHandler(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	b := New(Options{})
+	var (
+		sub  *Subscription
+		got  []int
+		gate = make(chan struct{})
+		done = make(chan bool, 1)
+	)
+	sub, _ = Subscribe(b, Topic[int]("n"), func(ctx context.Context, ev Event[int]) error {
+		got = append(got, ev.Data)
+		if ev.Data == 1 {
+			<-gate
+			done <- sub.Unsubscribe()
+		}
+		return nil
+	}, SubscribeOptions{Async: true, QueueSize: 4})
+
+	for i := 1; i <= 3; i++ {
+		Publish(context.Background(), b, Topic[int]("n"), i)
+	}
+	close(gate)
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatal("Unsubscribe from handler = false")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Unsubscribe from its own handler deadlocked")
+	}
+
+	// 其他goroutine中的 Unsubscribe 返回 false；Close 等待剩余事件处理完
+	if sub.Unsubscribe() {
+		t.Fatal("second Unsubscribe = true")
+	}
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-sub.sub.finished
+	if want := []int{1, 2, 3}; !slices.Equal(got, want) {
+		t.Fatalf("delivered %v; want %v", got, want)
+	}
+}
+
+// TestCloseFromHandler 在异步处理器中关闭总线：等待其他订阅者，不等待自己
+func TestCloseFromHandler(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	b := New(Options{})
+	var other []int
+	Subscribe(b, Topic[int]("n"), func(ctx context.Context, ev Event[int]) error {
+		time.Sleep(time.Millisecond)
+		other = append(other, ev.Data)
+		return nil
+	}, SubscribeOptions{Async: true, QueueSize: 8})
+
+	closed := make(chan error, 1)
+	self, _ := Subscribe(b, Topic[int]("n"), func(ctx context.Context, ev Event[int]) error {
+		if ev.Data == 1 {
+			closed <- b.Close(context.Background())
+		}
+		return nil
+	}, SubscribeOptions{Async: true, QueueSize: 8})
+
+	for i := 1; i <= 3; i++ {
+		Publish(context.Background(), b, Topic[int]("n"), i)
+	}
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("Close = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close from a handler deadlocked")
+	}
+	if want := []int{1, 2, 3}; !slices.Equal(other, want) {
+		t.Fatalf("other subscriber got %v; want %v", other, want)
+	}
+
+	// 自己队列中剩余的 2、3 在 Close 返回后不再处理
+	<-self.sub.finished
+	if st := b.Stats()["n"]; st.Delivered != 4 || st.Dropped != 2 {
+		t.Fatalf("stats = %+v; want Delivered 4, Dropped 2", st)
+	}
+}
+
+// blockedIn 是否有goroutine阻塞在 fn 中
+func blockedIn(fn string) bool {
+	return slices.ContainsFunc(leakcheck.All(), func(g leakcheck.Goroutine) bool {
+		return g.State == "select" && strings.Contains(g.Stack, fn)
+	})
+}
@@ -0,0 +1,242 @@
+// ============================= 1. Goroutine泄漏检测 ====================
+// 测试前记录正在运行的goroutine，测试后再次检查：
+// - 新出现且仍未退出的goroutine视为泄漏
+// - goroutine退出需要时间，检查会按指数退避重试直到超时，而不是固定 time.Sleep
+// - 失败时报告泄漏goroutine的完整调用栈
+// - Ignore 列表用于忽略已知的后台goroutine
+//
+// 用法：
+//
+//	func TestXxx(t *testing.T) {
+//		defer leakcheck.Verify(t, leakcheck.Options{})()
+//		...
+//	}
+
+package leakcheck
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TB testing.TB 的子集，*testing.T 和 *testing.B 都满足该接口
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Options 检查选项
+type Options struct {
+	Timeout time.Duration // 等待goroutine退出的最长时间，默认1秒
+	Ignore  []string      // 调用栈中包含这些函数名的goroutine不视为泄漏
+}
+
+// defaultIgnore 运行时和标准库按需启动、不会退出的后台goroutine
+// 不能忽略 testing.tRunner：忽略规则也匹配 "created by" 行，测试函数里启动的goroutine
+// 都由它创建，忽略后等于不检查；调用 Verify 的测试goroutine本身按ID排除。
+// 只忽略停在 t.Parallel 里、等待父测试返回才开始运行的并行子测试
+var defaultIgnore = []string{
+	"os/signal.signal_recv",
+	"os/signal.loop",
+	"runtime.ensureSigM",
+	"runtime/trace.Start",
+	"testing.(*M).startAlarm",
+	"testing.(*T).Parallel",
+}
+
+// Goroutine 一个goroutine的调用栈信息
+type Goroutine struct {
+	ID    uint64
+	State string // 例如 "chan receive"、"select"
+	Func  string // 栈顶函数
+	Stack string // 完整调用栈
+}
+
+func (g Goroutine) String() string {
+	return fmt.Sprintf("goroutine %d [%s] in %s", g.ID, g.State, g.Func)
+}
+
+// LeakError 检查失败时返回的错误，包含所有泄漏goroutine的调用栈
+type LeakError struct {
+	Goroutines []Goroutine
+}
+
+func (e *LeakError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "leakcheck: found %d leaked goroutine(s)", len(e.Goroutines))
+	for _, g := range e.Goroutines {
+		b.WriteString("\n\n")
+		b.WriteString(g.Stack)
+	}
+	return b.String()
+}
+
+// Snapshot 某一时刻正在运行的goroutine集合
+type Snapshot struct {
+	ids map[uint64]bool
+}
+
+// Take 记录当前正在运行的goroutine（包括调用者自身）
+func Take() *Snapshot {
+	s := &Snapshot{ids: make(map[uint64]bool)}
+	for _, g := range goroutines() {
+		s.ids[g.ID] = true
+	}
+	return s
+}
+
+// Leaked 返回快照之后启动且仍在运行的goroutine，按退避间隔重试直到超时
+func (s *Snapshot) Leaked(opts Options) []Goroutine {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	deadline := time.Now().Add(timeout)
+
+	backoff := time.Millisecond
+	for {
+		leaked := s.find(opts.Ignore)
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(min(backoff, time.Until(deadline)))
+		backoff = min(backoff*2, 100*time.Millisecond)
+	}
+}
+
+// Check 与 Leaked 相同，存在泄漏时返回 *LeakError
+func (s *Snapshot) Check(opts Options) error {
+	if leaked := s.Leaked(opts); len(leaked) > 0 {
+		return &LeakError{Goroutines: leaked}
+	}
+	return nil
+}
+
+func (s *Snapshot) find(ignore []string) []Goroutine {
+	var leaked []Goroutine
+	self := currentID()
+	for _, g := range goroutines() {
+		if s.ids[g.ID] || g.ID == self || ignored(g, defaultIgnore) || ignored(g, ignore) {
+			continue
+		}
+		leaked = append(leaked, g)
+	}
+	return leaked
+}
+
+// Verify 立即记录快照，返回的函数在测试结束时检查泄漏并通过 t.Errorf 报告
+func Verify(t TB, opts Options) func() {
+	t.Helper()
+	snap := Take()
+	return func() {
+		t.Helper()
+		if err := snap.Check(opts); err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+}
+
+// ============================= 2. 解析调用栈 ====================
+
+// All 返回除调用者以外的所有goroutine
+func All() []Goroutine {
+	self := currentID()
+	gs := goroutines()
+	for i, g := range gs {
+		if g.ID == self {
+			return append(gs[:i], gs[i+1:]...)
+		}
+	}
+	return gs
+}
+
+func goroutines() []Goroutine {
+	var gs []Goroutine
+	for block := range strings.SplitSeq(string(stacks(true)), "\n\n") {
+		if g, ok := parse(block); ok {
+			gs = append(gs, g)
+		}
+	}
+	return gs
+}
+
+// stacks 获取调用栈，缓冲区不够时加倍重试
+func stacks(all bool) []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, all)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+func currentID() uint64 {
+	g, _ := parse(string(stacks(false)))
+	return g.ID
+}
+
+// parse 解析一个goroutine块：
+//
+//	goroutine 7 [chan receive]:
+//	main.main.func1()
+//		/tmp/main.go:3 +0x19
+//	created by main.main in goroutine 1
+func parse(block string) (Goroutine, bool) {
+	block = strings.TrimSpace(block)
+	header, rest, _ := strings.Cut(block, "\n")
+
+	header, ok := strings.CutPrefix(header, "goroutine ")
+	if !ok {
+		return Goroutine{}, false
+	}
+	idStr, state, ok := strings.Cut(header, " [")
+	if !ok {
+		return Goroutine{}, false
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return Goroutine{}, false
+	}
+	state, _, _ = strings.Cut(state, "]")
+	state, _, _ = strings.Cut(state, ",") // 去掉 ", 2 minutes" 等等待时长
+
+	top, _, _ := strings.Cut(rest, "\n")
+	return Goroutine{
+		ID:    id,
+		State: state,
+		Func:  funcName(top),
+		Stack: block,
+	}, true
+}
+
+// funcName 从 "pkg.(*T).Method(0x1, ...)" 中取出函数名
+func funcName(line string) string {
+	if i := strings.LastIndexByte(line, '('); i > 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// ignored 调用栈的任一函数（包括 created by）命中忽略列表
+func ignored(g Goroutine, ignore []string) bool {
+	if len(ignore) == 0 {
+		return false
+	}
+	for line := range strings.Lines(g.Stack) {
+		if strings.HasPrefix(line, "\t") {
+			continue // 文件和行号
+		}
+		line = strings.TrimPrefix(strings.TrimSpace(line), "created by ")
+		for _, name := range ignore {
+			if strings.Contains(line, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package leakcheck
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// recorder 记录 Verify 报告的错误，代替 *testing.T 以免测试本身失败
+type recorder struct{ errs []string }
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+// TestVerifyReportsTestGoroutine 测试函数里直接启动的goroutine（created by testing.tRunner）也要被检查到
+func TestVerifyReportsTestGoroutine(t *testing.T) {
+	var r recorder
+	check := Verify(&r, Options{Timeout: 50 * time.Millisecond})
+
+	stop := make(chan struct{})
+	go func() { <-stop }()
+	check()
+	close(stop)
+
+	if len(r.errs) != 1 {
+		t.Fatalf("Verify reported %d errors; want 1 for the blocked goroutine", len(r.errs))
+	}
+}
+
+func TestVerifyWaitsForExit(t *testing.T) {
+	var r recorder
+	check := Verify(&r, Options{})
+
+	done := make(chan struct{})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(done)
+	}()
+	t.Run("sub", func(t *testing.T) {}) // 已结束的子测试goroutine不算泄漏
+	check()
+	<-done
+
+	if len(r.errs) != 0 {
+		t.Fatalf("unexpected leak report: %v", r.errs)
+	}
+}
+
+// TestVerifyIgnoresParallelSubtests 停在 t.Parallel 中的子测试不是泄漏
+func TestVerifyIgnoresParallelSubtests(t *testing.T) {
+	var r recorder
+	t.Cleanup(func() {
+		if len(r.errs) != 0 {
+			t.Errorf("unexpected leak report: %v", r.errs)
+		}
+	})
+	defer Verify(&r, Options{Timeout: 50 * time.Millisecond})()
+
+	t.Run("parallel", func(t *testing.T) {
+		t.Parallel()
+	})
+}
@@ -0,0 +1,232 @@
+package lockfree
+
+// 线性一致性检查
+// 并发数据结构正确的标准：每个操作看起来都在调用和返回之间的某一瞬间原子地生效
+// 检查方法：记录并发历史（每个操作的调用、返回时刻和结果），搜索一个顺序执行，使得
+// - A 返回早于 B 调用时，A 排在 B 前面（保持实时先后）
+// - 按这个顺序在顺序规约（model）上重放，每个操作的结果都一致
+// 搜索是指数级的，只适合几十个操作的小历史，压力测试用大量随机的小历史覆盖各种交错
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// operation 历史中的一次操作
+type operation struct {
+	call, ret int64 // 逻辑时刻，由 history 分配
+	input     any
+	output    any
+}
+
+// history 并发安全的操作记录器
+type history struct {
+	clock atomic.Int64
+	mu    sync.Mutex
+	ops   []operation
+}
+
+// begin 在调用操作之前获取调用时刻
+func (h *history) begin() int64 {
+	return h.clock.Add(1)
+}
+
+// end 在操作返回之后记录
+func (h *history) end(call int64, input, output any) {
+	ret := h.clock.Add(1)
+	h.mu.Lock()
+	h.ops = append(h.ops, operation{call: call, ret: ret, input: input, output: output})
+	h.mu.Unlock()
+}
+
+func (h *history) operations() []operation {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.ops)
+}
+
+// model 顺序规约
+type model[S any] struct {
+	init func() S
+	// step 在 state 上执行 input，结果与 output 一致时返回新状态和 true；不能修改 state
+	step func(state S, input, output any) (S, bool)
+}
+
+// linearizable 历史是否线性一致，最多支持64个操作
+func linearizable[S any](ops []operation, m model[S]) bool {
+	if len(ops) > 64 {
+		panic("lockfree: history too long to check")
+	}
+	all := uint64(1)<<len(ops) - 1
+	failed := make(map[string]bool) // 已经证明走不通的 (剩余操作, 状态)
+
+	var search func(remaining uint64, state S) bool
+	search = func(remaining uint64, state S) bool {
+		if remaining == 0 {
+			return true
+		}
+		key := fmt.Sprintf("%x|%v", remaining, state)
+		if failed[key] {
+			return false
+		}
+
+		// 只有在所有剩余操作返回之前就已调用的操作，才可能排在下一个
+		minReturn := int64(-1)
+		for i := range ops {
+			if remaining&(1<<i) != 0 && (minReturn < 0 || ops[i].ret < minReturn) {
+				minReturn = ops[i].ret
+			}
+		}
+		for i, op := range ops {
+			if remaining&(1<<i) == 0 || op.call > minReturn {
+				continue
+			}
+			if next, ok := m.step(state, op.input, op.output); ok && search(remaining&^(1<<i), next) {
+				return true
+			}
+		}
+		failed[key] = true
+		return false
+	}
+	return search(all, m.init())
+}
+
+// 记录中的操作和结果
+type (
+	pushOp  struct{ value int }
+	popOp   struct{}
+	popDone struct {
+		value int
+		ok    bool
+	}
+)
+
+// 队列和栈的顺序规约：状态是当前元素，step 不修改传入的切片
+var (
+	queueModel = model[[]int]{
+		init: func() []int { return nil },
+		step: func(s []int, in, out any) ([]int, bool) {
+			if op, ok := in.(pushOp); ok {
+				return append(slices.Clip(s), op.value), true
+			}
+			r := out.(popDone)
+			if len(s) == 0 {
+				return s, !r.ok
+			}
+			return s[1:], r.ok && r.value == s[0]
+		},
+	}
+	stackModel = model[[]int]{
+		init: func() []int { return nil },
+		step: func(s []int, in, out any) ([]int, bool) {
+			if op, ok := in.(pushOp); ok {
+				return append(slices.Clip(s), op.value), true
+			}
+			r := out.(popDone)
+			if len(s) == 0 {
+				return s, !r.ok
+			}
+			return s[:len(s)-1], r.ok && r.value == s[len(s)-1]
+		},
+	}
+)
+
+// recordPushPop 几个goroutine随机 push/pop，记录并发历史
+func recordPushPop(push func(int), pop func() (int, bool), workers, opsPerWorker int) []operation {
+	var (
+		h  history
+		wg sync.WaitGroup
+	)
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range opsPerWorker {
+				if rand.Intn(2) == 0 {
+					v := w*100 + i
+					call := h.begin()
+					push(v)
+					h.end(call, pushOp{v}, nil)
+				} else {
+					call := h.begin()
+					v, ok := pop()
+					h.end(call, popOp{}, popDone{v, ok})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return h.operations()
+}
+
+// checkLinearizable 大量随机的小并发历史，逐个搜索合法的顺序执行，返回不一致的轮数
+func checkLinearizable(m model[[]int], rounds int, newImpl func() (func(int), func() (int, bool))) int {
+	failures := 0
+	for range rounds {
+		push, pop := newImpl()
+		if !linearizable(recordPushPop(push, pop, 3, 6), m) {
+			failures++
+		}
+	}
+	return failures
+}
+
+// racyStack 没有用CAS的错误实现，并发 Push 会丢失元素，用来验证检查器能发现问题
+type racyStack struct {
+	top atomic.Pointer[[]int]
+}
+
+func (s *racyStack) Push(v int) {
+	cur := s.top.Load()
+	next := append(slices.Clone(*cur), v)
+	runtime.Gosched() // 放大读取和写入之间的窗口
+	s.top.Store(&next)
+}
+
+func (s *racyStack) Pop() (int, bool) {
+	cur := s.top.Load()
+	if len(*cur) == 0 {
+		return 0, false
+	}
+	next := slices.Clone((*cur)[:len(*cur)-1])
+	runtime.Gosched()
+	s.top.Store(&next)
+	return (*cur)[len(*cur)-1], true
+}
+
+// TestCheckerDetectsRace 检查器本身的测试：手工构造的历史和有竞争的实现
+func TestCheckerDetectsRace(t *testing.T) {
+	// push(1) 完成后 pop 却返回空：不存在合法的顺序
+	bad := []operation{
+		{call: 1, ret: 2, input: pushOp{1}},
+		{call: 3, ret: 4, input: popOp{}, output: popDone{}},
+	}
+	if linearizable(bad, queueModel) {
+		t.Fatal("empty pop after completed push reported linearizable")
+	}
+	// 两个操作重叠：pop 可以排在 push 之后
+	overlap := []operation{
+		{call: 1, ret: 4, input: pushOp{1}},
+		{call: 2, ret: 3, input: popOp{}, output: popDone{1, true}},
+	}
+	if !linearizable(overlap, queueModel) {
+		t.Fatal("overlapping push/pop reported non-linearizable")
+	}
+
+	if testing.Short() {
+		t.Skip("racy stack needs many rounds to lose an update")
+	}
+	failures := checkLinearizable(stackModel, 300, func() (func(int), func() (int, bool)) {
+		s := &racyStack{}
+		s.top.Store(&[]int{})
+		return s.Push, s.Pop
+	})
+	if failures == 0 {
+		t.Fatal("checker found no violation in racyStack over 300 rounds")
+	}
+}
@@ -0,0 +1,124 @@
+// ============================= 3. 写时复制 Copy-On-Write ====================
+// 读多写少的数据（路由表、黑名单、配置项）：
+// - 读：一次原子加载拿到不可变快照，完全无锁，读者之间互不影响
+// - 写：复制当前快照、修改副本、CAS 替换指针；有并发写入时重试
+// 每次写入都复制整个集合，写入频繁或集合很大时应改用 cmap 等分片结构
+
+package lockfree
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"sync/atomic"
+)
+
+// COWSlice 写时复制切片，零值可用
+type COWSlice[T any] struct {
+	p atomic.Pointer[[]T]
+}
+
+// Load 当前快照，调用者不能修改返回的切片
+func (s *COWSlice[T]) Load() []T {
+	if p := s.p.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// Len 元素数量
+func (s *COWSlice[T]) Len() int {
+	return len(s.Load())
+}
+
+// All 遍历调用时的快照，遍历期间的写入不可见
+func (s *COWSlice[T]) All() iter.Seq2[int, T] {
+	return slices.All(s.Load())
+}
+
+// Update 以当前快照的副本调用 fn，用返回值替换快照；有并发写入时 fn 会被重新调用
+func (s *COWSlice[T]) Update(fn func(cur []T) []T) {
+	for {
+		old := s.p.Load()
+		var cur []T
+		if old != nil {
+			cur = slices.Clone(*old)
+		}
+		next := fn(cur)
+		if s.p.CompareAndSwap(old, &next) {
+			return
+		}
+	}
+}
+
+// Append 追加元素
+func (s *COWSlice[T]) Append(vs ...T) {
+	s.Update(func(cur []T) []T { return append(cur, vs...) })
+}
+
+// DeleteFunc 删除满足 del 的元素
+func (s *COWSlice[T]) DeleteFunc(del func(T) bool) {
+	s.Update(func(cur []T) []T { return slices.DeleteFunc(cur, del) })
+}
+
+// COWMap 写时复制map，零值可用
+type COWMap[K comparable, V any] struct {
+	p atomic.Pointer[map[K]V]
+}
+
+func (m *COWMap[K, V]) snapshot() map[K]V {
+	if p := m.p.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// Load 读取键
+func (m *COWMap[K, V]) Load(key K) (V, bool) {
+	v, ok := m.snapshot()[key]
+	return v, ok
+}
+
+// Len 键数量
+func (m *COWMap[K, V]) Len() int {
+	return len(m.snapshot())
+}
+
+// Snapshot 当前快照，调用者不能修改返回的map
+func (m *COWMap[K, V]) Snapshot() map[K]V {
+	return m.snapshot()
+}
+
+// All 遍历调用时的快照
+func (m *COWMap[K, V]) All() iter.Seq2[K, V] {
+	return maps.All(m.snapshot())
+}
+
+// Update 以当前快照的副本调用 fn，fn 可以修改这个副本；有并发写入时 fn 会被重新调用
+// 多个键的修改在一次替换中完成，读者要么看到全部修改，要么一个都看不到
+func (m *COWMap[K, V]) Update(fn func(m map[K]V)) {
+	for {
+		old := m.p.Load()
+		next := make(map[K]V)
+		if old != nil {
+			next = maps.Clone(*old)
+		}
+		fn(next)
+		if m.p.CompareAndSwap(old, &next) {
+			return
+		}
+	}
+}
+
+// Store 写入键
+func (m *COWMap[K, V]) Store(key K, value V) {
+	m.Update(func(cur map[K]V) { cur[key] = value })
+}
+
+// Delete 删除键
+func (m *COWMap[K, V]) Delete(key K) {
+	if _, ok := m.Load(key); !ok {
+		return // 不存在时不必复制
+	}
+	m.Update(func(cur map[K]V) { delete(cur, key) })
+}
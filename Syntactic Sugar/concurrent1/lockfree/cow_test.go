@@ -0,0 +1,107 @@
+package lockfree
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// rwMutexMap 读写锁保护的map，作为基准对照
+type rwMutexMap[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+}
+
+func (m *rwMutexMap[K, V]) Load(key K) (V, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.m[key]
+	return v, ok
+}
+
+func (m *rwMutexMap[K, V]) Store(key K, value V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m[key] = value
+}
+
+// TestCOWMapAtomicUpdate 一次 Update 修改多个键，读者永远看不到转账的中间状态
+func TestCOWMapAtomicUpdate(t *testing.T) {
+	var accounts COWMap[string, int]
+	accounts.Update(func(m map[string]int) { m["alice"], m["bob"] = 100, 100 })
+
+	var (
+		stop    atomic.Bool
+		torn    atomic.Int64
+		readers sync.WaitGroup
+	)
+	for range 4 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for !stop.Load() {
+				snap := accounts.Snapshot()
+				if snap["alice"]+snap["bob"] != 200 {
+					torn.Add(1)
+				}
+			}
+		}()
+	}
+	for i := range 1000 {
+		amount := i%7 - 3
+		accounts.Update(func(m map[string]int) {
+			m["alice"] -= amount
+			m["bob"] += amount
+		})
+	}
+	stop.Store(true)
+	readers.Wait()
+
+	if n := torn.Load(); n != 0 {
+		t.Fatalf("readers saw %d intermediate states", n)
+	}
+}
+
+func TestCOWSlice(t *testing.T) {
+	var routes COWSlice[string]
+	routes.Append("/api/v1", "/api/v2", "/debug")
+	before := routes.Load()
+	routes.DeleteFunc(func(r string) bool { return r == "/debug" })
+
+	if got := routes.Load(); !slices.Equal(got, []string{"/api/v1", "/api/v2"}) {
+		t.Fatalf("Load = %v", got)
+	}
+	// 之前取得的快照不受后续修改影响
+	if len(before) != 3 || before[2] != "/debug" {
+		t.Fatalf("earlier snapshot changed: %v", before)
+	}
+}
+
+// BenchmarkCOWMap 读多写少（1%写）：COWMap 读不加锁，写要复制整个map
+func BenchmarkCOWMap(b *testing.B) {
+	const keys = 64
+	readMostly := func(b *testing.B, load func(int) (int, bool), store func(int, int)) {
+		for i := range keys {
+			store(i, i)
+		}
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				if i%100 == 0 {
+					store(i%keys, i)
+				} else {
+					load(i % keys)
+				}
+			}
+		})
+	}
+	b.Run("cow", func(b *testing.B) {
+		m := &COWMap[int, int]{}
+		readMostly(b, m.Load, m.Store)
+	})
+	b.Run("rwmutex", func(b *testing.B) {
+		m := &rwMutexMap[int, int]{m: make(map[int]int)}
+		readMostly(b, m.Load, m.Store)
+	})
+}
@@ -0,0 +1,83 @@
+// ============================= 1. Michael-Scott 无锁队列 ====================
+// 多生产者多消费者(MPMC)的无锁FIFO队列：
+// - 单向链表 + 哨兵节点，head 指向哨兵，tail 指向最后一个节点（或落后一个）
+// - 入队：CAS 把新节点挂到 tail.next，再 CAS 推进 tail；推进失败说明别人已经帮忙推进
+// - 出队：CAS 把 head 移到 head.next，旧的 head.next 成为新的哨兵
+// - 任何goroutine发现 tail 落后都会帮忙推进，因此不会有goroutine被卡住
+// 节点从不复用，由GC回收，所以不存在C语言实现中的ABA和内存回收问题
+
+package lockfree
+
+import "sync/atomic"
+
+// Queue 无锁FIFO队列，零值不可用，使用 NewQueue 创建
+type Queue[T any] struct {
+	head atomic.Pointer[node[T]]
+	tail atomic.Pointer[node[T]]
+	len  atomic.Int64
+}
+
+type node[T any] struct {
+	value T
+	next  atomic.Pointer[node[T]]
+}
+
+// NewQueue 创建空队列
+func NewQueue[T any]() *Queue[T] {
+	q := &Queue[T]{}
+	sentinel := &node[T]{}
+	q.head.Store(sentinel)
+	q.tail.Store(sentinel)
+	return q
+}
+
+// Enqueue 入队
+func (q *Queue[T]) Enqueue(v T) {
+	n := &node[T]{value: v}
+	for {
+		tail := q.tail.Load()
+		next := tail.next.Load()
+		if tail != q.tail.Load() {
+			continue // tail 已经变化，重新读取
+		}
+		if next != nil {
+			q.tail.CompareAndSwap(tail, next) // tail 落后，帮忙推进
+			continue
+		}
+		if tail.next.CompareAndSwap(nil, n) {
+			q.tail.CompareAndSwap(tail, n) // 失败也没关系，其他goroutine会推进
+			q.len.Add(1)
+			return
+		}
+	}
+}
+
+// Dequeue 出队，队列为空时 ok 为 false
+// 出队的值仍被新的哨兵节点引用，直到下一次出队才能被GC回收
+func (q *Queue[T]) Dequeue() (v T, ok bool) {
+	for {
+		head := q.head.Load()
+		tail := q.tail.Load()
+		next := head.next.Load()
+		if head != q.head.Load() {
+			continue
+		}
+		if next == nil {
+			return v, false // 只有哨兵：队列为空
+		}
+		if head == tail {
+			q.tail.CompareAndSwap(tail, next) // 有新节点但 tail 还没推进
+			continue
+		}
+		v = next.value // 必须在 CAS 之前读取，CAS 成功后 next 可能被其他goroutine出队
+		if q.head.CompareAndSwap(head, next) {
+			q.len.Add(-1)
+			return v, true
+		}
+	}
+}
+
+// Len 元素数量；并发修改时只是近似值
+func (q *Queue[T]) Len() int {
+	return int(max(q.len.Load(), 0))
+}
@@ -0,0 +1,120 @@
+package lockfree
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// mutexQueue 一把互斥锁保护的切片队列，作为基准对照
+type mutexQueue[T any] struct {
+	mu    sync.Mutex
+	items []T
+}
+
+func (q *mutexQueue[T]) Enqueue(v T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = append(q.items, v)
+}
+
+func (q *mutexQueue[T]) Dequeue() (v T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return v, false
+	}
+	v = q.items[0]
+	q.items = q.items[1:]
+	return v, true
+}
+
+// TestQueueMPMC 多生产者多消费者：不丢失、不重复，同一生产者的元素保持FIFO
+func TestQueueMPMC(t *testing.T) {
+	const producers, consumers, perProducer = 4, 4, 20000
+	q := NewQueue[int]()
+	var (
+		wg       sync.WaitGroup
+		consumed atomic.Int64
+		seen     = make([]atomic.Int32, producers*perProducer)
+		orderBad atomic.Int64
+	)
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perProducer {
+				q.Enqueue(p*perProducer + i)
+			}
+		}()
+	}
+	for range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := make([]int, producers) // 每个生产者上一次看到的序号
+			for i := range last {
+				last[i] = -1
+			}
+			for consumed.Load() < producers*perProducer {
+				v, ok := q.Dequeue()
+				if !ok {
+					runtime.Gosched()
+					continue
+				}
+				consumed.Add(1)
+				seen[v].Add(1)
+				p, i := v/perProducer, v%perProducer
+				if i <= last[p] {
+					orderBad.Add(1)
+				}
+				last[p] = i
+			}
+		}()
+	}
+	wg.Wait()
+
+	for v := range seen {
+		if n := seen[v].Load(); n != 1 {
+			t.Fatalf("value %d dequeued %d times", v, n)
+		}
+	}
+	if n := orderBad.Load(); n != 0 {
+		t.Fatalf("%d values out of per-producer order", n)
+	}
+	if n := q.Len(); n != 0 {
+		t.Fatalf("Len = %d after draining", n)
+	}
+}
+
+func TestQueueLinearizable(t *testing.T) {
+	failures := checkLinearizable(queueModel, 300, func() (func(int), func() (int, bool)) {
+		q := NewQueue[int]()
+		return q.Enqueue, q.Dequeue
+	})
+	if failures > 0 {
+		t.Fatalf("%d of 300 histories not linearizable", failures)
+	}
+}
+
+// pushPop 并行地成对入队出队
+func pushPop(b *testing.B, push func(int), pop func() (int, bool)) {
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			push(i)
+			pop()
+		}
+	})
+}
+
+func BenchmarkQueue(b *testing.B) {
+	b.Run("lockfree", func(b *testing.B) {
+		q := NewQueue[int]()
+		pushPop(b, q.Enqueue, q.Dequeue)
+	})
+	b.Run("mutex", func(b *testing.B) {
+		q := &mutexQueue[int]{}
+		pushPop(b, q.Enqueue, q.Dequeue)
+	})
+}
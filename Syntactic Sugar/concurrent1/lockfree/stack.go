@@ -0,0 +1,59 @@
+// ============================= 2. Treiber 无锁栈 ====================
+// 栈顶指针 + 不可变节点：
+// - 入栈：新节点的 next 指向当前栈顶，CAS 把栈顶换成新节点
+// - 出栈：CAS 把栈顶换成 top.next
+// 节点发布后不再修改，读取 next 不需要同步；同样依赖GC避免ABA
+
+package lockfree
+
+import "sync/atomic"
+
+// Stack 无锁LIFO栈，零值可用
+type Stack[T any] struct {
+	top atomic.Pointer[stackNode[T]]
+	len atomic.Int64
+}
+
+type stackNode[T any] struct {
+	value T
+	next  *stackNode[T]
+}
+
+// Push 入栈
+func (s *Stack[T]) Push(v T) {
+	n := &stackNode[T]{value: v}
+	for {
+		n.next = s.top.Load() // n 尚未发布，可以直接修改
+		if s.top.CompareAndSwap(n.next, n) {
+			s.len.Add(1)
+			return
+		}
+	}
+}
+
+// Pop 出栈，栈为空时 ok 为 false
+func (s *Stack[T]) Pop() (v T, ok bool) {
+	for {
+		top := s.top.Load()
+		if top == nil {
+			return v, false
+		}
+		if s.top.CompareAndSwap(top, top.next) {
+			s.len.Add(-1)
+			return top.value, true
+		}
+	}
+}
+
+// Peek 查看栈顶但不出栈
+func (s *Stack[T]) Peek() (v T, ok bool) {
+	if top := s.top.Load(); top != nil {
+		return top.value, true
+	}
+	return v, false
+}
+
+// Len 元素数量；并发修改时只是近似值
+func (s *Stack[T]) Len() int {
+	return int(max(s.len.Load(), 0))
+}
@@ -0,0 +1,65 @@
+package lockfree
+
+import (
+	"sync"
+	"testing"
+)
+
+// mutexStack 一把互斥锁保护的切片栈，作为基准对照
+type mutexStack[T any] struct {
+	mu    sync.Mutex
+	items []T
+}
+
+func (s *mutexStack[T]) Push(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append(s.items, v)
+}
+
+func (s *mutexStack[T]) Pop() (v T, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.items) == 0 {
+		return v, false
+	}
+	v = s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return v, true
+}
+
+func TestStackZeroValue(t *testing.T) {
+	var s Stack[int] // 零值可用
+	if _, ok := s.Pop(); ok {
+		t.Fatal("Pop on empty stack ok = true")
+	}
+	s.Push(1)
+	s.Push(2)
+	if v, ok := s.Peek(); !ok || v != 2 || s.Len() != 2 {
+		t.Fatalf("Peek = %d, %t, Len = %d; want 2, true, 2", v, ok, s.Len())
+	}
+	if v, _ := s.Pop(); v != 2 {
+		t.Fatalf("Pop = %d; want 2", v)
+	}
+}
+
+func TestStackLinearizable(t *testing.T) {
+	failures := checkLinearizable(stackModel, 300, func() (func(int), func() (int, bool)) {
+		s := &Stack[int]{}
+		return s.Push, s.Pop
+	})
+	if failures > 0 {
+		t.Fatalf("%d of 300 histories not linearizable", failures)
+	}
+}
+
+func BenchmarkStack(b *testing.B) {
+	b.Run("lockfree", func(b *testing.B) {
+		s := &Stack[int]{}
+		pushPop(b, s.Push, s.Pop)
+	})
+	b.Run("mutex", func(b *testing.B) {
+		s := &mutexStack[int]{}
+		pushPop(b, s.Push, s.Pop)
+	})
+}
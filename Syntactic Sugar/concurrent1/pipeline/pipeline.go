@@ -0,0 +1,401 @@
+// ============================= 1. 流水线概述 ====================
+// 把 producer/consumer 扩展为多阶段流水线：
+//   Source → Stage(fn, workers) → ... → Sink
+// - 每个阶段有独立的缓冲管道，workers>1 时自动扇出/扇入
+// - Ordered 选项让多worker阶段保持输入顺序输出
+// - 任一阶段返回错误会取消整个流水线的ctx，所有goroutine随之退出
+// - 每个阶段记录输入/输出/错误数量，用于计算吞吐量
+
+package pipeline
+
+import (
+	"context"
+	"iter"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options 阶段配置
+type Options struct {
+	Workers int  // 并发worker数量，默认1
+	Buffer  int  // 输出管道缓冲大小，默认0
+	Ordered bool // 多worker时是否按输入顺序输出
+}
+
+// Pipeline 管理所有阶段的生命周期和第一个错误
+type Pipeline struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+
+	mu     sync.Mutex
+	stages []*counter
+}
+
+// Stream 连接两个阶段的类型化数据流
+type Stream[T any] struct {
+	p  *Pipeline
+	ch <-chan T
+}
+
+// New 创建流水线，parent 取消时整个流水线停止
+func New(parent context.Context) *Pipeline {
+	ctx, cancel := context.WithCancelCause(parent)
+	return &Pipeline{
+		parent: parent,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Context 返回流水线ctx，出错时会被取消
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Wait 等待所有阶段退出，返回第一个阶段错误或父ctx的错误
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.cancel(nil)
+	if p.err != nil {
+		return p.err
+	}
+	return p.parent.Err()
+}
+
+// fail 记录第一个错误并取消所有阶段
+func (p *Pipeline) fail(err error) {
+	p.errOnce.Do(func() {
+		p.err = err
+		p.cancel(err)
+	})
+}
+
+func (p *Pipeline) spawn(fn func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		fn()
+	}()
+}
+
+// ============================= 2. 数据源 ====================
+
+// Generate 通过 emit 产生数据；emit 返回 false 表示流水线已取消，应停止生产
+func Generate[T any](p *Pipeline, name string, opts Options, fn func(ctx context.Context, emit func(T) bool) error) *Stream[T] {
+	c := p.newCounter(name)
+	out := make(chan T, opts.Buffer)
+
+	p.spawn(func() {
+		defer close(out)
+		defer c.finish()
+		emit := func(v T) bool {
+			if !send(p.ctx, out, v) {
+				return false
+			}
+			c.out.Add(1)
+			return true
+		}
+		if err := fn(p.ctx, emit); err != nil {
+			c.errors.Add(1)
+			p.fail(err)
+		}
+	})
+	return &Stream[T]{p: p, ch: out}
+}
+
+// FromSlice 把切片作为数据源
+func FromSlice[T any](p *Pipeline, name string, items []T) *Stream[T] {
+	return Generate(p, name, Options{}, func(ctx context.Context, emit func(T) bool) error {
+		for _, item := range items {
+			if !emit(item) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// Merge 扇入：把多个数据流合并为一个（不保证顺序），没有输入流时返回已关闭的数据流
+func Merge[T any](p *Pipeline, name string, streams ...*Stream[T]) *Stream[T] {
+	c := p.newCounter(name)
+	out := make(chan T)
+
+	var wg sync.WaitGroup
+	for _, s := range streams {
+		wg.Add(1)
+		p.spawn(func() {
+			defer wg.Done()
+			for v := range recvAll(p.ctx, s.ch) {
+				c.in.Add(1)
+				if !send(p.ctx, out, v) {
+					return
+				}
+				c.out.Add(1)
+			}
+		})
+	}
+	p.spawn(func() {
+		wg.Wait()
+		c.finish()
+		close(out)
+	})
+	return &Stream[T]{p: p, ch: out}
+}
+
+// ============================= 3. 处理阶段 ====================
+
+// Stage 用 fn 处理每个元素，返回错误会短路整个流水线
+func Stage[In, Out any](s *Stream[In], name string, opts Options, fn func(ctx context.Context, v In) (Out, error)) *Stream[Out] {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	p := s.p
+	c := p.newCounter(name)
+	out := make(chan Out, opts.Buffer)
+
+	process := func(v In) (Out, bool) {
+		c.in.Add(1)
+		start := time.Now()
+		r, err := fn(p.ctx, v)
+		c.busy.Add(int64(time.Since(start)))
+		if err != nil {
+			c.errors.Add(1)
+			p.fail(err)
+			return r, false
+		}
+		return r, true
+	}
+
+	if opts.Ordered && opts.Workers > 1 {
+		orderedStage(s, c, opts, out, process)
+		return &Stream[Out]{p: p, ch: out}
+	}
+
+	// 无序扇出：多个worker共享输入管道，共同写入输出管道
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		p.spawn(func() {
+			defer wg.Done()
+			for v := range recvAll(p.ctx, s.ch) {
+				r, ok := process(v)
+				if !ok || !send(p.ctx, out, r) {
+					return
+				}
+				c.out.Add(1)
+			}
+		})
+	}
+	p.spawn(func() {
+		wg.Wait()
+		c.finish()
+		close(out)
+	})
+	return &Stream[Out]{p: p, ch: out}
+}
+
+// orderedStage 给输入编号，worker并发处理后按编号重排输出
+// window 限制在途元素数量，避免慢元素导致重排缓冲无限增长
+func orderedStage[In, Out any](s *Stream[In], c *counter, opts Options, out chan<- Out, process func(In) (Out, bool)) {
+	type job struct {
+		seq uint64
+		v   In
+	}
+	type result struct {
+		seq uint64
+		v   Out
+	}
+
+	p := s.p
+	jobs := make(chan job)
+	results := make(chan result, opts.Workers)
+	window := make(chan struct{}, opts.Workers+opts.Buffer)
+
+	// 分发：编号并占用窗口
+	p.spawn(func() {
+		defer close(jobs)
+		var seq uint64
+		for v := range recvAll(p.ctx, s.ch) {
+			if !send(p.ctx, window, struct{}{}) || !send(p.ctx, jobs, job{seq, v}) {
+				return
+			}
+			seq++
+		}
+	})
+
+	// 并发处理
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		p.spawn(func() {
+			defer wg.Done()
+			for j := range recvAll(p.ctx, jobs) {
+				r, ok := process(j.v)
+				if !ok || !send(p.ctx, results, result{j.seq, r}) {
+					return
+				}
+			}
+		})
+	}
+	p.spawn(func() {
+		wg.Wait()
+		close(results)
+	})
+
+	// 重排：按编号依次输出并释放窗口
+	p.spawn(func() {
+		defer close(out)
+		defer c.finish()
+		pending := make(map[uint64]Out)
+		var next uint64
+		for r := range recvAll(p.ctx, results) {
+			pending[r.seq] = r.v
+			for {
+				v, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+				if !send(p.ctx, out, v) {
+					return
+				}
+				c.out.Add(1)
+				<-window
+			}
+		}
+	})
+}
+
+// ============================= 4. 数据汇 ====================
+
+// Sink 消费最终结果，返回错误同样会短路整个流水线
+func Sink[T any](s *Stream[T], name string, opts Options, fn func(ctx context.Context, v T) error) {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	p := s.p
+	c := p.newCounter(name)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		p.spawn(func() {
+			defer wg.Done()
+			for v := range recvAll(p.ctx, s.ch) {
+				c.in.Add(1)
+				start := time.Now()
+				err := fn(p.ctx, v)
+				c.busy.Add(int64(time.Since(start)))
+				if err != nil {
+					c.errors.Add(1)
+					p.fail(err)
+					return
+				}
+				c.out.Add(1)
+			}
+		})
+	}
+	p.spawn(func() {
+		wg.Wait()
+		c.finish()
+	})
+}
+
+// ============================= 5. 管道工具 ====================
+
+// send 在ctx取消时放弃发送，避免goroutine泄漏
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// recvAll 以迭代器形式读取管道，直到管道关闭或ctx取消
+func recvAll[T any](ctx context.Context, ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			select {
+			case v, ok := <-ch:
+				if !ok || !yield(v) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// ============================= 6. 吞吐量统计 ====================
+
+// StageStats 单个阶段的统计快照
+type StageStats struct {
+	Name       string
+	In         int64         // 接收的元素数
+	Out        int64         // 输出的元素数
+	Errors     int64         // 返回错误的次数
+	Busy       time.Duration // 所有worker处理耗时之和
+	Throughput float64       // 每秒输出元素数，按阶段运行时间计算，阶段结束后不再变化
+}
+
+type counter struct {
+	name   string
+	in     atomic.Int64
+	out    atomic.Int64
+	errors atomic.Int64
+	busy   atomic.Int64
+	start  time.Time
+	end    atomic.Int64 // 阶段结束时刻(UnixNano)，运行中为0
+}
+
+// finish 记录阶段结束时刻，吞吐量从此固定
+func (c *counter) finish() {
+	c.end.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// elapsed 阶段从创建到结束（或到现在）的时间
+func (c *counter) elapsed() time.Duration {
+	if end := c.end.Load(); end != 0 {
+		return time.Unix(0, end).Sub(c.start)
+	}
+	return time.Since(c.start)
+}
+
+func (p *Pipeline) newCounter(name string) *counter {
+	c := &counter{name: name, start: time.Now()}
+	p.mu.Lock()
+	p.stages = append(p.stages, c)
+	p.mu.Unlock()
+	return c
+}
+
+// Stats 返回所有阶段的统计，按创建顺序排列
+func (p *Pipeline) Stats() []StageStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := make([]StageStats, 0, len(p.stages))
+	for _, c := range p.stages {
+		st := StageStats{
+			Name:   c.name,
+			In:     c.in.Load(),
+			Out:    c.out.Load(),
+			Errors: c.errors.Load(),
+			Busy:   time.Duration(c.busy.Load()),
+		}
+		if elapsed := c.elapsed().Seconds(); elapsed > 0 {
+			st.Throughput = float64(st.Out) / elapsed
+		}
+		stats = append(stats, st)
+	}
+	return stats
+}
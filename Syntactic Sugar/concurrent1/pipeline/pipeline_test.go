@@ -0,0 +1,204 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+)
+
+// collect 把数据流的所有元素收集到切片，流水线结束后返回
+func collect[T any](s *Stream[T]) *[]T {
+	var (
+		mu  sync.Mutex
+		out []T
+	)
+	Sink(s, "collect", Options{}, func(_ context.Context, v T) error {
+		mu.Lock()
+		out = append(out, v)
+		mu.Unlock()
+		return nil
+	})
+	return &out
+}
+
+func seq(n int) []int {
+	items := make([]int, n)
+	for i := range items {
+		items[i] = i
+	}
+	return items
+}
+
+func TestOrdered(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	for _, ordered := range []bool{true, false} {
+		p := New(context.Background())
+		src := FromSlice(p, "src", seq(100))
+		// 偶数元素处理得慢，无序输出时会被后面的元素超过
+		doubled := Stage(src, "double", Options{Workers: 8, Ordered: ordered}, func(_ context.Context, v int) (int, error) {
+			if v%2 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			return v * 2, nil
+		})
+		got := collect(doubled)
+		if err := p.Wait(); err != nil {
+			t.Fatal(err)
+		}
+
+		want := make([]int, 100)
+		for i := range want {
+			want[i] = i * 2
+		}
+		if ordered && !slices.Equal(*got, want) {
+			t.Errorf("Ordered output = %v, want %v", *got, want)
+		}
+		sorted := slices.Sorted(slices.Values(*got))
+		if !slices.Equal(sorted, want) {
+			t.Errorf("ordered=%t: output set = %v, want %v", ordered, sorted, want)
+		}
+	}
+}
+
+func TestFirstErrorCancels(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	errBad := errors.New("bad item")
+	p := New(context.Background())
+
+	// 无限数据源：只能靠取消停止
+	src := Generate(p, "src", Options{}, func(ctx context.Context, emit func(int) bool) error {
+		for i := 0; ; i++ {
+			if !emit(i) {
+				return nil
+			}
+		}
+	})
+	checked := Stage(src, "check", Options{Workers: 4, Buffer: 64}, func(_ context.Context, v int) (int, error) {
+		if v == 10 {
+			return 0, errBad
+		}
+		return v, nil
+	})
+	// 下游阶段阻塞在ctx上，被第一个错误取消
+	slow := Stage(checked, "slow", Options{}, func(ctx context.Context, v int) (int, error) {
+		if v < 5 {
+			return v, nil
+		}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	collect(slow)
+
+	if err := p.Wait(); err != errBad {
+		t.Fatalf("Wait = %v, want %v", err, errBad)
+	}
+	if cause := context.Cause(p.Context()); cause != errBad {
+		t.Errorf("Context cause = %v, want %v", cause, errBad)
+	}
+	for _, st := range p.Stats() {
+		if st.Name == "check" && st.Errors != 1 {
+			t.Errorf("check stage errors = %d, want 1", st.Errors)
+		}
+	}
+}
+
+func TestSinkErrorAndParentCancel(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	errStop := errors.New("stop")
+	p := New(context.Background())
+	Sink(FromSlice(p, "src", seq(10)), "sink", Options{}, func(_ context.Context, v int) error {
+		if v == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if err := p.Wait(); err != errStop {
+		t.Errorf("Wait = %v, want %v", err, errStop)
+	}
+
+	// 父ctx取消时 Wait 返回父ctx的错误
+	parent, cancel := context.WithCancel(context.Background())
+	p = New(parent)
+	Sink(FromSlice(p, "src", seq(10)), "sink", Options{}, func(_ context.Context, v int) error {
+		if v == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if err := p.Wait(); err != context.Canceled {
+		t.Errorf("Wait after parent cancel = %v, want context.Canceled", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	p := New(context.Background())
+	merged := Merge(p, "merge", FromSlice(p, "a", seq(50)), FromSlice(p, "b", seq(30)))
+	got := collect(merged)
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*got) != 80 {
+		t.Errorf("merged %d items, want 80", len(*got))
+	}
+
+	// 没有输入流：返回已关闭的数据流
+	p = New(context.Background())
+	got = collect(Merge[int](p, "empty"))
+	if err := p.Wait(); err != nil || len(*got) != 0 {
+		t.Errorf("empty Merge: %v, %v", *got, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	p := New(context.Background())
+	src := FromSlice(p, "src", seq(20))
+	squared := Stage(src, "square", Options{Workers: 3, Ordered: true, Buffer: 2}, func(_ context.Context, v int) (int, error) {
+		return v * v, nil
+	})
+	strs := Stage(squared, "format", Options{}, func(_ context.Context, v int) (string, error) {
+		time.Sleep(100 * time.Microsecond)
+		return "x", nil
+	})
+	collect(strs)
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []StageStats{
+		{Name: "src", In: 0, Out: 20},
+		{Name: "square", In: 20, Out: 20},
+		{Name: "format", In: 20, Out: 20},
+		{Name: "collect", In: 20, Out: 20},
+	}
+	stats := p.Stats()
+	if len(stats) != len(want) {
+		t.Fatalf("Stats has %d stages, want %d", len(stats), len(want))
+	}
+	for i, st := range stats {
+		w := want[i]
+		if st.Name != w.Name || st.In != w.In || st.Out != w.Out || st.Errors != 0 {
+			t.Errorf("stage %d = %+v, want %+v", i, st, w)
+		}
+		if st.Throughput <= 0 {
+			t.Errorf("stage %s throughput = %v", st.Name, st.Throughput)
+		}
+	}
+	if stats[2].Busy < 20*100*time.Microsecond {
+		t.Errorf("format busy = %v, want >= 2ms", stats[2].Busy)
+	}
+
+	// 阶段结束后吞吐量固定
+	time.Sleep(10 * time.Millisecond)
+	for i, st := range p.Stats() {
+		if st.Throughput != stats[i].Throughput {
+			t.Errorf("stage %s throughput changed after finish: %v -> %v", st.Name, stats[i].Throughput, st.Throughput)
+		}
+	}
+}
@@ -2,10 +2,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"Syntactic_Sugar/concurrent1/chanx"
+	"Syntactic_Sugar/concurrent1/syncx"
 )
 
 func selectBasicDemo() {
@@ -336,46 +341,196 @@ func consumer(id int, queue *[]int, cond *sync.Cond, wg *sync.WaitGroup) {
 }
 
 // ============================= 7. 实用工具函数 ====================
+// 通用的非阻塞/超时/组合操作已抽取到 chanx 包，支持任意元素类型
 func utilityFunctions() {
 	fmt.Println("\n=== 实用工具函数 ===")
 
 	// 7.1 非阻塞发送
 	ch := make(chan int, 1)
 
-	if trySend(ch, 100) {
+	if chanx.TrySend(ch, 100) {
 		fmt.Println("发送成功")
 	} else {
 		fmt.Println("发送失败")
 	}
 
 	// 7.2 非阻塞接收
-	if val, ok := tryRecv(ch); ok {
+	if val, ok := chanx.TryRecv(ch); ok {
 		fmt.Printf("接收成功: %d\n", val)
 	} else {
 		fmt.Println("接收失败")
 	}
 
+	// 7.3 超时发送：无接收者时100ms后放弃
+	strCh := make(chan string)
+	fmt.Printf("超时发送结果: %t\n", chanx.SendTimeout(strCh, "hello", 100*time.Millisecond))
+
+	// 7.4 合并多个管道 + 攒批
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gen := func(start int) <-chan int {
+		out := make(chan int)
+		go func() {
+			defer close(out)
+			for i := start; i < start+5; i++ {
+				out <- i
+			}
+		}()
+		return out
+	}
+	merged := chanx.Merge(ctx, gen(0), gen(100))
+	for batch := range chanx.Batch(ctx, merged, 4, 50*time.Millisecond) {
+		fmt.Printf("批次: %v\n", batch)
+	}
+
+	// 7.5 防抖：快速连续的输入只保留最后一个
+	keys := make(chan string)
+	go func() {
+		defer close(keys)
+		for _, k := range []string{"g", "go", "gol", "gola", "golang"} {
+			keys <- k
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	for v := range chanx.Debounce(ctx, keys, 50*time.Millisecond) {
+		fmt.Printf("防抖输出: %s\n", v)
+	}
+
 	close(ch)
 }
 
-// 非阻塞发送
-func trySend(ch chan int, value int) bool {
-	select {
-	case ch <- value:
-		return true
-	default:
-		return false
+// ============================= 8. 高级同步原语 ====================
+func advancedSyncDemo() {
+	fmt.Println("\n=== 高级同步原语 ===")
+	ctx := context.Background()
+
+	// 8.1 带权信号量：总容量10，大任务占用更多许可
+	sem := syncx.NewSemaphore(10)
+	var wg sync.WaitGroup
+	for i, weight := range []int64{6, 3, 5, 2} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := sem.Acquire(ctx, weight); err != nil {
+				return
+			}
+			defer sem.Release(weight)
+			fmt.Printf("任务%d 获得 %d 个许可\n", i, weight)
+			time.Sleep(50 * time.Millisecond)
+		}()
+	}
+	wg.Wait()
+
+	// 超时获取：许可被占满时放弃
+	sem.Acquire(ctx, 10)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	fmt.Printf("许可已满时获取: %v, 非阻塞获取: %t\n", sem.Acquire(timeoutCtx, 1), sem.TryAcquire(1))
+	cancel()
+	sem.Release(10)
+
+	// 8.2 CountDownLatch：等待3个服务都启动
+	latch := syncx.NewCountDownLatch(3)
+	for _, name := range []string{"数据库", "缓存", "消息队列"} {
+		go func() {
+			time.Sleep(time.Duration(rand.Intn(50)) * time.Millisecond)
+			fmt.Printf("%s 已启动\n", name)
+			latch.CountDown()
+		}()
+	}
+	latch.Wait(ctx)
+	fmt.Println("所有依赖已就绪")
+
+	// 8.3 CyclicBarrier：3个worker每轮计算完成后一起进入下一轮
+	barrier := syncx.NewCyclicBarrier(3, func() { fmt.Println("--- 本轮全部完成 ---") })
+	wg.Add(3)
+	for id := 0; id < 3; id++ {
+		go func() {
+			defer wg.Done()
+			for round := 0; round < 2; round++ {
+				time.Sleep(time.Duration(rand.Intn(30)) * time.Millisecond)
+				fmt.Printf("worker%d 完成第%d轮\n", id, round)
+				barrier.Await(ctx)
+			}
+		}()
 	}
+	wg.Wait()
+
+	// 8.4 Phaser：参与者中途退出，后续阶段不再等待它
+	phaser := syncx.NewPhaser(3)
+	wg.Add(3)
+	for id := 0; id < 3; id++ {
+		go func() {
+			defer wg.Done()
+			for phase := 0; phase < 3; phase++ {
+				if id == 2 && phase == 1 {
+					phaser.ArriveAndDeregister()
+					fmt.Printf("参与者%d 在阶段%d退出\n", id, phase)
+					return
+				}
+				phaser.ArriveAndAwaitAdvance(ctx)
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Printf("Phaser最终阶段: %d, 剩余参与者: %d\n", phaser.Phase(), phaser.Parties())
+
+	syncPrimitivesStress()
 }
 
-// 非阻塞接收
-func tryRecv(ch chan int) (int, bool) {
-	select {
-	case val, ok := <-ch:
-		return val, ok
-	default:
-		return 0, false
+// syncPrimitivesStress 高并发校验不变量，建议使用 go run -race 运行
+func syncPrimitivesStress() {
+	const goroutines = 64
+	ctx := context.Background()
+
+	// 信号量：任意时刻持有的许可不超过容量，部分请求随机取消
+	sem := syncx.NewSemaphore(8)
+	var held, maxHeld atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				n := int64(rand.Intn(4) + 1)
+				acquireCtx, cancel := context.WithTimeout(ctx, time.Duration(rand.Intn(200))*time.Microsecond)
+				err := sem.Acquire(acquireCtx, n)
+				cancel()
+				if err != nil {
+					continue
+				}
+				cur := held.Add(n)
+				for {
+					old := maxHeld.Load()
+					if cur <= old || maxHeld.CompareAndSwap(old, cur) {
+						break
+					}
+				}
+				held.Add(-n)
+				sem.Release(n)
+			}
+		}()
 	}
+	wg.Wait()
+
+	// 栅栏：每一代都恰好有 goroutines 个参与者通过
+	var passed atomic.Int64
+	barrier := syncx.NewCyclicBarrier(goroutines, nil)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < 20; round++ {
+				if _, err := barrier.Await(ctx); err == nil {
+					passed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	fmt.Printf("压力测试 - 信号量最大持有: %d (上限8), 所有许可已归还: %t, 栅栏通过: %d/%d\n",
+		maxHeld.Load(), sem.TryAcquire(8), passed.Load(), goroutines*20)
 }
 
 // ============================= 主函数入口 ====================
@@ -389,6 +544,7 @@ func main() {
 	rwMutexDemo()
 	condDemo()
 	utilityFunctions()
+	advancedSyncDemo()
 
 	fmt.Println("\n=== 所有示例执行完成 ===")
 }
@@ -427,13 +583,25 @@ Select和锁核心知识点总结：
    - Broadcast()：唤醒所有等待者
    - 必须与锁配合使用，Wait前加锁
 
-6. 最佳实践：
+6. chanx管道工具：
+   - TrySend/TryRecv：select+default实现非阻塞操作
+   - SendTimeout/RecvContext：超时或ctx取消时放弃
+   - Merge/OrDone：扇入和可取消的管道包装
+   - Debounce/Throttle/Batch：防抖、节流、攒批
+
+7. 高级同步原语(syncx)：
+   - Semaphore：带权信号量，FIFO等待，Acquire支持ctx取消
+   - CountDownLatch：计数归零后一次性放行
+   - CyclicBarrier：凑齐参与者后放行，可循环使用，取消会打破栅栏
+   - Phaser：参与者可动态注册/注销的多阶段栅栏
+
+8. 最佳实践：
    - 使用defer确保锁释放
    - 条件变量检查使用for循环而非if
    - 锁粒度尽量小，减少持有时间
    - Select超时避免永久阻塞
 
-7. 注意事项：
+9. 注意事项：
    - 避免锁嵌套导致的死锁
    - nil管道在select中会被忽略
    - 条件变量Wait前必须持有锁
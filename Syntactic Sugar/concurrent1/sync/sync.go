@@ -2,9 +2,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/lockfree"
+	"Syntactic_Sugar/concurrent1/syncx"
+	"Syntactic_Sugar/config"
 )
 
 func onceDemo() {
@@ -259,7 +269,69 @@ func atomicValueDemo() {
 	// badValue.Store(123) // 这会panic: store of inconsistently typed value
 }
 
-// ============================= 7. 性能对比示例 ====================
+// ============================= 7. Watched 热加载配置 ====================
+// atomicValueDemo 中手动 Store 新配置的通用封装：
+// 文件变化或 SIGHUP 时重新解析，校验通过才原子替换，并通知订阅者
+type ServerConfig struct {
+	Server  string        `config:"server,required"`
+	Port    int           `config:"port" default:"8080"`
+	Timeout time.Duration `config:"timeout" default:"30s"`
+}
+
+func watchedConfigDemo() {
+	fmt.Println("\n=== Watched 热加载配置演示 ===")
+
+	path := filepath.Join(os.TempDir(), "watched-demo.yaml")
+	defer os.Remove(path)
+	os.WriteFile(path, []byte("server: localhost\nport: 8080\n"), 0o644)
+
+	// 7.1 首次加载，校验端口范围
+	watched, err := config.NewWatched(path, config.BindFile[ServerConfig], config.WatchOptions[ServerConfig]{
+		Interval: 50 * time.Millisecond,
+		SIGHUP:   true,
+		Validate: func(cfg ServerConfig) error {
+			if cfg.Port <= 0 || cfg.Port > 65535 {
+				return errors.New("端口超出范围")
+			}
+			return nil
+		},
+		OnError: func(err error) {
+			fmt.Println("新配置被拒绝:", err)
+		},
+	})
+	if err != nil {
+		fmt.Println("加载配置失败:", err)
+		return
+	}
+	fmt.Printf("初始配置: %+v\n", watched.Load())
+
+	// 7.2 订阅变更
+	changed := make(chan struct{}, 1)
+	watched.Subscribe(func(old, new ServerConfig) {
+		fmt.Printf("配置变更: %+v -> %+v\n", old, new)
+		changed <- struct{}{}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watched.Watch(ctx)
+
+	// 7.3 写入无效配置：保留旧快照
+	os.WriteFile(path, []byte("server: localhost\nport: 70000\n"), 0o644)
+	time.Sleep(200 * time.Millisecond)
+	fmt.Printf("无效配置后仍为: %+v\n", watched.Load())
+
+	// 7.4 写入有效配置：原子替换
+	os.WriteFile(path, []byte("server: api.example.com\nport: 443\ntimeout: 1m\n"), 0o644)
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		fmt.Println("等待配置变更超时")
+	}
+	fmt.Printf("当前配置: %+v\n", watched.Load())
+}
+
+// ============================= 8. 性能对比示例 ====================
 func performanceDemo() {
 	fmt.Println("\n=== 性能对比演示 ===")
 
@@ -273,7 +345,7 @@ func performanceDemo() {
 	iterations := 10000
 	wg.Add(2)
 
-	// 7.1 原子操作性能
+	// 8.1 原子操作性能
 	go func() {
 		defer wg.Done()
 		for i := 0; i < iterations; i++ {
@@ -281,7 +353,7 @@ func performanceDemo() {
 		}
 	}()
 
-	// 7.2 互斥锁性能
+	// 8.2 互斥锁性能
 	go func() {
 		defer wg.Done()
 		for i := 0; i < iterations; i++ {
@@ -296,6 +368,181 @@ func performanceDemo() {
 	fmt.Printf("互斥锁结果: %d\n", mutexVal)
 }
 
+// ============================= 9. OnceErr/OnceValue/Singleflight ====================
+func onceErrDemo() {
+	fmt.Println("\n=== 可重试Once与Singleflight演示 ===")
+
+	// 9.1 OnceErr：失败可以重试，成功后不再执行
+	var (
+		connectOnce syncx.OnceErr
+		attempts    int
+	)
+	connect := func() error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("第%d次连接失败", attempts)
+		}
+		fmt.Printf("第%d次连接成功\n", attempts)
+		return nil
+	}
+	for i := 0; i < 4; i++ {
+		if err := connectOnce.Do(connect); err != nil {
+			fmt.Println("初始化失败，稍后重试:", err)
+		}
+	}
+	fmt.Printf("连接尝试次数: %d, 已完成: %t\n", attempts, connectOnce.Done())
+
+	// 9.2 OnceValue：懒加载并缓存，Reset后重新加载
+	var loads atomic.Int64
+	dbConn := syncx.NewOnceValue(func() (string, error) {
+		n := loads.Add(1)
+		return fmt.Sprintf("conn-%d", n), nil
+	})
+	conn, _ := dbConn.Get()
+	conn2, _ := dbConn.Get()
+	fmt.Printf("懒加载: %s %s\n", conn, conn2)
+	dbConn.Reset() // 模拟连接断开
+	conn3, _ := dbConn.Get()
+	fmt.Printf("Reset后重新加载: %s\n", conn3)
+
+	// 9.3 Singleflight：10个并发请求同一个键，只查询一次数据库
+	var (
+		group   syncx.Group[string, string]
+		queries atomic.Int64
+		wg      sync.WaitGroup
+		shared  atomic.Int64
+	)
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer wg.Done()
+			_, err, isShared := group.Do(context.Background(), "user:42", func(ctx context.Context) (string, error) {
+				queries.Add(1)
+				time.Sleep(50 * time.Millisecond) // 模拟慢查询
+				return "Alice", nil
+			})
+			if err == nil && isShared {
+				shared.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Printf("数据库查询次数: %d, 共享结果的调用: %d\n", queries.Load(), shared.Load())
+}
+
+// ============================= 10. 无锁数据结构 ====================
+// lockfree 包：Michael-Scott 队列、Treiber 栈、写时复制切片/map
+
+func lockFreeDemo() {
+	fmt.Println("\n=== 无锁数据结构演示 ===")
+
+	// 10.1 基本用法
+	q := lockfree.NewQueue[string]()
+	q.Enqueue("a")
+	q.Enqueue("b")
+	first, _ := q.Dequeue()
+	fmt.Printf("队列: 出队 %s, 剩余 %d\n", first, q.Len())
+
+	var st lockfree.Stack[int] // 零值可用
+	st.Push(1)
+	st.Push(2)
+	top, _ := st.Peek()
+	fmt.Printf("栈: 栈顶 %d, 长度 %d\n", top, st.Len())
+
+	// 10.2 MPMC压力测试：不丢失、不重复，同一生产者的元素保持FIFO
+	const producers, consumers, perProducer = 4, 4, 20000
+	mpmc := lockfree.NewQueue[int]()
+	var (
+		wg       sync.WaitGroup
+		consumed atomic.Int64
+		seen     = make([]atomic.Int32, producers*perProducer)
+		orderBad atomic.Int64
+	)
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perProducer {
+				mpmc.Enqueue(p*perProducer + i)
+			}
+		}()
+	}
+	for range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := make([]int, producers) // 每个生产者上一次看到的序号
+			for i := range last {
+				last[i] = -1
+			}
+			for consumed.Load() < producers*perProducer {
+				v, ok := mpmc.Dequeue()
+				if !ok {
+					runtime.Gosched()
+					continue
+				}
+				consumed.Add(1)
+				seen[v].Add(1)
+				p, i := v/perProducer, v%perProducer
+				if i <= last[p] {
+					orderBad.Add(1)
+				}
+				last[p] = i
+			}
+		}()
+	}
+	wg.Wait()
+	missing, dup := 0, 0
+	for i := range seen {
+		switch n := seen[i].Load(); {
+		case n == 0:
+			missing++
+		case n > 1:
+			dup++
+		}
+	}
+	fmt.Printf("MPMC: 出队 %d, 丢失 %d, 重复 %d, 乱序 %d\n", consumed.Load(), missing, dup, orderBad.Load())
+
+	// 10.3 写时复制：一次 Update 修改多个键，读者永远看不到转账的中间状态
+	var accounts lockfree.COWMap[string, int]
+	accounts.Update(func(m map[string]int) { m["alice"], m["bob"] = 100, 100 })
+	var (
+		stop    atomic.Bool
+		torn    atomic.Int64
+		readers sync.WaitGroup
+	)
+	for range 4 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for !stop.Load() {
+				snap := accounts.Snapshot()
+				if snap["alice"]+snap["bob"] != 200 {
+					torn.Add(1)
+				}
+			}
+		}()
+	}
+	for i := range 1000 {
+		amount := i%7 - 3
+		accounts.Update(func(m map[string]int) {
+			m["alice"] -= amount
+			m["bob"] += amount
+		})
+	}
+	stop.Store(true)
+	readers.Wait()
+	fmt.Printf("COWMap: 余额合计 %d, 读到中间状态 %d 次\n", accounts.Snapshot()["alice"]+accounts.Snapshot()["bob"], torn.Load())
+
+	var routes lockfree.COWSlice[string]
+	routes.Append("/api/v1", "/api/v2", "/debug")
+	routes.DeleteFunc(func(r string) bool { return r == "/debug" })
+	fmt.Printf("COWSlice: %v\n", routes.Load())
+
+	// 线性一致性检查（记录并发历史，搜索合法的顺序执行）以及与互斥锁版本的并行基准测试
+	// 见 lockfree 包的测试：go test -race ./concurrent1/lockfree && go test -bench . ./concurrent1/lockfree
+}
+
 // ============================= 主函数入口 ====================
 func main() {
 	onceDemo()
@@ -304,7 +551,10 @@ func main() {
 	atomicDemo()
 	casDemo()
 	atomicValueDemo()
+	watchedConfigDemo()
 	performanceDemo()
+	onceErrDemo()
+	lockFreeDemo()
 
 	fmt.Println("\n=== 所有sync示例执行完成 ===")
 }
@@ -347,14 +597,33 @@ sync包和atomic核心知识点总结：
    - 不能存储nil值
    - 适用于配置等需要原子更新的场景
 
-7. 使用场景对比：
+7. Watched热加载：
+   - 基于atomic.Pointer保存不可变快照，读取无锁
+   - 轮询文件或SIGHUP触发重新加载
+   - 解析+校验通过才替换，失败保留旧配置
+   - 订阅者收到(old, new)回调
+
+8. 可重试Once与Singleflight：
+   - OnceErr：失败不标记完成，下次调用重试
+   - OnceValue：懒加载并缓存成功结果，Reset后重新加载
+   - Group：同一个键的并发调用只执行一次，结果共享
+   - 每个调用者按自己的ctx等待，全部放弃后才取消fn
+
+9. 无锁数据结构(lockfree)：
+   - Queue：Michael-Scott队列，哨兵节点+CAS，发现tail落后时帮忙推进
+   - Stack：Treiber栈，CAS替换栈顶；节点由GC回收，不存在ABA问题
+   - COWSlice/COWMap：读取原子加载快照，写入复制+CAS替换，适合读多写少
+   - 线性一致性检查(lockfree测试)：记录并发历史，搜索符合顺序规约的执行来验证正确性
+   - 无锁不等于更快：竞争小、核数少时互斥锁往往不慢，要用基准测试说话
+
+10. 使用场景对比：
    - Once: 一次性初始化
    - Pool: 高频创建销毁的对象
    - Map: 并发安全键值存储
    - atomic: 简单计数器、标志位
    - Mutex: 复杂临界区保护
 
-8. 最佳实践：
+11. 最佳实践：
    - 选择合适的并发控制工具
    - 避免过度优化，先保证正确性
    - 注意原子类型的不可复制性
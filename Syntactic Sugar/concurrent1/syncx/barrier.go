@@ -0,0 +1,264 @@
+// ============================= 4. 闭锁、栅栏与分阶段同步 ====================
+// CountDownLatch 一次性：计数减到0后所有等待者放行，不能重置
+// CyclicBarrier   可复用：凑齐 parties 个参与者后一起放行，然后进入下一代
+// Phaser          参与者数量可变的多阶段栅栏，每个阶段全部到达后推进
+
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrBrokenBarrier 栅栏被打破：有等待者取消或调用了 Reset
+var ErrBrokenBarrier = errors.New("syncx: broken barrier")
+
+// CountDownLatch 倒计数闭锁
+type CountDownLatch struct {
+	mu    sync.Mutex
+	count int
+	done  chan struct{}
+}
+
+// NewCountDownLatch 创建计数为 count 的闭锁
+func NewCountDownLatch(count int) *CountDownLatch {
+	l := &CountDownLatch{count: count, done: make(chan struct{})}
+	if count <= 0 {
+		close(l.done)
+	}
+	return l
+}
+
+// CountDown 计数减一，减到0时放行所有等待者
+func (l *CountDownLatch) CountDown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count == 0 {
+		return
+	}
+	l.count--
+	if l.count == 0 {
+		close(l.done)
+	}
+}
+
+// Count 当前剩余计数
+func (l *CountDownLatch) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count
+}
+
+// Wait 等待计数归零或ctx取消
+func (l *CountDownLatch) Wait(ctx context.Context) error {
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Done 计数归零时关闭的管道
+func (l *CountDownLatch) Done() <-chan struct{} {
+	return l.done
+}
+
+// CyclicBarrier 可循环使用的栅栏
+type CyclicBarrier struct {
+	parties int
+	action  func() // 每代最后一个到达者在放行前执行
+
+	mu  sync.Mutex
+	gen *barrierGen
+}
+
+type barrierGen struct {
+	arrived int
+	broken  bool
+	done    chan struct{}
+}
+
+// NewCyclicBarrier 创建需要 parties 个参与者的栅栏，action 可以为 nil
+func NewCyclicBarrier(parties int, action func()) *CyclicBarrier {
+	return &CyclicBarrier{
+		parties: parties,
+		action:  action,
+		gen:     &barrierGen{done: make(chan struct{})},
+	}
+}
+
+// Await 到达栅栏并等待其他参与者，返回到达序号（0 为第一个到达）
+// 任一等待者的ctx取消都会打破本代栅栏，其他等待者收到 ErrBrokenBarrier
+func (b *CyclicBarrier) Await(ctx context.Context) (int, error) {
+	b.mu.Lock()
+	g := b.gen
+	if g.broken {
+		b.mu.Unlock()
+		return 0, ErrBrokenBarrier
+	}
+
+	index := g.arrived
+	g.arrived++
+	if g.arrived == b.parties {
+		// 最后一个到达者：执行动作，放行本代并开启下一代
+		if b.action != nil {
+			b.action()
+		}
+		close(g.done)
+		b.gen = &barrierGen{done: make(chan struct{})}
+		b.mu.Unlock()
+		return index, nil
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-g.done:
+		if g.broken {
+			return index, ErrBrokenBarrier
+		}
+		return index, nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		select {
+		case <-g.done:
+			// 取消时本代恰好完成或已被打破
+			if g.broken {
+				return index, ErrBrokenBarrier
+			}
+			return index, nil
+		default:
+			b.breakLocked()
+			return index, ctx.Err()
+		}
+	}
+}
+
+// Reset 打破当前代（等待者收到 ErrBrokenBarrier）并开启新的一代
+func (b *CyclicBarrier) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.breakLocked()
+}
+
+func (b *CyclicBarrier) breakLocked() {
+	b.gen.broken = true
+	close(b.gen.done)
+	b.gen = &barrierGen{done: make(chan struct{})}
+}
+
+// Waiting 当前代已到达的参与者数量
+func (b *CyclicBarrier) Waiting() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.gen.arrived
+}
+
+// Phaser 参与者可动态注册/注销的多阶段栅栏
+type Phaser struct {
+	mu      sync.Mutex
+	phase   int
+	parties int
+	arrived int
+	advance chan struct{} // 当前阶段推进时关闭
+}
+
+// NewPhaser 创建初始有 parties 个参与者的 Phaser
+func NewPhaser(parties int) *Phaser {
+	return &Phaser{parties: parties, advance: make(chan struct{})}
+}
+
+// Register 增加一个参与者，返回当前阶段
+func (p *Phaser) Register() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.parties++
+	return p.phase
+}
+
+// Arrive 到达当前阶段但不等待，返回到达的阶段号
+func (p *Phaser) Arrive() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	phase := p.phase
+	p.arrived++
+	p.tryAdvanceLocked()
+	return phase
+}
+
+// ArriveAndDeregister 到达并注销，之后的阶段不再等待该参与者
+func (p *Phaser) ArriveAndDeregister() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	phase := p.phase
+	p.parties--
+	p.tryAdvanceLocked()
+	return phase
+}
+
+// ArriveAndAwaitAdvance 到达并等待其他参与者，返回新的阶段号
+// ctx 取消时返回错误，但本次到达已计入，不影响其他参与者推进
+func (p *Phaser) ArriveAndAwaitAdvance(ctx context.Context) (int, error) {
+	p.mu.Lock()
+	phase := p.phase
+	advance := p.advance
+	p.arrived++
+	p.tryAdvanceLocked()
+	p.mu.Unlock()
+
+	select {
+	case <-advance:
+		return phase + 1, nil
+	case <-ctx.Done():
+		return phase, ctx.Err()
+	}
+}
+
+// AwaitAdvance 等待指定阶段结束；phase 已经过去时立即返回
+func (p *Phaser) AwaitAdvance(ctx context.Context, phase int) (int, error) {
+	p.mu.Lock()
+	if p.phase != phase {
+		cur := p.phase
+		p.mu.Unlock()
+		return cur, nil
+	}
+	advance := p.advance
+	p.mu.Unlock()
+
+	select {
+	case <-advance:
+		return phase + 1, nil
+	case <-ctx.Done():
+		return phase, ctx.Err()
+	}
+}
+
+// Phase 当前阶段号
+func (p *Phaser) Phase() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.phase
+}
+
+// Parties 当前注册的参与者数量
+func (p *Phaser) Parties() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.parties
+}
+
+// tryAdvanceLocked 所有参与者到达时推进到下一阶段
+func (p *Phaser) tryAdvanceLocked() {
+	if p.parties > 0 && p.arrived < p.parties {
+		return
+	}
+	if p.parties <= 0 && p.arrived == 0 {
+		return // 没有参与者时不推进
+	}
+	p.phase++
+	p.arrived = 0
+	close(p.advance)
+	p.advance = make(chan struct{})
+}
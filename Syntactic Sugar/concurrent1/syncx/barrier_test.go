@@ -0,0 +1,198 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCountDownLatchConcurrent(t *testing.T) {
+	const n = 100
+	l := NewCountDownLatch(n)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	// 多出的 CountDown 不应使计数变为负数
+	for range n + 10 {
+		go l.CountDown()
+	}
+	wg.Wait()
+	if c := l.Count(); c != 0 {
+		t.Fatalf("Count = %d; want 0", c)
+	}
+	select {
+	case <-l.Done():
+	default:
+		t.Fatal("Done not closed after count reached zero")
+	}
+}
+
+// TestCyclicBarrierGenerations 多代复用：动作每代恰好执行一次且先于放行，
+// 每代的到达序号是 0..parties-1 的排列
+func TestCyclicBarrierGenerations(t *testing.T) {
+	const parties, rounds = 4, 200
+	gens := 0 // 只在动作内写；读者再次到达之前不会开始下一代
+	b := NewCyclicBarrier(parties, func() { gens++ })
+
+	indexes := make([][]int, rounds)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range parties {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range rounds {
+				idx, err := b.Await(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if got := gens; got != r+1 {
+					t.Errorf("round %d saw %d generations", r, got)
+					return
+				}
+				mu.Lock()
+				indexes[r] = append(indexes[r], idx)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for r, idx := range indexes {
+		sort.Ints(idx)
+		for i, v := range idx {
+			if v != i {
+				t.Fatalf("round %d arrival indexes %v; want permutation of 0..%d", r, idx, parties-1)
+			}
+		}
+	}
+}
+
+// TestCyclicBarrierCancelBreaks 一个等待者取消会打破本代，其余等待者收到 ErrBrokenBarrier，
+// 栅栏随后开启新的一代可以继续使用
+func TestCyclicBarrierCancelBreaks(t *testing.T) {
+	b := NewCyclicBarrier(3, nil)
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := b.Await(context.Background())
+		errs <- err
+	}()
+	waitFor(t, func() bool { return b.Waiting() == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("canceled Await = %v; want DeadlineExceeded", err)
+	}
+	if err := <-errs; !errors.Is(err, ErrBrokenBarrier) {
+		t.Fatalf("peer Await = %v; want ErrBrokenBarrier", err)
+	}
+
+	if n := b.Waiting(); n != 0 {
+		t.Fatalf("Waiting after break = %d; want 0", n)
+	}
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := b.Await(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCyclicBarrierReset(t *testing.T) {
+	b := NewCyclicBarrier(2, nil)
+	errs := make(chan error, 1)
+	go func() {
+		_, err := b.Await(context.Background())
+		errs <- err
+	}()
+	waitFor(t, func() bool { return b.Waiting() == 1 })
+
+	b.Reset()
+	if err := <-errs; !errors.Is(err, ErrBrokenBarrier) {
+		t.Fatalf("Await after Reset = %v; want ErrBrokenBarrier", err)
+	}
+}
+
+// TestPhaserDynamicParties 参与者中途注销/注册，阶段照常推进
+func TestPhaserDynamicParties(t *testing.T) {
+	const workers, phases = 4, 50
+	p := NewPhaser(workers)
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 第 w 个参与者在第 w*10 阶段后注销，最后一个跑完全部阶段
+			stop := phases
+			if w < workers-1 {
+				stop = w * 10
+			}
+			for ph := range stop {
+				next, err := p.ArriveAndAwaitAdvance(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if next != ph+1 {
+					t.Errorf("worker %d: advanced to %d; want %d", w, next, ph+1)
+					return
+				}
+			}
+			if stop < phases {
+				p.ArriveAndDeregister()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ph := p.Phase(); ph != phases {
+		t.Fatalf("Phase = %d; want %d", ph, phases)
+	}
+	if n := p.Parties(); n != 1 {
+		t.Fatalf("Parties = %d; want 1", n)
+	}
+}
+
+func TestPhaserAwaitAdvance(t *testing.T) {
+	p := NewPhaser(1)
+	if ph, err := p.AwaitAdvance(context.Background(), 5); err != nil || ph != 0 {
+		t.Fatalf("AwaitAdvance(past phase) = %d, %v; want 0, nil", ph, err)
+	}
+
+	done := make(chan int, 1)
+	go func() {
+		ph, _ := p.AwaitAdvance(context.Background(), 0)
+		done <- ph
+	}()
+	p.Register()
+	p.Arrive()
+	select {
+	case <-done:
+		t.Fatal("advanced before all registered parties arrived")
+	case <-time.After(10 * time.Millisecond):
+	}
+	p.Arrive()
+	if ph := <-done; ph != 1 {
+		t.Fatalf("AwaitAdvance = %d; want 1", ph)
+	}
+}
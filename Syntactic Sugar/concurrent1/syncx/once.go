@@ -0,0 +1,91 @@
+// ============================= 1. 可重试的 Once ====================
+// sync.Once 无论 fn 成功与否都只执行一次，失败后无法重试
+// OnceErr/OnceValue 只在成功时标记完成，失败后下次调用会重试；
+// OnceValue 还可以 Reset，用于连接断开后重新建立等场景
+
+package syncx
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// OnceErr 执行 fn 直到它第一次返回 nil
+type OnceErr struct {
+	done atomic.Bool
+	mu   sync.Mutex
+}
+
+// Do 未成功过时执行 fn；并发调用会串行等待，成功后的调用直接返回 nil
+func (o *OnceErr) Do(fn func() error) error {
+	if o.done.Load() {
+		return nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.done.Load() {
+		return nil
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	o.done.Store(true)
+	return nil
+}
+
+// Done 是否已经成功执行过
+func (o *OnceErr) Done() bool {
+	return o.done.Load()
+}
+
+// OnceValue 懒加载并缓存 fn 的成功结果
+type OnceValue[T any] struct {
+	fn    func() (T, error)
+	mu    sync.Mutex
+	value atomic.Pointer[T]
+}
+
+// NewOnceValue 创建懒加载值，fn 在第一次 Get 时执行
+func NewOnceValue[T any](fn func() (T, error)) *OnceValue[T] {
+	return &OnceValue[T]{fn: fn}
+}
+
+// Get 返回缓存值；尚未成功加载时执行 fn，失败不缓存
+func (o *OnceValue[T]) Get() (T, error) {
+	if v := o.value.Load(); v != nil {
+		return *v, nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if v := o.value.Load(); v != nil {
+		return *v, nil
+	}
+
+	v, err := o.fn()
+	if err != nil {
+		return v, err
+	}
+	o.value.Store(&v)
+	return v, nil
+}
+
+// Peek 不触发加载，只返回已缓存的值
+func (o *OnceValue[T]) Peek() (T, bool) {
+	if v := o.value.Load(); v != nil {
+		return *v, true
+	}
+	var zero T
+	return zero, false
+}
+
+// Reset 丢弃缓存值，返回被丢弃的旧值，下次 Get 会重新执行 fn
+func (o *OnceValue[T]) Reset() (old T, loaded bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if v := o.value.Swap(nil); v != nil {
+		return *v, true
+	}
+	return old, false
+}
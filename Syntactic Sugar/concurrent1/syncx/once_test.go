@@ -0,0 +1,93 @@
+package syncx
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+var errTemporary = errors.New("temporary")
+
+func TestOnceErr(t *testing.T) {
+	var (
+		o             OnceErr
+		calls, active atomic.Int32
+		wg            sync.WaitGroup
+	)
+	// 前两次失败，之后成功；并发调用串行执行 fn
+	fn := func() error {
+		if active.Add(1) != 1 {
+			t.Error("fn running concurrently")
+		}
+		defer active.Add(-1)
+		if calls.Add(1) <= 2 {
+			return errTemporary
+		}
+		return nil
+	}
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.Do(fn)
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("fn called %d times, want 3", n)
+	}
+	if !o.Done() || o.Do(fn) != nil || calls.Load() != 3 {
+		t.Error("Do after success should return nil without calling fn")
+	}
+}
+
+func TestOnceValueReset(t *testing.T) {
+	var calls atomic.Int32
+	o := NewOnceValue(func() (int32, error) {
+		n := calls.Add(1)
+		if n == 1 {
+			return 0, errTemporary
+		}
+		return n, nil
+	})
+
+	// 失败不缓存
+	if _, err := o.Get(); err != errTemporary {
+		t.Fatalf("first Get = %v, want errTemporary", err)
+	}
+	if _, ok := o.Peek(); ok {
+		t.Fatal("Peek after failed Get = ok")
+	}
+
+	// 并发 Get：重试只执行一次 fn
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := o.Get(); v != 2 || err != nil {
+				t.Errorf("Get = %d, %v; want 2", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("fn called %d times, want 2", n)
+	}
+
+	// Reset 丢弃旧值，下次 Get 重新加载
+	if old, ok := o.Reset(); old != 2 || !ok {
+		t.Fatalf("Reset = %d, %t; want 2, true", old, ok)
+	}
+	if _, ok := o.Reset(); ok {
+		t.Fatal("second Reset = loaded")
+	}
+	if v, err := o.Get(); v != 3 || err != nil {
+		t.Fatalf("Get after Reset = %d, %v; want 3", v, err)
+	}
+	if v, ok := o.Peek(); v != 3 || !ok {
+		t.Errorf("Peek = %d, %t", v, ok)
+	}
+}
@@ -0,0 +1,110 @@
+// ============================= 3. 带权信号量 ====================
+// Semaphore 限制同时持有的总权重，例如"最多同时使用 64MB 缓冲"
+// 等待者按 FIFO 顺序获得许可，大请求不会被小请求饿死
+
+package syncx
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Semaphore 带权信号量，支持ctx取消
+type Semaphore struct {
+	mu      sync.Mutex
+	size    int64
+	cur     int64
+	waiters list.List // 元素为 *semWaiter
+}
+
+type semWaiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// NewSemaphore 创建总权重为 size 的信号量
+func NewSemaphore(size int64) *Semaphore {
+	return &Semaphore{size: size}
+}
+
+// Acquire 获取 n 个许可，ctx 取消时返回 ctx.Err() 且不占用许可
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	s.mu.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+	if n > s.size {
+		// 永远无法满足，只能等待ctx结束
+		s.mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	w := &semWaiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// 取消与获得许可同时发生：视为获得后立即归还
+			s.cur -= n
+			s.notifyWaiters()
+		default:
+			isFront := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// 队首被移除后，后面的等待者可能已经可以满足
+			if isFront && s.size > s.cur {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// TryAcquire 不阻塞地获取 n 个许可
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release 归还 n 个许可
+func (s *Semaphore) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("syncx: semaphore released more than held")
+	}
+	s.notifyWaiters()
+}
+
+// notifyWaiters 按FIFO唤醒能够满足的等待者，调用方需持有锁
+func (s *Semaphore) notifyWaiters() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*semWaiter)
+		if s.size-s.cur < w.n {
+			return // 队首不满足时停止，保证FIFO
+		}
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
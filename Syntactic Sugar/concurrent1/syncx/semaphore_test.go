@@ -0,0 +1,146 @@
+package syncx
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestSemaphoreStress 并发获取随机权重，任意时刻持有的总权重不超过上限
+func TestSemaphoreStress(t *testing.T) {
+	const size = 5
+	s := NewSemaphore(size)
+	var (
+		wg       sync.WaitGroup
+		cur, max atomic.Int64
+	)
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				n := int64(1 + rand.Intn(3))
+				if err := s.Acquire(context.Background(), n); err != nil {
+					t.Error(err)
+					return
+				}
+				v := cur.Add(n)
+				for m := max.Load(); v > m && !max.CompareAndSwap(m, v); m = max.Load() {
+				}
+				cur.Add(-n)
+				s.Release(n)
+			}
+		}()
+	}
+	wg.Wait()
+	if max.Load() > size {
+		t.Fatalf("held weight reached %d; limit %d", max.Load(), size)
+	}
+	if !s.TryAcquire(size) {
+		t.Fatal("permits not fully returned")
+	}
+}
+
+// TestSemaphoreCancelStress ctx 超时与获得许可同时发生时，许可不会丢失
+func TestSemaphoreCancelStress(t *testing.T) {
+	const size = 3
+	s := NewSemaphore(size)
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(rand.Intn(50))*time.Microsecond)
+				n := 1 + int64(rand.Intn(size))
+				if s.Acquire(ctx, n) == nil {
+					s.Release(n)
+				}
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	if !s.TryAcquire(size) {
+		t.Fatal("permits leaked after canceled acquires")
+	}
+}
+
+func TestSemaphoreFIFO(t *testing.T) {
+	s := NewSemaphore(5)
+	if !s.TryAcquire(5) {
+		t.Fatal("TryAcquire(5) on fresh semaphore failed")
+	}
+
+	bigDone := make(chan error, 1)
+	go func() { bigDone <- s.Acquire(context.Background(), 5) }()
+	waitFor(t, func() bool { return s.waiting() == 1 })
+
+	s.Release(1)
+	// 有等待者时小请求不能插队，否则大请求会被饿死
+	if s.TryAcquire(1) {
+		t.Fatal("small TryAcquire jumped ahead of queued large waiter")
+	}
+	s.Release(4)
+	if err := <-bigDone; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSemaphoreCancelFront 队首等待者取消后，后面能够满足的等待者被唤醒
+func TestSemaphoreCancelFront(t *testing.T) {
+	s := NewSemaphore(2)
+	s.TryAcquire(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bigDone := make(chan error, 1)
+	go func() { bigDone <- s.Acquire(ctx, 2) }()
+	waitFor(t, func() bool { return s.waiting() == 1 })
+
+	smallDone := make(chan error, 1)
+	go func() { smallDone <- s.Acquire(context.Background(), 1) }()
+	waitFor(t, func() bool { return s.waiting() == 2 })
+
+	cancel()
+	if err := <-bigDone; err != context.Canceled {
+		t.Fatalf("canceled Acquire = %v; want context.Canceled", err)
+	}
+	select {
+	case err := <-smallDone:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter behind canceled front was not woken")
+	}
+}
+
+func TestSemaphoreOverRelease(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Release beyond held permits did not panic")
+		}
+	}()
+	NewSemaphore(1).Release(1)
+}
+
+func (s *Semaphore) waiting() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waiters.Len()
+}
+
+// waitFor 等待条件成立，用于确认goroutine已经进入等待队列
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
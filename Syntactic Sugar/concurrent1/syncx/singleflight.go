@@ -0,0 +1,134 @@
+// ============================= 2. Singleflight 合并请求 ====================
+// 同一个键的并发调用只执行一次 fn，其余调用者共享结果，避免缓存击穿
+// - 每个调用者用自己的 ctx 等待，ctx 取消时单独返回
+// - 所有等待者都放弃后，fn 的 ctx 才会被取消
+// - Forget 让后续调用不再复用进行中的结果
+
+package syncx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// PanicError fn 发生 panic 时返回给所有等待者的错误
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("syncx: singleflight fn panic: %v", e.Value)
+}
+
+// Result DoChan 返回的结果
+type Result[V any] struct {
+	Val    V
+	Err    error
+	Shared bool // 结果是否被多个调用者共享
+}
+
+type call[V any] struct {
+	done    chan struct{}
+	val     V
+	err     error
+	waiters int // 仍在等待结果的调用者数量
+	dups    int // 加入的重复调用者数量
+	cancel  context.CancelFunc
+}
+
+// Group 按键合并并发调用
+type Group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+// Do 执行并返回 fn 的结果；shared 表示结果是否与其他调用者共享
+func (g *Group[K, V]) Do(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (v V, err error, shared bool) {
+	c, dup := g.join(ctx, key, fn)
+
+	select {
+	case <-c.done:
+		return c.val, c.err, dup || c.dups > 0
+	case <-ctx.Done():
+		g.leave(key, c)
+		var zero V
+		return zero, ctx.Err(), dup
+	}
+}
+
+// DoChan 与 Do 相同，但通过管道返回结果
+func (g *Group[K, V]) DoChan(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) <-chan Result[V] {
+	ch := make(chan Result[V], 1)
+	go func() {
+		v, err, shared := g.Do(ctx, key, fn)
+		ch <- Result[V]{Val: v, Err: err, Shared: shared}
+	}()
+	return ch
+}
+
+// Forget 让该键的下一次调用重新执行 fn，已在等待的调用者不受影响
+func (g *Group[K, V]) Forget(key K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.calls, key)
+}
+
+// join 加入进行中的调用，或者启动新调用
+func (g *Group[K, V]) join(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (*call[V], bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	if c, ok := g.calls[key]; ok {
+		c.waiters++
+		c.dups++
+		return c, true
+	}
+
+	// fn 继承第一个调用者的值，但不受单个调用者取消的影响
+	fnCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	c := &call[V]{
+		done:    make(chan struct{}),
+		waiters: 1,
+		cancel:  cancel,
+	}
+	g.calls[key] = c
+
+	go g.run(fnCtx, key, c, fn)
+	return c, false
+}
+
+// leave 调用者放弃等待；最后一个等待者离开时取消 fn
+func (g *Group[K, V]) leave(key K, c *call[V]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c.waiters--
+	if c.waiters == 0 {
+		c.cancel()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+	}
+}
+
+func (g *Group[K, V]) run(ctx context.Context, key K, c *call[V], fn func(ctx context.Context) (V, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = &PanicError{Value: r}
+		}
+		c.cancel()
+
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.val, c.err = fn(ctx)
+}
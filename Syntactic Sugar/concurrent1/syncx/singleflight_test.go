@@ -0,0 +1,184 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+)
+
+// waitWaiters 等到键 key 上有 n 个调用者在等待
+func waitWaiters[K comparable, V any](t *testing.T, g *Group[K, V], key K, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		g.mu.Lock()
+		c := g.calls[key]
+		got := 0
+		if c != nil {
+			got = c.waiters
+		}
+		g.mu.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("key %v: %d waiters, want %d", key, got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGroupDedupe(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	const callers = 20
+	var g Group[string, int]
+
+	for round := range 5 {
+		var calls atomic.Int32
+		release := make(chan struct{})
+		fn := func(context.Context) (int, error) {
+			calls.Add(1)
+			<-release
+			return round, nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([]int, callers)
+		shared := make([]bool, callers)
+		for i := range callers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v, err, s := g.Do(context.Background(), "k", fn)
+				if err != nil {
+					t.Error(err)
+				}
+				results[i], shared[i] = v, s
+			}()
+		}
+		waitWaiters(t, &g, "k", callers)
+		close(release)
+		wg.Wait()
+
+		if n := calls.Load(); n != 1 {
+			t.Fatalf("round %d: fn called %d times, want 1", round, n)
+		}
+		for i := range callers {
+			if results[i] != round || !shared[i] {
+				t.Fatalf("round %d caller %d: %d shared=%t", round, i, results[i], shared[i])
+			}
+		}
+	}
+
+	// 没有并发调用者时结果不算共享
+	if _, _, s := g.Do(context.Background(), "k", func(context.Context) (int, error) { return 0, nil }); s {
+		t.Error("single caller reported shared")
+	}
+}
+
+type ctxKey struct{}
+
+func TestGroupCallerCancel(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	var g Group[string, string]
+	release := make(chan struct{})
+	fnErr := make(chan error, 1)
+	fn := func(ctx context.Context) (string, error) {
+		select {
+		case <-release:
+			fnErr <- ctx.Err()
+			return ctx.Value(ctxKey{}).(string), nil
+		case <-ctx.Done():
+			fnErr <- ctx.Err()
+			return "", ctx.Err()
+		}
+	}
+
+	// 第一个调用者取消后，其他等待者和 fn 都不受影响；fn 继承第一个调用者的值
+	ctxA, cancelA := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "from A"))
+	errA := make(chan error, 1)
+	go func() {
+		_, err, _ := g.Do(ctxA, "k", fn)
+		errA <- err
+	}()
+	waitWaiters(t, &g, "k", 1)
+	resB := g.DoChan(context.Background(), "k", fn)
+	waitWaiters(t, &g, "k", 2)
+
+	cancelA()
+	if err := <-errA; err != context.Canceled {
+		t.Fatalf("canceled caller = %v, want context.Canceled", err)
+	}
+	close(release)
+	if res := <-resB; res.Err != nil || res.Val != "from A" || !res.Shared {
+		t.Fatalf("remaining caller = %+v", res)
+	}
+	if err := <-fnErr; err != nil {
+		t.Fatalf("fn ctx = %v after one caller left, want alive", err)
+	}
+
+	// 所有等待者都放弃后 fn 的 ctx 被取消，下一次调用重新执行 fn
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	go func() {
+		<-started
+		cancel()
+	}()
+	if _, err, _ := g.Do(ctx, "k", func(ctx context.Context) (string, error) {
+		close(started)
+		<-ctx.Done()
+		fnErr <- ctx.Err()
+		return "", ctx.Err()
+	}); err != context.Canceled {
+		t.Fatalf("Do = %v, want context.Canceled", err)
+	}
+	if err := <-fnErr; err != context.Canceled {
+		t.Fatalf("fn ctx after all callers left = %v, want context.Canceled", err)
+	}
+	if v, err, _ := g.Do(context.Background(), "k", func(context.Context) (string, error) { return "fresh", nil }); v != "fresh" || err != nil {
+		t.Errorf("Do after abandon = %q, %v", v, err)
+	}
+}
+
+func TestGroupForget(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	var g Group[string, int]
+	release := make(chan struct{})
+	first := g.DoChan(context.Background(), "k", func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	})
+	waitWaiters(t, &g, "k", 1)
+
+	// Forget 后新的调用不再复用进行中的 fn
+	g.Forget("k")
+	v, err, shared := g.Do(context.Background(), "k", func(context.Context) (int, error) { return 2, nil })
+	if v != 2 || err != nil || shared {
+		t.Fatalf("Do after Forget = %d, %v, shared=%t; want 2, nil, false", v, err, shared)
+	}
+
+	// 已在等待的调用者仍拿到原来的结果
+	close(release)
+	if res := <-first; res.Val != 1 || res.Err != nil {
+		t.Errorf("forgotten call = %+v, want 1", res)
+	}
+}
+
+func TestGroupPanic(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	var g Group[int, int]
+	_, err, _ := g.Do(context.Background(), 1, func(context.Context) (int, error) { panic("boom") })
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("Do = %v, want *PanicError(boom)", err)
+	}
+	// panic 后键被清理
+	if v, err, _ := g.Do(context.Background(), 1, func(context.Context) (int, error) { return 7, nil }); v != 7 || err != nil {
+		t.Errorf("Do after panic = %d, %v", v, err)
+	}
+}
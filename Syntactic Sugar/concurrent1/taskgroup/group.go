@@ -0,0 +1,154 @@
+// ============================= 1. 任务组 ====================
+// 类似 errgroup：一组并发任务共享一个ctx
+// - 第一个返回错误（或panic）的任务会取消ctx，通知其他任务尽快退出
+// - SetLimit 限制同时运行的任务数，Go 在达到上限时阻塞
+// - Wait 等待所有任务结束，返回第一个错误
+// - 泛型 Go 函数为每个任务保存返回值
+
+package taskgroup
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// PanicError 任务panic被恢复后转换成的错误
+// Error() 只包含panic的值，调用栈保存在 Stack 字段中，需要时自行打印
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("taskgroup: task panic: %v", e.Value)
+}
+
+// Group 一组共享取消信号的并发任务，零值可用（不带ctx取消）
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+	sem    chan struct{}
+
+	errOnce sync.Once
+	err     error
+}
+
+// WithContext 创建任务组，返回的ctx在第一个任务失败或 Wait 返回时取消
+func WithContext(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{ctx: ctx, cancel: cancel}, ctx
+}
+
+// SetLimit 限制同时运行的任务数，n<0 表示不限制；必须在启动任务前调用
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if len(g.sem) != 0 {
+		panic("taskgroup: SetLimit called while tasks are running")
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go 启动任务，达到并发上限时阻塞等待空位
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.start(fn)
+}
+
+// TryGo 未达到并发上限时启动任务并返回 true
+func (g *Group) TryGo(fn func(ctx context.Context) error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.start(fn)
+	return true
+}
+
+func (g *Group) start(fn func(ctx context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.done()
+		if err := g.call(fn); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				if g.cancel != nil {
+					g.cancel(err)
+				}
+			})
+		}
+	}()
+}
+
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+// call 执行任务并把panic转换为 PanicError
+func (g *Group) call(fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	ctx := g.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return fn(ctx)
+}
+
+// Wait 等待所有任务结束，返回第一个错误
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(g.err)
+	}
+	return g.err
+}
+
+// ============================= 2. 带返回值的任务 ====================
+
+// Result 单个任务的返回值，在 Wait 返回后读取
+type Result[T any] struct {
+	value T
+	err   error
+	done  chan struct{}
+}
+
+// Go 在任务组中启动带返回值的任务
+func Go[T any](g *Group, fn func(ctx context.Context) (T, error)) *Result[T] {
+	r := &Result[T]{done: make(chan struct{})}
+	g.Go(func(ctx context.Context) error {
+		defer close(r.done)
+		r.value, r.err = fn(ctx)
+		return r.err
+	})
+	return r
+}
+
+// Get 等待该任务完成并返回结果；任务panic时返回零值和 nil（错误由 Wait 返回）
+func (r *Result[T]) Get() (T, error) {
+	<-r.done
+	return r.value, r.err
+}
+
+// Value 返回任务的值，应在 Wait 之后调用
+func (r *Result[T]) Value() T {
+	v, _ := r.Get()
+	return v
+}
@@ -0,0 +1,147 @@
+package taskgroup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+)
+
+func TestPanicToError(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	g, ctx := WithContext(context.Background())
+	g.Go(func(context.Context) error { panic("boom") })
+
+	err := g.Wait()
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("Wait = %v, want *PanicError(boom)", err)
+	}
+	// 错误信息只有一行，调用栈在 Stack 字段中
+	if msg := pe.Error(); msg != "taskgroup: task panic: boom" {
+		t.Errorf("Error() = %q", msg)
+	}
+	if !bytes.Contains(pe.Stack, []byte("TestPanicToError")) {
+		t.Errorf("Stack does not contain the panicking function:\n%s", pe.Stack)
+	}
+	if cause := context.Cause(ctx); cause != err {
+		t.Errorf("ctx cause = %v, want the panic error", cause)
+	}
+}
+
+func TestFirstErrorCancels(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	errFirst := errors.New("first")
+	g, ctx := WithContext(context.Background())
+
+	var started atomic.Int32
+	for range 5 {
+		g.Go(func(ctx context.Context) error {
+			started.Add(1)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}
+	g.Go(func(context.Context) error {
+		for started.Load() < 5 {
+			time.Sleep(time.Millisecond)
+		}
+		return errFirst
+	})
+
+	// 其他任务返回的 context.Canceled 不会覆盖第一个错误
+	if err := g.Wait(); err != errFirst {
+		t.Fatalf("Wait = %v, want %v", err, errFirst)
+	}
+	if cause := context.Cause(ctx); cause != errFirst {
+		t.Errorf("ctx cause = %v, want %v", cause, errFirst)
+	}
+
+	// 全部成功时 Wait 返回后 ctx 也被取消
+	g, ctx = WithContext(context.Background())
+	g.Go(func(context.Context) error { return nil })
+	if err := g.Wait(); err != nil || ctx.Err() != context.Canceled {
+		t.Errorf("Wait = %v, ctx.Err = %v; want nil, context.Canceled", err, ctx.Err())
+	}
+}
+
+func TestLimit(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	const limit = 3
+	var (
+		g           Group // 零值可用
+		running, hi atomic.Int32
+	)
+	g.SetLimit(limit)
+
+	release := make(chan struct{})
+	for range limit {
+		g.Go(func(context.Context) error {
+			<-release
+			return nil
+		})
+	}
+	// 达到上限：TryGo 失败，SetLimit 不允许修改
+	if g.TryGo(func(context.Context) error { return nil }) {
+		t.Fatal("TryGo succeeded at the limit")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("SetLimit while running did not panic")
+			}
+		}()
+		g.SetLimit(1)
+	}()
+	close(release)
+	g.Wait()
+
+	for range 30 {
+		g.Go(func(context.Context) error {
+			n := running.Add(1)
+			for m := hi.Load(); n > m && !hi.CompareAndSwap(m, n); m = hi.Load() {
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if n := hi.Load(); n > limit {
+		t.Errorf("%d tasks ran concurrently, limit %d", n, limit)
+	}
+}
+
+func TestResult(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	errBad := errors.New("bad")
+	g, _ := WithContext(context.Background())
+	a := Go(g, func(context.Context) (string, error) { return "a", nil })
+	b := Go(g, func(context.Context) (int, error) { return 0, errBad })
+	if err := g.Wait(); err != errBad {
+		t.Fatalf("Wait = %v", err)
+	}
+	if v := a.Value(); v != "a" {
+		t.Errorf("a = %q", v)
+	}
+	if _, err := b.Get(); err != errBad {
+		t.Errorf("b err = %v", err)
+	}
+
+	// panic 的任务：Get 返回零值和 nil，错误由 Wait 返回
+	g, _ = WithContext(context.Background())
+	p := Go(g, func(context.Context) (string, error) { panic("x") })
+	if err := g.Wait(); err == nil || strings.Contains(err.Error(), "\n") {
+		t.Errorf("Wait = %q, want single-line panic error", err)
+	}
+	if v, err := p.Get(); v != "" || err != nil {
+		t.Errorf("Get after panic = %q, %v", v, err)
+	}
+}
@@ -0,0 +1,43 @@
+// ============================= 5. Future 异步结果 ====================
+
+package workerpool
+
+import "context"
+
+// Future 异步任务的结果句柄
+type Future[T any] interface {
+	// Get 等待任务完成并返回结果，ctx 取消时提前返回 ctx.Err()
+	Get(ctx context.Context) (T, error)
+	// Done 任务完成时关闭的管道
+	Done() <-chan struct{}
+}
+
+type future[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+func newFuture[T any]() *future[T] {
+	return &future[T]{done: make(chan struct{})}
+}
+
+// complete 只能调用一次，关闭done之前写入的结果对所有等待者可见
+func (f *future[T]) complete(val T, err error) {
+	f.val, f.err = val, err
+	close(f.done)
+}
+
+func (f *future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+func (f *future[T]) Done() <-chan struct{} {
+	return f.done
+}
@@ -0,0 +1,274 @@
+// ============================= 1. 工作池概述 ====================
+// WorkerPool 用固定或弹性数量的worker消费有界任务队列：
+// - Submit 在队列满时阻塞，直到有空位、ctx取消或工作池关闭
+// - 每个任务返回 Future，可以等待结果
+// - Shutdown 先停止接收任务并排空队列，超时后取消剩余任务
+
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPoolClosed 工作池已关闭，不再接收任务
+var ErrPoolClosed = errors.New("workerpool: pool closed")
+
+// Task 在worker中执行的任务，ctx 会在超时、调用方取消或强制关闭时取消
+type Task[T any] func(ctx context.Context) (T, error)
+
+// Options 工作池配置
+type Options struct {
+	Workers     int           // 常驻worker数量，默认1
+	MaxWorkers  int           // 弹性上限，<=Workers 表示固定数量
+	QueueSize   int           // 任务队列容量，默认等于 Workers
+	TaskTimeout time.Duration // 默认单任务超时，0 表示不限制
+	IdleTimeout time.Duration // 弹性worker空闲多久后退出，默认1秒
+}
+
+// Stats 工作池运行指标
+type Stats struct {
+	Workers   int64 // 当前worker数
+	Queued    int64 // 排队中的任务
+	Active    int64 // 执行中的任务
+	Completed int64 // 成功完成的任务
+	Failed    int64 // 返回错误、超时或panic的任务
+}
+
+// WorkerPool 有界并发的泛型工作池
+type WorkerPool[T any] struct {
+	opts  Options
+	queue chan *job[T]
+
+	ctx    context.Context // 强制关闭时取消所有任务
+	cancel context.CancelFunc
+
+	mu        sync.RWMutex // Submit 持读锁发送，Shutdown 持写锁关闭队列
+	closed    bool
+	closing   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+
+	workers   atomic.Int64
+	queued    atomic.Int64
+	active    atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+}
+
+type job[T any] struct {
+	ctx     context.Context
+	task    Task[T]
+	timeout time.Duration
+	future  *future[T]
+}
+
+// New 创建并启动工作池
+func New[T any](opts Options) *WorkerPool[T] {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.MaxWorkers < opts.Workers {
+		opts.MaxWorkers = opts.Workers
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = opts.Workers
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &WorkerPool[T]{
+		opts:    opts,
+		queue:   make(chan *job[T], opts.QueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+		closing: make(chan struct{}),
+	}
+	for i := 0; i < opts.Workers; i++ {
+		p.startWorker(false)
+	}
+	return p
+}
+
+// ============================= 2. 提交任务 ====================
+
+// Submit 使用默认超时提交任务
+func (p *WorkerPool[T]) Submit(ctx context.Context, task Task[T]) (Future[T], error) {
+	return p.SubmitTimeout(ctx, p.opts.TaskTimeout, task)
+}
+
+// SubmitTimeout 提交任务并指定单任务超时，timeout<=0 表示不限制
+func (p *WorkerPool[T]) SubmitTimeout(ctx context.Context, timeout time.Duration, task Task[T]) (Future[T], error) {
+	j := &job[T]{
+		ctx:     ctx,
+		task:    task,
+		timeout: timeout,
+		future:  newFuture[T](),
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+
+	// 队列已满且未达上限时扩容一个弹性worker
+	if len(p.queue) == cap(p.queue) {
+		p.tryGrow()
+	}
+
+	select {
+	case p.queue <- j:
+		p.queued.Add(1)
+		return j.future, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.closing:
+		return nil, ErrPoolClosed
+	}
+}
+
+func (p *WorkerPool[T]) tryGrow() {
+	for {
+		n := p.workers.Load()
+		if n >= int64(p.opts.MaxWorkers) {
+			return
+		}
+		if p.workers.CompareAndSwap(n, n+1) {
+			p.wg.Add(1)
+			go p.worker(true)
+			return
+		}
+	}
+}
+
+// ============================= 3. worker执行 ====================
+
+func (p *WorkerPool[T]) startWorker(elastic bool) {
+	p.workers.Add(1)
+	p.wg.Add(1)
+	go p.worker(elastic)
+}
+
+func (p *WorkerPool[T]) worker(elastic bool) {
+	defer p.wg.Done()
+	defer p.workers.Add(-1)
+
+	var idle <-chan time.Time
+	for {
+		if elastic {
+			idle = time.After(p.opts.IdleTimeout)
+		}
+		select {
+		case j, ok := <-p.queue:
+			if !ok {
+				return
+			}
+			p.queued.Add(-1)
+			p.run(j)
+		case <-idle:
+			return // 弹性worker空闲退出
+		}
+	}
+}
+
+func (p *WorkerPool[T]) run(j *job[T]) {
+	p.active.Add(1)
+	defer p.active.Add(-1)
+
+	// 任务ctx：调用方ctx + 强制关闭 + 单任务超时
+	ctx, cancel := context.WithCancel(j.ctx)
+	defer cancel()
+	stop := context.AfterFunc(p.ctx, cancel)
+	defer stop()
+	if j.timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, j.timeout)
+		defer cancelTimeout()
+	}
+
+	var (
+		val T
+		err error
+	)
+	switch {
+	case p.ctx.Err() != nil:
+		err = ErrPoolClosed
+	case j.ctx.Err() != nil:
+		err = j.ctx.Err()
+	default:
+		val, err = safeCall(ctx, j.task)
+	}
+
+	if err != nil {
+		p.failed.Add(1)
+	} else {
+		p.completed.Add(1)
+	}
+	j.future.complete(val, err)
+}
+
+// safeCall 执行任务并把panic转换为错误
+func safeCall[T any](ctx context.Context, task Task[T]) (val T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("workerpool: task panic: %v", r)
+		}
+	}()
+	return task(ctx)
+}
+
+// ============================= 4. 关闭与指标 ====================
+
+// Shutdown 停止接收新任务并等待队列排空；
+// ctx 到期时取消执行中的任务，未执行的任务以 ErrPoolClosed 结束
+func (p *WorkerPool[T]) Shutdown(ctx context.Context) error {
+	// 先唤醒阻塞在队列上的 Submit，让它们释放读锁
+	p.closeOnce.Do(func() { close(p.closing) })
+
+	// 拿到写锁后已没有 Submit 在发送，可以安全关闭队列
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		for j := range p.queue {
+			p.queued.Add(-1)
+			p.failed.Add(1)
+			j.future.complete(*new(T), ErrPoolClosed)
+		}
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Stats 返回当前指标快照
+func (p *WorkerPool[T]) Stats() Stats {
+	return Stats{
+		Workers:   p.workers.Load(),
+		Queued:    p.queued.Load(),
+		Active:    p.active.Load(),
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+	}
+}
@@ -0,0 +1,232 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+)
+
+// waitFor 轮询直到 cond 成立
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// blocker 阻塞到 release 关闭或 ctx 结束的任务
+func blocker(release <-chan struct{}, v int) Task[int] {
+	return func(ctx context.Context) (int, error) {
+		select {
+		case <-release:
+			return v, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func mustSubmit(t *testing.T, p *WorkerPool[int], task Task[int]) Future[int] {
+	t.Helper()
+	f, err := p.Submit(context.Background(), task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func shutdown(t *testing.T, p *WorkerPool[int]) {
+	t.Helper()
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubmitBackpressure(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	p := New[int](Options{Workers: 1, QueueSize: 1})
+	release := make(chan struct{})
+
+	running := mustSubmit(t, p, blocker(release, 1))
+	waitFor(t, "first task to start", func() bool { return p.Stats().Active == 1 })
+	queued := mustSubmit(t, p, blocker(release, 2))
+
+	// worker忙、队列满：Submit 阻塞到 ctx 到期
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.Submit(ctx, blocker(release, 3)); err != context.DeadlineExceeded {
+		t.Fatalf("Submit on full queue = %v, want context.DeadlineExceeded", err)
+	}
+	if s := p.Stats(); s.Queued != 1 || s.Active != 1 {
+		t.Fatalf("Stats = %+v, want 1 queued, 1 active", s)
+	}
+
+	// 队列有空位后阻塞的 Submit 继续
+	submitted := make(chan error, 1)
+	go func() {
+		_, err := p.Submit(context.Background(), blocker(release, 4))
+		submitted <- err
+	}()
+	close(release)
+	if err := <-submitted; err != nil {
+		t.Fatalf("blocked Submit = %v", err)
+	}
+	for want, f := range map[int]Future[int]{1: running, 2: queued} {
+		if got, err := f.Get(context.Background()); err != nil || got != want {
+			t.Errorf("Get = %d, %v; want %d", got, err, want)
+		}
+	}
+	shutdown(t, p)
+	if s := p.Stats(); s.Completed != 3 || s.Failed != 0 {
+		t.Errorf("Stats after shutdown = %+v, want 3 completed", s)
+	}
+}
+
+func TestShutdownDrainsQueue(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	p := New[int](Options{Workers: 2, QueueSize: 10})
+	release := make(chan struct{})
+	var futures []Future[int]
+	for i := range 10 {
+		futures = append(futures, mustSubmit(t, p, blocker(release, i)))
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- p.Shutdown(context.Background()) }()
+	waitFor(t, "pool to close", func() bool {
+		_, err := p.Submit(context.Background(), blocker(release, -1))
+		return errors.Is(err, ErrPoolClosed)
+	})
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+	// 关闭前已入队的任务全部执行完
+	for i, f := range futures {
+		if got, err := f.Get(context.Background()); err != nil || got != i {
+			t.Errorf("future %d = %d, %v", i, got, err)
+		}
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	p := New[int](Options{Workers: 1, QueueSize: 2})
+	never := make(chan struct{})
+	running := mustSubmit(t, p, blocker(never, 1))
+	waitFor(t, "task to start", func() bool { return p.Stats().Active == 1 })
+	queued := []Future[int]{mustSubmit(t, p, blocker(never, 2)), mustSubmit(t, p, blocker(never, 3))}
+
+	// 队列满时阻塞的 Submit 被 Shutdown 唤醒
+	blocked := make(chan error, 1)
+	go func() {
+		_, err := p.Submit(context.Background(), blocker(never, 4))
+		blocked <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown = %v, want context.DeadlineExceeded", err)
+	}
+	if err := <-blocked; !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("blocked Submit = %v, want ErrPoolClosed", err)
+	}
+	// 执行中的任务被取消，未执行的任务以 ErrPoolClosed 结束
+	if _, err := running.Get(context.Background()); err != context.Canceled {
+		t.Errorf("running task = %v, want context.Canceled", err)
+	}
+	for i, f := range queued {
+		if _, err := f.Get(context.Background()); !errors.Is(err, ErrPoolClosed) {
+			t.Errorf("queued task %d = %v, want ErrPoolClosed", i, err)
+		}
+	}
+	if s := p.Stats(); s.Failed != 3 || s.Queued != 0 || s.Workers != 0 {
+		t.Errorf("Stats = %+v, want 3 failed, nothing queued, no workers", s)
+	}
+}
+
+func TestElasticWorkers(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	p := New[int](Options{Workers: 1, MaxWorkers: 3, QueueSize: 1, IdleTimeout: 20 * time.Millisecond})
+	release := make(chan struct{})
+
+	// 提交时队列已满就扩容一个worker，直到 MaxWorkers
+	var futures []Future[int]
+	for i := range 4 {
+		futures = append(futures, mustSubmit(t, p, blocker(release, i)))
+	}
+	waitFor(t, "3 workers busy", func() bool {
+		s := p.Stats()
+		return s.Workers == 3 && s.Active == 3 && s.Queued == 1
+	})
+
+	close(release)
+	for _, f := range futures {
+		if _, err := f.Get(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 弹性worker空闲后退出，只留下常驻worker
+	waitFor(t, "elastic workers to exit", func() bool { return p.Stats().Workers == 1 })
+	shutdown(t, p)
+}
+
+func TestTaskTimeout(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	p := New[int](Options{Workers: 2, TaskTimeout: 10 * time.Millisecond})
+	defer shutdown(t, p)
+	never := make(chan struct{})
+
+	if _, err := mustSubmit(t, p, blocker(never, 1)).Get(context.Background()); err != context.DeadlineExceeded {
+		t.Errorf("default timeout: err = %v, want context.DeadlineExceeded", err)
+	}
+	// SubmitTimeout 覆盖默认超时，<=0 表示不限制
+	release := make(chan struct{})
+	f, err := p.SubmitTimeout(context.Background(), 0, blocker(release, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	close(release)
+	if got, err := f.Get(context.Background()); err != nil || got != 2 {
+		t.Errorf("no timeout: %d, %v", got, err)
+	}
+
+	// 调用方ctx取消也会取消任务
+	ctx, cancel := context.WithCancel(context.Background())
+	f, err = p.SubmitTimeout(ctx, time.Minute, blocker(never, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if _, err := f.Get(context.Background()); err != context.Canceled {
+		t.Errorf("caller cancel: err = %v, want context.Canceled", err)
+	}
+}
+
+func TestTaskPanic(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	p := New[int](Options{Workers: 1})
+	defer shutdown(t, p)
+
+	f := mustSubmit(t, p, func(context.Context) (int, error) { panic("boom") })
+	if _, err := f.Get(context.Background()); err == nil || !strings.Contains(err.Error(), "task panic: boom") {
+		t.Fatalf("panicking task err = %v", err)
+	}
+	// worker 不会因为panic退出
+	if got, err := mustSubmit(t, p, func(context.Context) (int, error) { return 7, nil }).Get(context.Background()); err != nil || got != 7 {
+		t.Fatalf("after panic: %d, %v", got, err)
+	}
+	if s := p.Stats(); s.Failed != 1 || s.Completed != 1 || s.Workers != 1 {
+		t.Errorf("Stats = %+v", s)
+	}
+}
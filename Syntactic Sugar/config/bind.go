@@ -0,0 +1,260 @@
+// ============================= 5. 结构体绑定 ====================
+// 通过标签把配置绑定到结构体：
+//   config:"port"           指定键名（默认使用小写字段名）
+//   config:"port,required"  必填键，缺失时 Bind 返回 ErrRequired
+//   config:"-"              忽略该字段
+//   default:"8080"          所有配置层都没有该键时使用的默认值
+// 嵌套结构体字段的键会加上父字段前缀，例如 server.port
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+var timeType = reflect.TypeOf(time.Time{})
+
+// Bind 把配置绑定到结构体指针，并校验所有必填键
+func (c *Config) Bind(dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: Bind needs a non-nil struct pointer, got %T", dst)
+	}
+
+	var errs []error
+	c.bindStruct(rv.Elem(), "", &errs)
+	return errors.Join(errs...)
+}
+
+func (c *Config) bindStruct(sv reflect.Value, prefix string, errs *[]error) {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, required := parseTag(field)
+		if name == "-" {
+			continue
+		}
+		key := prefix + name
+		fv := sv.Field(i)
+
+		// 嵌套结构体递归绑定（time.Time 作为普通值处理）
+		if field.Type.Kind() == reflect.Struct && field.Type != timeType {
+			c.bindStruct(fv, key+".", errs)
+			continue
+		}
+
+		raw, ok := c.Lookup(key)
+		if !ok {
+			if def, hasDef := field.Tag.Lookup("default"); hasDef {
+				raw, ok = def, true
+			}
+		}
+		if !ok {
+			if required {
+				*errs = append(*errs, fmt.Errorf("%w: %s", ErrRequired, key))
+			}
+			continue
+		}
+
+		v, err := convert(raw, field.Type)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("config: key %s: %w", key, err))
+			continue
+		}
+		fv.Set(v)
+	}
+}
+
+func parseTag(field reflect.StructField) (name string, required bool) {
+	tag := field.Tag.Get("config")
+	parts := strings.Split(tag, ",")
+	name = normalizeKey(parts[0])
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	for _, opt := range parts[1:] {
+		if strings.TrimSpace(opt) == "required" {
+			required = true
+		}
+	}
+	return name, required
+}
+
+// ============================= 6. 类型转换 ====================
+
+// convert 把文件/环境变量/flag中的原始值转换为目标类型
+func convert(raw any, t reflect.Type) (reflect.Value, error) {
+	rv := reflect.ValueOf(raw)
+	if !rv.IsValid() {
+		return reflect.Zero(t), nil
+	}
+
+	// Duration 优先处理：字符串按 time.ParseDuration，数字按秒计算
+	if t == durationType {
+		d, err := toDuration(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(d), nil
+	}
+
+	if rv.Type().AssignableTo(t) {
+		return rv, nil
+	}
+
+	out := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		out.SetString(fmt.Sprint(raw))
+
+	case reflect.Bool:
+		s, isStr := raw.(string)
+		if !isStr {
+			return reflect.Value{}, fmt.Errorf("cannot convert %T to bool", raw)
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if out.OverflowInt(n) {
+			return reflect.Value{}, fmt.Errorf("value %d overflows %s", n, t)
+		}
+		out.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if n < 0 || out.OverflowUint(uint64(n)) {
+			return reflect.Value{}, fmt.Errorf("value %d overflows %s", n, t)
+		}
+		out.SetUint(uint64(n))
+
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out.SetFloat(f)
+
+	case reflect.Slice:
+		items, err := toSlice(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out = reflect.MakeSlice(t, 0, len(items))
+		for i, item := range items {
+			ev, err := convert(item, t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+			}
+			out = reflect.Append(out, ev)
+		}
+
+	default:
+		if rv.Type().ConvertibleTo(t) {
+			return rv.Convert(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("unsupported conversion from %T to %s", raw, t)
+	}
+	return out, nil
+}
+
+func toInt(raw any) (int64, error) {
+	switch v := raw.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		return int64(v), nil
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, fmt.Errorf("value %v is not an integer", v)
+		}
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(v), 0, 64)
+	}
+	rv := reflect.ValueOf(raw)
+	switch {
+	case rv.CanInt():
+		return rv.Int(), nil
+	case rv.CanUint():
+		return int64(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to integer", raw)
+}
+
+func toFloat(raw any) (float64, error) {
+	switch v := raw.(type) {
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+	n, err := toInt(raw)
+	if err != nil {
+		return 0, fmt.Errorf("cannot convert %T to float", raw)
+	}
+	return float64(n), nil
+}
+
+func toDuration(raw any) (time.Duration, error) {
+	switch v := raw.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		return time.ParseDuration(strings.TrimSpace(v))
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	}
+	n, err := toInt(raw)
+	if err != nil {
+		return 0, fmt.Errorf("cannot convert %T to duration", raw)
+	}
+	return time.Duration(n) * time.Second, nil
+}
+
+// toSlice 支持YAML/JSON数组，以及环境变量/flag中逗号分隔的字符串
+func toSlice(raw any) ([]any, error) {
+	if s, ok := raw.(string); ok {
+		if strings.TrimSpace(s) == "" {
+			return nil, nil
+		}
+		parts := strings.Split(s, ",")
+		items := make([]any, len(parts))
+		for i, p := range parts {
+			items[i] = strings.TrimSpace(p)
+		}
+		return items, nil
+	}
+
+	rv := reflect.ValueOf(raw)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("cannot convert %T to slice", raw)
+	}
+	items := make([]any, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, nil
+}
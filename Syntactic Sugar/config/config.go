@@ -0,0 +1,226 @@
+// ============================= 1. 分层配置概述 ====================
+// 配置按优先级分层，高层覆盖低层：
+// 默认值 < 配置文件(YAML/JSON) < 环境变量 < 命令行flag
+// 键统一使用小写的点分路径，例如 server.port 对应文件中的 server: {port: 8080}
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNotFound 表示所有配置层中都没有该键
+var ErrNotFound = errors.New("config: key not found")
+
+// ErrRequired 表示必填的配置键缺失
+var ErrRequired = errors.New("config: required key missing")
+
+// Config 分层配置容器，并发安全
+type Config struct {
+	mu        sync.RWMutex
+	defaults  map[string]any
+	file      map[string]any
+	flags     map[string]any
+	envPrefix string
+}
+
+// Options Load 使用的加载选项
+type Options struct {
+	File      string        // 配置文件路径，为空则跳过；按扩展名选择JSON或YAML
+	EnvPrefix string        // 环境变量前缀，例如 APP 对应 APP_SERVER_PORT
+	FlagSet   *flag.FlagSet // 已解析的命令行参数，只有显式设置的flag才会覆盖
+	Defaults  map[string]any
+}
+
+// New 创建空的配置容器
+func New() *Config {
+	return &Config{
+		defaults: make(map[string]any),
+		file:     make(map[string]any),
+		flags:    make(map[string]any),
+	}
+}
+
+// Load 按 Options 依次加载各层配置
+func Load(opts Options) (*Config, error) {
+	c := New()
+	for key, value := range opts.Defaults {
+		c.SetDefault(key, value)
+	}
+	if opts.File != "" {
+		if err := c.LoadFile(opts.File); err != nil {
+			return nil, err
+		}
+	}
+	c.SetEnvPrefix(opts.EnvPrefix)
+	if opts.FlagSet != nil {
+		c.LoadFlags(opts.FlagSet)
+	}
+	return c, nil
+}
+
+// ============================= 2. 各层数据来源 ====================
+
+// SetDefault 设置默认值（最低优先级）
+func (c *Config) SetDefault(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	flatten(c.defaults, normalizeKey(key), value)
+}
+
+// LoadFile 读取配置文件，.json 使用JSON解析，其余扩展名按YAML解析
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: read %s: %w", path, err)
+	}
+	return c.LoadBytes(data, filepath.Ext(path))
+}
+
+// LoadBytes 解析内存中的配置内容，ext 为 ".json"/".yaml" 等格式提示
+func (c *Config) LoadBytes(data []byte, ext string) error {
+	var raw map[string]any
+	var err error
+	if strings.EqualFold(ext, ".json") {
+		err = json.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return fmt.Errorf("config: parse %s data: %w", strings.TrimPrefix(ext, "."), err)
+	}
+
+	layer := make(map[string]any)
+	for key, value := range raw {
+		flatten(layer, normalizeKey(key), value)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.file = layer
+	return nil
+}
+
+// SetEnvPrefix 启用环境变量层：server.read_timeout -> PREFIX_SERVER_READ_TIMEOUT
+func (c *Config) SetEnvPrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.envPrefix = strings.ToUpper(strings.TrimSuffix(prefix, "_"))
+}
+
+// LoadFlags 读取已解析FlagSet中被显式设置的参数，flag名即配置键
+func (c *Config) LoadFlags(fs *flag.FlagSet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fs.Visit(func(f *flag.Flag) {
+		if getter, ok := f.Value.(flag.Getter); ok {
+			c.flags[normalizeKey(f.Name)] = getter.Get()
+			return
+		}
+		c.flags[normalizeKey(f.Name)] = f.Value.String()
+	})
+}
+
+// ============================= 3. 读取配置 ====================
+
+// Lookup 按 flag > 环境变量 > 文件 > 默认值 的顺序查找原始值
+func (c *Config) Lookup(key string) (any, bool) {
+	key = normalizeKey(key)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if v, ok := c.flags[key]; ok {
+		return v, true
+	}
+	if c.envPrefix != "" {
+		if v, ok := os.LookupEnv(envName(c.envPrefix, key)); ok {
+			return v, true
+		}
+	}
+	if v, ok := c.file[key]; ok {
+		return v, true
+	}
+	if v, ok := c.defaults[key]; ok {
+		return v, true
+	}
+	return nil, false
+}
+
+// Has 判断任意一层中是否存在该键
+func (c *Config) Has(key string) bool {
+	_, ok := c.Lookup(key)
+	return ok
+}
+
+// Require 校验一组必填键，返回所有缺失键合并后的错误
+func (c *Config) Require(keys ...string) error {
+	var errs []error
+	for _, key := range keys {
+		if !c.Has(key) {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrRequired, normalizeKey(key)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Get 读取配置并转换为 T，支持基础类型、time.Duration 和切片
+func Get[T any](c *Config, key string) (T, error) {
+	var zero T
+	raw, ok := c.Lookup(key)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s", ErrNotFound, normalizeKey(key))
+	}
+
+	v, err := convert(raw, reflect.TypeOf(&zero).Elem())
+	if err != nil {
+		return zero, fmt.Errorf("config: key %s: %w", normalizeKey(key), err)
+	}
+	return v.Interface().(T), nil
+}
+
+// GetOr 读取配置，键不存在或类型转换失败时返回默认值
+func GetOr[T any](c *Config, key string, defaultValue T) T {
+	v, err := Get[T](c, key)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+
+// ============================= 4. 内部工具函数 ====================
+
+// flatten 把嵌套的map展开成点分键
+func flatten(dst map[string]any, prefix string, value any) {
+	switch m := value.(type) {
+	case map[string]any:
+		for k, v := range m {
+			flatten(dst, prefix+"."+normalizeKey(k), v)
+		}
+	case map[any]any:
+		for k, v := range m {
+			flatten(dst, prefix+"."+normalizeKey(fmt.Sprint(k)), v)
+		}
+	default:
+		dst[prefix] = value
+	}
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
+
+func envName(prefix, key string) string {
+	name := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+	return prefix + "_" + name
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile 在临时目录中写入配置文件，返回路径
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLayerPrecedence(t *testing.T) {
+	path := writeFile(t, "app.yaml", `
+server:
+  port: 8081
+  host: file-host
+  name: file-name
+`)
+	t.Setenv("APP_SERVER_PORT", "8082")
+	t.Setenv("APP_SERVER_HOST", "env-host")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("server.port", 0, "")
+	fs.String("server.mode", "flag-default", "") // 未显式设置，不参与覆盖
+	if err := fs.Parse([]string{"-server.port=8083"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(Options{
+		File:      path,
+		EnvPrefix: "APP_",
+		FlagSet:   fs,
+		Defaults: map[string]any{
+			"server": map[string]any{"port": 8080, "host": "default-host", "name": "default-name", "mode": "default-mode", "debug": true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 默认值 < 文件 < 环境变量 < flag
+	cases := []struct {
+		key  string
+		want string
+	}{
+		{"server.port", "8083"},      // flag
+		{"server.host", "env-host"},  // 环境变量
+		{"server.name", "file-name"}, // 文件
+		{"server.debug", "true"},     // 默认值
+		{"server.mode", "default-mode"},
+		{" Server.Port ", "8083"}, // 键不区分大小写
+	}
+	for _, tc := range cases {
+		got, err := Get[string](c, tc.key)
+		if err != nil || got != tc.want {
+			t.Errorf("Get(%q) = %q, %v; want %q", tc.key, got, err, tc.want)
+		}
+	}
+	if _, err := Get[int](c, "server.missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing key: err = %v, want ErrNotFound", err)
+	}
+	if got := GetOr(c, "server.host", 0); got != 0 {
+		t.Errorf("GetOr with failed conversion = %d, want default 0", got)
+	}
+	if err := c.Require("server.port", "db.dsn", "db.user"); !errors.Is(err, ErrRequired) ||
+		!strings.Contains(err.Error(), "db.dsn") || !strings.Contains(err.Error(), "db.user") {
+		t.Errorf("Require = %v, want both missing keys", err)
+	}
+}
+
+func TestLoadBytesJSON(t *testing.T) {
+	c := New()
+	if err := c.LoadBytes([]byte(`{"db": {"pool": {"size": 10}}, "tags": ["a", "b"]}`), ".json"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Get[int](c, "db.pool.size"); err != nil || got != 10 {
+		t.Errorf("db.pool.size = %d, %v", got, err)
+	}
+	if got, err := Get[[]string](c, "tags"); err != nil || strings.Join(got, ",") != "a,b" {
+		t.Errorf("tags = %v, %v", got, err)
+	}
+	if err := c.LoadBytes([]byte(`{bad`), ".json"); err == nil {
+		t.Error("bad JSON: want error")
+	}
+}
+
+type serverConfig struct {
+	Host    string        `config:"host" default:"localhost"`
+	Port    int           `config:"port,required"`
+	Timeout time.Duration `config:"timeout" default:"5s"`
+	Idle    time.Duration `config:"idle"`
+	Tags    []string      `config:"tags"`
+	Ignored string        `config:"-"`
+	Limits  struct {
+		Rate  float64 `config:"rate"`
+		Burst uint8   `config:"burst" default:"10"`
+	}
+	Started time.Time `config:"started"`
+}
+
+type appConfig struct {
+	Name   string
+	Debug  bool `config:"debug"`
+	Server serverConfig
+}
+
+func TestBind(t *testing.T) {
+	c := New()
+	err := c.LoadBytes([]byte(`
+name: demo
+debug: true
+ignored: x
+server:
+  port: 9000
+  idle: 90
+  tags: [a, b]
+  ignored: x
+  limits:
+    rate: 1.5
+  started: 2024-01-02T03:04:05Z
+`), ".yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APP_SERVER_TAGS", "x, y ,z") // 环境变量中的逗号分隔列表
+	c.SetEnvPrefix("app")
+
+	var cfg appConfig
+	cfg.Server.Ignored = "keep"
+	if err := c.Bind(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := appConfig{Name: "demo", Debug: true, Server: serverConfig{
+		Host:    "localhost",
+		Port:    9000,
+		Timeout: 5 * time.Second,
+		Idle:    90 * time.Second, // 数字按秒计算
+		Tags:    []string{"x", "y", "z"},
+		Ignored: "keep",
+		Started: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}}
+	want.Server.Limits.Rate = 1.5
+	want.Server.Limits.Burst = 10
+	if cfg.Name != want.Name || cfg.Debug != want.Debug || cfg.Server.Host != want.Server.Host ||
+		cfg.Server.Port != want.Server.Port || cfg.Server.Timeout != want.Server.Timeout ||
+		cfg.Server.Idle != want.Server.Idle || strings.Join(cfg.Server.Tags, ",") != "x,y,z" ||
+		cfg.Server.Ignored != "keep" || cfg.Server.Limits != want.Server.Limits ||
+		!cfg.Server.Started.Equal(want.Server.Started) {
+		t.Errorf("Bind =\n%+v\nwant\n%+v", cfg, want)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		yaml string
+		want []string // 合并错误中应包含的片段
+	}{
+		{"MissingRequired", `server: {host: h}`, []string{"required key missing: server.port"}},
+		{"BadInt", `server: {port: abc}`, []string{"server.port"}},
+		{"Overflow", `server: {port: 1, limits: {burst: 300}}`, []string{"server.limits.burst", "overflows"}},
+		{"BadDuration", `server: {port: 1, timeout: soon}`, []string{"server.timeout"}},
+		{"NotInteger", `server: {port: 1.5}`, []string{"not an integer"}},
+		// 所有错误一起返回
+		{"Joined", `{debug: maybe, server: {idle: x}}`, []string{"debug", "server.idle", "server.port"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New()
+			if err := c.LoadBytes([]byte(tc.yaml), ".yaml"); err != nil {
+				t.Fatal(err)
+			}
+			var cfg appConfig
+			err := c.Bind(&cfg)
+			if err == nil {
+				t.Fatal("Bind succeeded, want error")
+			}
+			for _, s := range tc.want {
+				if !strings.Contains(err.Error(), s) {
+					t.Errorf("Bind error %q does not mention %q", err, s)
+				}
+			}
+		})
+	}
+
+	c := New()
+	for _, dst := range []any{nil, appConfig{}, new(int), (*appConfig)(nil)} {
+		if err := c.Bind(dst); err == nil {
+			t.Errorf("Bind(%T) succeeded, want error", dst)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// ============================= 7. 热加载配置 ====================
+// Watched 持有配置的不可变快照，读取时无锁：
+// 1. 轮询文件修改时间/大小，或收到 SIGHUP 时重新加载
+// 2. 新配置解析并校验通过后才原子替换，失败时保留旧快照
+// 3. 替换成功后按订阅顺序回调 (old, new)
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Parser 从配置文件路径解析出一个配置快照
+type Parser[T any] func(path string) (T, error)
+
+// WatchOptions 热加载选项
+type WatchOptions[T any] struct {
+	Interval time.Duration // 轮询间隔，<=0 表示不轮询
+	SIGHUP   bool          // 收到 SIGHUP 时重新加载
+	Validate func(T) error // 新快照校验，返回错误则拒绝替换
+	OnError  func(error)   // 后台重新加载失败时的回调
+}
+
+// Watched 可热加载的配置持有者
+type Watched[T any] struct {
+	path    string
+	parse   Parser[T]
+	opts    WatchOptions[T]
+	current atomic.Pointer[T]
+
+	mu        sync.Mutex // 串行化加载，保护文件状态和待通知的变更
+	modTime   time.Time
+	size      int64
+	changes   []change[T] // 已替换、尚未通知的变更，按替换顺序
+	notifying bool        // 有goroutine正在通知订阅者
+
+	subsMu sync.Mutex // 保护订阅者，回调中可以安全地取消订阅
+	subs   map[int]func(old, new T)
+	nextID int
+}
+
+type change[T any] struct {
+	old, new T
+}
+
+// BindFile 默认解析器：加载文件并绑定到结构体 T（会应用 default 标签并校验必填键）
+func BindFile[T any](path string) (T, error) {
+	var cfg T
+	c := New()
+	if err := c.LoadFile(path); err != nil {
+		return cfg, err
+	}
+	if err := c.Bind(&cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// NewWatched 立即加载一次配置，首次加载失败直接返回错误
+func NewWatched[T any](path string, parse Parser[T], opts WatchOptions[T]) (*Watched[T], error) {
+	if parse == nil {
+		parse = BindFile[T]
+	}
+	w := &Watched[T]{
+		path:  path,
+		parse: parse,
+		opts:  opts,
+		subs:  make(map[int]func(old, new T)),
+	}
+	if _, err := w.Reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Load 返回当前配置快照
+func (w *Watched[T]) Load() T {
+	return *w.current.Load()
+}
+
+// Subscribe 注册变更回调，返回取消订阅函数
+func (w *Watched[T]) Subscribe(fn func(old, new T)) (unsubscribe func()) {
+	w.subsMu.Lock()
+	defer w.subsMu.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.subs[id] = fn
+
+	return func() {
+		w.subsMu.Lock()
+		defer w.subsMu.Unlock()
+		delete(w.subs, id)
+	}
+}
+
+// Reload 重新解析并校验配置，成功则原子替换并通知订阅者
+// 返回值表示快照是否被替换；回调执行时不持有锁，回调中可以再次调用 Reload
+func (w *Watched[T]) Reload() (bool, error) {
+	w.mu.Lock()
+	swapped, err := w.reloadLocked()
+	if len(w.changes) == 0 || w.notifying {
+		// 首次加载、失败，或者其他goroutine正在通知：变更已入队，由它按顺序通知
+		w.mu.Unlock()
+		return swapped, err
+	}
+
+	w.notifying = true
+	for len(w.changes) > 0 {
+		c := w.changes[0]
+		w.changes = w.changes[1:]
+		w.mu.Unlock()
+		w.notify(c.old, c.new)
+		w.mu.Lock()
+	}
+	w.notifying = false
+	w.mu.Unlock()
+	return true, nil
+}
+
+func (w *Watched[T]) reloadLocked() (bool, error) {
+	before, err := os.Stat(w.path)
+	if err != nil {
+		return false, fmt.Errorf("config: stat %s: %w", w.path, err)
+	}
+
+	// 先记录文件状态，同一个无效版本只报告一次错误
+	w.modTime, w.size = before.ModTime(), before.Size()
+
+	next, err := w.parse(w.path)
+	if err != nil {
+		return false, err
+	}
+	if w.opts.Validate != nil {
+		if err := w.opts.Validate(next); err != nil {
+			return false, fmt.Errorf("config: validate %s: %w", w.path, err)
+		}
+	}
+
+	// 解析期间文件又被写入：读到的可能不是最新版本，清空记录的状态，下次轮询一定重新加载
+	if after, err := os.Stat(w.path); err != nil || !after.ModTime().Equal(before.ModTime()) || after.Size() != before.Size() {
+		w.modTime, w.size = time.Time{}, -1
+	}
+
+	old := w.current.Swap(&next)
+	if old == nil {
+		return true, nil // 首次加载不通知
+	}
+	w.changes = append(w.changes, change[T]{old: *old, new: next})
+	return true, nil
+}
+
+// notify 按订阅顺序回调，复制订阅者列表后再回调
+func (w *Watched[T]) notify(old, new T) {
+	w.subsMu.Lock()
+	subs := make([]func(old, new T), 0, len(w.subs))
+	for id := 0; id < w.nextID; id++ {
+		if fn, ok := w.subs[id]; ok {
+			subs = append(subs, fn)
+		}
+	}
+	w.subsMu.Unlock()
+
+	for _, fn := range subs {
+		fn(old, new)
+	}
+}
+
+// changed 判断文件修改时间或大小是否变化
+func (w *Watched[T]) changed() bool {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+}
+
+// Watch 在后台轮询文件和监听 SIGHUP，直到 ctx 取消
+func (w *Watched[T]) Watch(ctx context.Context) {
+	var tick <-chan time.Time
+	if w.opts.Interval > 0 {
+		ticker := time.NewTicker(w.opts.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var hup chan os.Signal
+	if w.opts.SIGHUP {
+		hup = make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			if !w.changed() {
+				continue
+			}
+		case <-hup:
+		}
+
+		if _, err := w.Reload(); err != nil && w.opts.OnError != nil {
+			w.opts.OnError(err)
+		}
+	}
+}
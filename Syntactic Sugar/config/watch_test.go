@@ -0,0 +1,198 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type watchedConfig struct {
+	Version int    `config:"version,required"`
+	Name    string `config:"name" default:"app"`
+}
+
+// rewrite 写入新版本的配置文件，修改时间前移保证和上一个版本不同
+func rewrite(t *testing.T, path string, version int, mod time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, fmt.Appendf(nil, "version: %d\n", version), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchedReload(t *testing.T) {
+	path := writeFile(t, "app.yaml", "version: 1\n")
+	w, err := NewWatched[watchedConfig](path, nil, WatchOptions[watchedConfig]{
+		Validate: func(c watchedConfig) error {
+			if c.Version < 0 {
+				return errors.New("negative version")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Load(); got.Version != 1 || got.Name != "app" {
+		t.Fatalf("initial Load = %+v", got)
+	}
+
+	// 订阅者按订阅顺序收到 (old, new)
+	var events []string
+	w.Subscribe(func(old, new watchedConfig) { events = append(events, fmt.Sprintf("a:%d->%d", old.Version, new.Version)) })
+	unsubscribe := w.Subscribe(func(old, new watchedConfig) { events = append(events, fmt.Sprintf("b:%d->%d", old.Version, new.Version)) })
+
+	base := time.Now().Add(-time.Hour)
+	rewrite(t, path, 2, base)
+	if swapped, err := w.Reload(); !swapped || err != nil {
+		t.Fatalf("Reload = %t, %v", swapped, err)
+	}
+
+	// 校验失败和解析失败都保留旧快照，不通知
+	rewrite(t, path, -1, base.Add(time.Second))
+	if swapped, err := w.Reload(); swapped || err == nil || !strings.Contains(err.Error(), "negative version") {
+		t.Fatalf("Reload invalid = %t, %v; want validate error", swapped, err)
+	}
+	if err := os.WriteFile(path, []byte("name: x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if swapped, err := w.Reload(); swapped || !errors.Is(err, ErrRequired) {
+		t.Fatalf("Reload without version = %t, %v; want ErrRequired", swapped, err)
+	}
+	if got := w.Load().Version; got != 2 {
+		t.Fatalf("after rejected reloads Version = %d, want 2", got)
+	}
+
+	unsubscribe()
+	rewrite(t, path, 3, base.Add(2*time.Second))
+	if _, err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a:1->2", "b:1->2", "a:2->3"}
+	if !slices.Equal(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	if _, err := NewWatched[watchedConfig](path+".missing", nil, WatchOptions[watchedConfig]{}); err == nil {
+		t.Error("NewWatched with missing file: want error")
+	}
+}
+
+func TestWatchedNotifyOrder(t *testing.T) {
+	path := writeFile(t, "app.yaml", "version: 1\n")
+	w, err := NewWatched[watchedConfig](path, nil, WatchOptions[watchedConfig]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一个订阅者在回调里再次 Reload：新的变更排在当前变更之后，
+	// 第二个订阅者先收到 1->2，再收到 2->3
+	base := time.Now().Add(-time.Hour)
+	var events []string
+	w.Subscribe(func(old, new watchedConfig) {
+		events = append(events, fmt.Sprintf("a:%d->%d", old.Version, new.Version))
+		if new.Version == 2 {
+			rewrite(t, path, 3, base.Add(time.Second))
+			if swapped, err := w.Reload(); !swapped || err != nil {
+				t.Errorf("nested Reload = %t, %v", swapped, err)
+			}
+		}
+	})
+	w.Subscribe(func(old, new watchedConfig) {
+		events = append(events, fmt.Sprintf("b:%d->%d", old.Version, new.Version))
+	})
+
+	rewrite(t, path, 2, base)
+	if _, err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a:1->2", "b:1->2", "a:2->3", "b:2->3"}
+	if !slices.Equal(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestWatchPolling(t *testing.T) {
+	path := writeFile(t, "app.yaml", "version: 1\n")
+	errc := make(chan error, 1)
+	w, err := NewWatched[watchedConfig](path, nil, WatchOptions[watchedConfig]{
+		Interval: 5 * time.Millisecond,
+		OnError: func(err error) {
+			select {
+			case errc <- err:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		mu       sync.Mutex
+		versions []int
+	)
+	w.Subscribe(func(_, new watchedConfig) {
+		mu.Lock()
+		versions = append(versions, new.Version)
+		mu.Unlock()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Watch(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	base := time.Now().Add(-time.Hour)
+	rewrite(t, path, 2, base)
+	waitUntil(t, func() bool { return w.Load().Version == 2 })
+
+	// 无效版本通过 OnError 报告，快照不变
+	if err := os.WriteFile(path, []byte("version: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Fatal("OnError(nil)")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnError not called for invalid file")
+	}
+	if got := w.Load().Version; got != 2 {
+		t.Fatalf("Version after invalid file = %d, want 2", got)
+	}
+
+	rewrite(t, path, 3, base.Add(time.Second))
+	waitUntil(t, func() bool { return w.Load().Version == 3 })
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(versions, []int{2, 3}) {
+		t.Errorf("notified versions = %v, want [2 3]", versions)
+	}
+}
+
+// waitUntil 轮询直到 cond 成立
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 2s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
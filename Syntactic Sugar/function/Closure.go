@@ -1,6 +1,15 @@
 package function
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Syntactic_Sugar/function/fn"
+)
 
 func main() {
 	// grow 是一个闭包函数，它"记住"了外部变量 e 和 n
@@ -10,6 +19,12 @@ func main() {
 		// 每次调用 grow() 时，它都能记住上次执行后的状态
 		fmt.Println("2^%d=%d\n", i, grow())
 	}
+
+	// ============================= 闭包的应用：fn 包 ====================
+	memoizeDemo()
+	lazyDemo()
+	debounceThrottleDemo()
+	composeDemo()
 }
 
 func Exp(n int) func() int {
@@ -26,3 +41,133 @@ func Exp(n int) func() int {
 }
 
 //匿名函数 + 引用外部变量 = 闭包！！！！！！！！！！！！！！！！！
+
+// memoizeDemo 闭包捕获缓存：并发的相同调用只执行一次
+func memoizeDemo() {
+	// 1.1 100个goroutine同时查询同一个键，慢函数只执行一次
+	var calls atomic.Int32
+	slowSquare := fn.Memoize(func(n int) int {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return n * n
+	})
+	var wg sync.WaitGroup
+	results := make([]int, 100)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = slowSquare(12)
+		}()
+	}
+	wg.Wait()
+	fmt.Printf("100次并发调用，实际执行 %d 次，结果 %d\n", calls.Load(), results[99])
+
+	// 1.2 递归函数记忆化：斐波那契从指数级变为线性
+	var fib func(int) int
+	fib = fn.Memoize(func(n int) int {
+		if n < 2 {
+			return n
+		}
+		return fib(n-1) + fib(n-2)
+	})
+	fmt.Println("fib(90) =", fib(90))
+
+	// 1.3 限制条数和有效期，错误不缓存
+	calls.Store(0)
+	lookup := fn.NewMemo(func(user string) (string, error) {
+		calls.Add(1)
+		if user == "" {
+			return "", errors.New("empty user")
+		}
+		return strings.ToUpper(user), nil
+	}, fn.MemoOptions{MaxSize: 2, TTL: 100 * time.Millisecond})
+	for _, user := range []string{"alice", "bob", "alice", "carol", "bob", "", ""} {
+		v, err := lookup.Get(user)
+		fmt.Printf("  Get(%q) = %q, %v\n", user, v, err)
+	}
+	fmt.Printf("执行 %d 次，缓存 %d 条（最多2条，错误不缓存）\n", calls.Load(), lookup.Len())
+	time.Sleep(150 * time.Millisecond)
+	lookup.Get("carol")
+	fmt.Printf("过期后再次查询，执行 %d 次\n", calls.Load())
+}
+
+// lazyDemo 第一次使用时才初始化
+func lazyDemo() {
+	config := fn.Lazy(func() map[string]string {
+		fmt.Println("  加载配置...")
+		return map[string]string{"env": "prod"}
+	})
+	fmt.Println("Lazy已创建，尚未加载")
+	fmt.Println("env =", config()["env"])
+	fmt.Println("env =", config()["env"]) // 不会再次加载
+
+	attempts := 0
+	connect := fn.LazyErr(func() (string, error) {
+		attempts++
+		if attempts < 3 {
+			return "", fmt.Errorf("第%d次连接失败", attempts)
+		}
+		return "conn-1", nil
+	})
+	for range 4 {
+		conn, err := connect()
+		fmt.Printf("  connect() = %q, %v\n", conn, err)
+	}
+}
+
+// debounceThrottleDemo 防抖只执行最后一次，节流按间隔丢弃多余调用
+func debounceThrottleDemo() {
+	saved := make(chan string, 1)
+	save, stop := fn.Debounce(50*time.Millisecond, func(text string) {
+		saved <- text
+	})
+	defer stop()
+	for _, text := range []string{"h", "he", "hel", "hell", "hello"} {
+		save(text) // 连续输入，每次都会重置计时
+		time.Sleep(10 * time.Millisecond)
+	}
+	fmt.Printf("防抖: 输入5次，保存了 %q\n", <-saved)
+
+	reported := 0
+	report := fn.Throttle(30*time.Millisecond, func(percent int) {
+		reported++
+	})
+	for percent := range 100 {
+		report(percent)
+		time.Sleep(time.Millisecond)
+	}
+	fmt.Printf("节流: 调用100次，上报 %d 次\n", reported)
+}
+
+// composeDemo 组合小函数，固定部分参数
+func composeDemo() {
+	normalize := fn.Pipe(strings.TrimSpace, strings.ToLower, func(s string) string {
+		return strings.ReplaceAll(s, " ", "-")
+	})
+	fmt.Printf("Pipe: %q\n", normalize("  Hello Go World "))
+
+	double := func(n int) int { return n * 2 }
+	inc := func(n int) int { return n + 1 }
+	fmt.Println("Pipe(double, inc)(5) =", fn.Pipe(double, inc)(5), " Compose(double, inc)(5) =", fn.Compose(double, inc)(5))
+
+	wordCount := fn.Pipe2(strings.Fields, func(words []string) int { return len(words) })
+	fmt.Println("单词数:", wordCount("the quick brown fox"))
+
+	hasPrefix := fn.Partial(strings.HasPrefix, "/api/v1/users")
+	fmt.Println("Partial:", hasPrefix("/api"), hasPrefix("/admin"))
+
+	join := fn.Curry3(func(sep, a, b string) string { return a + sep + b })
+	withColon := join(":")
+	fmt.Println("Curry3:", withColon("host")("8080"), withColon("key")("value"))
+	fmt.Println("Uncurry:", fn.Uncurry(fn.Curry(strings.Repeat))("ab", 3))
+}
+
+/*
+总结知识点：
+1. 闭包捕获的变量在函数返回后依然存活，可以用来保存状态（计数器、缓存、定时器）
+2. fn.Memoize: 并发安全的记忆化，相同键并发调用只执行一次；NewMemo 支持LRU条数限制和TTL，错误不缓存
+3. fn.Lazy/LazyErr: 第一次调用才执行，LazyErr 失败后可以重试
+4. fn.Debounce/Throttle: 防抖执行最后一次调用，节流丢弃间隔内的多余调用
+5. fn.Pipe/Compose/Partial/Curry: 用泛型组合小函数，类型由编译器检查
+*/
@@ -0,0 +1,80 @@
+// ============================= 4. 组合、偏应用与柯里化 ====================
+// Pipe 从左到右依次执行，Compose 从右到左（与数学上的 f∘g 一致）
+// 参数类型各不相同的组合用 Pipe2/Pipe3，由编译器检查每一步的类型
+
+package fn
+
+// Pipe 依次执行 fs：Pipe(f, g, h)(x) == h(g(f(x)))；没有函数时原样返回 x
+func Pipe[T any](fs ...func(T) T) func(T) T {
+	return func(x T) T {
+		for _, f := range fs {
+			x = f(x)
+		}
+		return x
+	}
+}
+
+// Compose 从右到左执行 fs：Compose(f, g, h)(x) == f(g(h(x)))
+func Compose[T any](fs ...func(T) T) func(T) T {
+	return func(x T) T {
+		for i := len(fs) - 1; i >= 0; i-- {
+			x = fs[i](x)
+		}
+		return x
+	}
+}
+
+// Pipe2 组合两个类型不同的函数：Pipe2(f, g)(x) == g(f(x))
+func Pipe2[A, B, C any](f func(A) B, g func(B) C) func(A) C {
+	return func(a A) C {
+		return g(f(a))
+	}
+}
+
+// Pipe3 组合三个类型不同的函数：Pipe3(f, g, h)(x) == h(g(f(x)))
+func Pipe3[A, B, C, D any](f func(A) B, g func(B) C, h func(C) D) func(A) D {
+	return func(a A) D {
+		return h(g(f(a)))
+	}
+}
+
+// Partial 固定两参数函数的第一个参数
+func Partial[A, B, R any](f func(A, B) R, a A) func(B) R {
+	return func(b B) R {
+		return f(a, b)
+	}
+}
+
+// Partial3 固定三参数函数的第一个参数
+func Partial3[A, B, C, R any](f func(A, B, C) R, a A) func(B, C) R {
+	return func(b B, c C) R {
+		return f(a, b, c)
+	}
+}
+
+// Curry 把 f(a, b) 转换为 f(a)(b)
+func Curry[A, B, R any](f func(A, B) R) func(A) func(B) R {
+	return func(a A) func(B) R {
+		return func(b B) R {
+			return f(a, b)
+		}
+	}
+}
+
+// Curry3 把 f(a, b, c) 转换为 f(a)(b)(c)
+func Curry3[A, B, C, R any](f func(A, B, C) R) func(A) func(B) func(C) R {
+	return func(a A) func(B) func(C) R {
+		return func(b B) func(C) R {
+			return func(c C) R {
+				return f(a, b, c)
+			}
+		}
+	}
+}
+
+// Uncurry 把 f(a)(b) 转换回 f(a, b)
+func Uncurry[A, B, R any](f func(A) func(B) R) func(A, B) R {
+	return func(a A, b B) R {
+		return f(a)(b)
+	}
+}
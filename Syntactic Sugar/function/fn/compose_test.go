@@ -0,0 +1,70 @@
+package fn
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPipeCompose(t *testing.T) {
+	inc := func(x int) int { return x + 1 }
+	double := func(x int) int { return x * 2 }
+	square := func(x int) int { return x * x }
+
+	cases := []struct {
+		name      string
+		got, want int
+	}{
+		{"Pipe", Pipe(inc, double, square)(3), 64},       // ((3+1)*2)^2
+		{"Compose", Compose(inc, double, square)(3), 19}, // 3^2*2+1
+		{"PipeEmpty", Pipe[int]()(7), 7},
+		{"ComposeEmpty", Compose[int]()(7), 7},
+		{"PipeOne", Pipe(double)(7), 14},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("%s = %d; want %d", c.name, c.got, c.want)
+		}
+	}
+
+	// 类型不同的组合
+	length := Pipe2(strings.TrimSpace, func(s string) int { return len(s) })
+	if n := length("  abc "); n != 3 {
+		t.Errorf("Pipe2 = %d; want 3", n)
+	}
+	format := Pipe3(strconv.Itoa, strings.NewReader, func(r *strings.Reader) int64 { return r.Size() })
+	if n := format(12345); n != 5 {
+		t.Errorf("Pipe3 = %d; want 5", n)
+	}
+}
+
+func TestPartialCurry(t *testing.T) {
+	sub := func(a, b int) int { return a - b }
+	clamp := func(lo, hi, x int) int { return max(lo, min(hi, x)) }
+
+	if got := Partial(sub, 10)(3); got != 7 {
+		t.Errorf("Partial = %d; want 7", got)
+	}
+	if got := Partial3(clamp, 0)(10, 42); got != 10 {
+		t.Errorf("Partial3 = %d; want 10", got)
+	}
+
+	// 柯里化保持参数顺序
+	curried := Curry(sub)
+	from10 := curried(10)
+	if got := from10(3); got != 7 {
+		t.Errorf("Curry = %d; want 7", got)
+	}
+	if got := from10(4); got != 6 {
+		t.Errorf("reused curried func = %d; want 6", got)
+	}
+	if got := Curry3(clamp)(0)(10)(-5); got != 0 {
+		t.Errorf("Curry3 = %d; want 0", got)
+	}
+	if got := Uncurry(curried)(3, 10); got != -7 {
+		t.Errorf("Uncurry(Curry(f)) = %d; want -7", got)
+	}
+	if got := Curry(strings.Repeat)("ab")(3); got != "ababab" {
+		t.Errorf("Curry(strings.Repeat) = %q", got)
+	}
+}
@@ -0,0 +1,198 @@
+package fn
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestMemoizeExactlyOnce 同一个键的并发调用只执行一次 f，所有调用者拿到相同结果
+func TestMemoizeExactlyOnce(t *testing.T) {
+	const keys, callers = 10, 100
+	var calls [keys]atomic.Int32
+	square := Memoize(func(k int) int {
+		calls[k].Add(1)
+		time.Sleep(time.Millisecond) // 拉长计算时间，让并发调用重叠
+		return k * k
+	})
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			k := i % keys
+			if got := square(k); got != k*k {
+				t.Errorf("square(%d) = %d", k, got)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	for k := range keys {
+		if n := calls[k].Load(); n != 1 {
+			t.Errorf("f called %d times for key %d; want 1", n, k)
+		}
+	}
+}
+
+func TestMemoErrorNotCached(t *testing.T) {
+	var calls atomic.Int32
+	m := NewMemo(func(k string) (int, error) {
+		if calls.Add(1) == 1 {
+			return 0, errors.New("temporary")
+		}
+		return len(k), nil
+	}, MemoOptions{})
+
+	if _, err := m.Get("abc"); err == nil {
+		t.Fatal("first Get: want error")
+	}
+	if v, err := m.Get("abc"); err != nil || v != 3 {
+		t.Fatalf("retry Get = %d, %v; want 3, nil", v, err)
+	}
+	m.Get("abc")
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("f called %d times; want 2", n)
+	}
+}
+
+// TestMemoPanicSharedWithWaiters 计算 panic 时，等待同一个键的调用者收到 *PanicError
+func TestMemoPanicSharedWithWaiters(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	m := NewMemo(func(int) (int, error) {
+		close(entered)
+		<-release
+		panic("boom")
+	}, MemoOptions{})
+
+	panicked := make(chan any, 1)
+	go func() {
+		defer func() { panicked <- recover() }()
+		m.Get(1)
+	}()
+	<-entered
+
+	waiter := make(chan error, 1)
+	go func() {
+		_, err := m.Get(1)
+		waiter <- err
+	}()
+	for m.Len() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond) // 让等待者阻塞在 done 上
+	close(release)
+
+	if v := <-panicked; v != "boom" {
+		t.Fatalf("computing caller recovered %v; want boom", v)
+	}
+	var p *PanicError
+	if err := <-waiter; !errors.As(err, &p) || p.Value != "boom" {
+		t.Fatalf("waiter got %v; want PanicError(boom)", err)
+	}
+	if m.Len() != 0 {
+		t.Fatal("panicked result was cached")
+	}
+}
+
+func TestMemoLRU(t *testing.T) {
+	var calls atomic.Int32
+	m := NewMemo(func(k int) (int, error) {
+		calls.Add(1)
+		return k, nil
+	}, MemoOptions{MaxSize: 2})
+
+	m.Get(1)
+	m.Get(2)
+	m.Get(1) // 1 最近使用，淘汰 2
+	m.Get(3)
+	if m.Len() != 2 {
+		t.Fatalf("Len = %d; want 2", m.Len())
+	}
+	m.Get(1)
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("f called %d times; want 3 (key 1 still cached)", n)
+	}
+	m.Get(2)
+	if n := calls.Load(); n != 4 {
+		t.Fatalf("f called %d times; want 4 (key 2 evicted)", n)
+	}
+}
+
+func TestMemoTTL(t *testing.T) {
+	var calls atomic.Int32
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemo(func(int) (int32, error) {
+		return calls.Add(1), nil
+	}, MemoOptions{TTL: time.Minute, Now: func() time.Time { return now }})
+
+	if v, _ := m.Get(0); v != 1 {
+		t.Fatalf("Get = %d; want 1", v)
+	}
+	now = now.Add(time.Minute - time.Nanosecond)
+	if v, _ := m.Get(0); v != 1 {
+		t.Fatalf("cached Get = %d; want 1", v)
+	}
+	// 过期时间从计算完成时算起，访问不会续期
+	now = now.Add(time.Nanosecond)
+	if v, _ := m.Get(0); v != 2 {
+		t.Fatalf("Get after TTL = %d; want 2", v)
+	}
+	if v, _ := m.Get(0); v != 2 {
+		t.Fatalf("Get after recompute = %d; want 2", v)
+	}
+}
+
+func TestMemoForget(t *testing.T) {
+	var calls atomic.Int32
+	m := NewMemo(func(k string) (int32, error) {
+		return calls.Add(1), nil
+	}, MemoOptions{})
+
+	m.Get("a")
+	m.Get("b")
+	m.Forget("a")
+	m.Forget("missing") // 不存在的键什么也不做
+	if m.Len() != 1 {
+		t.Fatalf("Len after Forget = %d; want 1", m.Len())
+	}
+	if v, _ := m.Get("a"); v != 3 {
+		t.Errorf("Get after Forget = %d; want recomputed 3", v)
+	}
+	if v, _ := m.Get("b"); v != 2 {
+		t.Errorf("Get(b) = %d; want cached 2", v)
+	}
+
+	// 计算进行中 Forget：当前调用者照常拿到结果，下次调用重新计算
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	slow := NewMemo(func(int) (int32, error) {
+		n := calls.Add(1)
+		if n == 4 {
+			close(entered)
+			<-release
+		}
+		return n, nil
+	}, MemoOptions{})
+	got := make(chan int32)
+	go func() {
+		v, _ := slow.Get(0)
+		got <- v
+	}()
+	<-entered
+	slow.Forget(0)
+	close(release)
+	if v := <-got; v != 4 {
+		t.Errorf("in-flight Get = %d; want 4", v)
+	}
+	if v, _ := slow.Get(0); v != 5 {
+		t.Errorf("Get after in-flight Forget = %d; want 5", v)
+	}
+}
@@ -0,0 +1,34 @@
+// ============================= 2. 惰性求值 Lazy ====================
+// 昂贵的初始化（加载配置、建立连接）推迟到第一次使用时执行
+
+package fn
+
+import "sync"
+
+// Lazy 第一次调用时才执行 f，之后直接返回结果；并发调用只执行一次
+// 基于 sync.OnceValue：f panic 时之后的每次调用都会以相同的值 panic
+func Lazy[T any](f func() T) func() T {
+	return sync.OnceValue(f)
+}
+
+// LazyErr 与 Lazy 相同，但 f 返回错误时不缓存，下次调用会重试
+func LazyErr[T any](f func() (T, error)) func() (T, error) {
+	var (
+		mu   sync.Mutex
+		done bool
+		val  T
+	)
+	return func() (T, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			return val, nil
+		}
+		v, err := f()
+		if err != nil {
+			return v, err
+		}
+		val, done = v, true
+		return val, nil
+	}
+}
@@ -0,0 +1,88 @@
+package fn
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	var calls atomic.Int32
+	get := Lazy(func() int32 { return calls.Add(1) })
+	if calls.Load() != 0 {
+		t.Fatal("Lazy ran f before the first call")
+	}
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v := get(); v != 1 {
+				t.Errorf("get = %d; want 1", v)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("f called %d times; want 1", n)
+	}
+
+	// panic 被记住，之后每次调用都以相同的值 panic
+	boom := Lazy(func() int { panic("boom") })
+	for range 2 {
+		func() {
+			defer func() {
+				if r := recover(); r != "boom" {
+					t.Errorf("recovered %v; want boom", r)
+				}
+			}()
+			boom()
+		}()
+	}
+}
+
+func TestLazyErrRetry(t *testing.T) {
+	errDown := errors.New("down")
+	var calls atomic.Int32
+	get := LazyErr(func() (string, error) {
+		if calls.Add(1) < 3 {
+			return "partial", errDown
+		}
+		return "ok", nil
+	})
+
+	// 失败不缓存，返回 f 的原始结果
+	for range 2 {
+		if v, err := get(); err != errDown || v != "partial" {
+			t.Fatalf("get = %q, %v; want partial, %v", v, err, errDown)
+		}
+	}
+	for range 2 {
+		if v, err := get(); err != nil || v != "ok" {
+			t.Fatalf("get = %q, %v; want ok, nil", v, err)
+		}
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("f called %d times; want 3", n)
+	}
+
+	// 并发调用串行执行 f，成功后不再调用
+	calls.Store(0)
+	once := LazyErr(func() (int32, error) { return calls.Add(1), nil })
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := once(); v != 1 || err != nil {
+				t.Errorf("once = %d, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("f called %d times; want 1", n)
+	}
+}
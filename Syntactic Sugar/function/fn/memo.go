@@ -0,0 +1,161 @@
+// ============================= 1. 记忆化 Memoize ====================
+// 闭包捕获一个缓存，相同参数的调用直接返回上次的结果
+// - 同一个键的并发调用只执行一次 f，其余调用者等待并共享结果
+// - 返回错误的结果不缓存，下次调用会重试
+// - MaxSize 限制缓存条数，超出时淘汰最久未使用的键（LRU）
+// - TTL 到期的结果在下次访问时重新计算
+
+package fn
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoOptions 记忆化选项，零值表示不限制条数、永不过期
+type MemoOptions struct {
+	MaxSize int
+	TTL     time.Duration
+	// Now 时间来源，用于计算 TTL，默认 time.Now
+	Now func() time.Time
+}
+
+// PanicError f 发生 panic 时返回给等待同一个键的其他调用者
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("fn: memoized function panic: %v", e.Value)
+}
+
+// Memo 并发安全的记忆化函数
+type Memo[K comparable, V any] struct {
+	f    func(K) (V, error)
+	opts MemoOptions
+
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	lru     *list.List // 最近使用的在前
+}
+
+type memoEntry[K comparable, V any] struct {
+	key     K
+	done    chan struct{} // 计算完成时关闭
+	val     V
+	err     error
+	expires time.Time // 零值表示永不过期
+}
+
+// NewMemo 创建记忆化函数
+func NewMemo[K comparable, V any](f func(K) (V, error), opts MemoOptions) *Memo[K, V] {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Memo[K, V]{
+		f:       f,
+		opts:    opts,
+		entries: make(map[K]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Memoize 不限条数、永不过期的记忆化，适合纯函数
+func Memoize[K comparable, V any](f func(K) V) func(K) V {
+	m := NewMemo(func(k K) (V, error) { return f(k), nil }, MemoOptions{})
+	return func(k K) V {
+		v, err := m.Get(k)
+		if p, ok := err.(*PanicError); ok {
+			panic(p.Value)
+		}
+		return v
+	}
+}
+
+// Get 返回 key 的结果，没有缓存或已过期时调用 f
+func (m *Memo[K, V]) Get(key K) (V, error) {
+	m.mu.Lock()
+	if el, ok := m.entries[key]; ok {
+		e := el.Value.(*memoEntry[K, V])
+		if !m.expiredLocked(e) {
+			m.lru.MoveToFront(el)
+			m.mu.Unlock()
+			<-e.done
+			return e.val, e.err
+		}
+		m.removeLocked(el)
+	}
+
+	e := &memoEntry[K, V]{key: key, done: make(chan struct{})}
+	m.entries[key] = m.lru.PushFront(e)
+	if m.opts.MaxSize > 0 && m.lru.Len() > m.opts.MaxSize {
+		m.removeLocked(m.lru.Back()) // 被淘汰的计算仍会完成，等待者照常拿到结果
+	}
+	m.mu.Unlock()
+
+	m.compute(e)
+	return e.val, e.err
+}
+
+// compute 调用 f，panic 时把错误交给等待者后继续向上 panic
+func (m *Memo[K, V]) compute(e *memoEntry[K, V]) {
+	finished := false
+	defer func() {
+		var recovered any
+		if !finished {
+			recovered = recover()
+			e.err = &PanicError{Value: recovered}
+		}
+
+		m.mu.Lock()
+		if e.err != nil {
+			// 失败的结果不缓存；只删除自己，键可能已经被新的计算替换
+			if el, ok := m.entries[e.key]; ok && el.Value == e {
+				m.removeLocked(el)
+			}
+		} else if m.opts.TTL > 0 {
+			e.expires = m.opts.Now().Add(m.opts.TTL)
+		}
+		m.mu.Unlock()
+		close(e.done)
+
+		if !finished && recovered != nil { // recovered 为 nil 说明是 runtime.Goexit
+			panic(recovered)
+		}
+	}()
+	e.val, e.err = m.f(e.key)
+	finished = true
+}
+
+// expiredLocked 只判断已经完成的结果，进行中的计算不会过期
+func (m *Memo[K, V]) expiredLocked(e *memoEntry[K, V]) bool {
+	select {
+	case <-e.done:
+		return !e.expires.IsZero() && !m.opts.Now().Before(e.expires)
+	default:
+		return false
+	}
+}
+
+func (m *Memo[K, V]) removeLocked(el *list.Element) {
+	delete(m.entries, el.Value.(*memoEntry[K, V]).key)
+	m.lru.Remove(el)
+}
+
+// Forget 删除 key 的缓存，进行中的计算不受影响
+func (m *Memo[K, V]) Forget(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[key]; ok {
+		m.removeLocked(el)
+	}
+}
+
+// Len 缓存的条数（包括进行中的计算）
+func (m *Memo[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
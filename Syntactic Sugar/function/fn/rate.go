@@ -0,0 +1,78 @@
+// ============================= 3. 防抖 Debounce 与节流 Throttle ====================
+// 防抖：连续调用只在停止调用 wait 之后执行最后一次，例如输入框搜索、配置文件变更
+// 节流：每个 interval 内最多执行一次，多余的调用被丢弃，例如进度上报、日志限频
+
+package fn
+
+import (
+	"sync"
+	"time"
+)
+
+// Debounce 返回防抖后的函数，f 在最后一次调用的 wait 之后以最后一次的参数执行
+// stop 取消尚未执行的调用，之后的调用都被忽略
+func Debounce[T any](wait time.Duration, f func(T)) (call func(T), stop func()) {
+	var (
+		mu      sync.Mutex
+		timer   *time.Timer
+		gen     uint64 // 每次调用加一，旧的定时器触发时发现不是最新一代就放弃
+		last    T
+		stopped bool
+	)
+
+	call = func(arg T) {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return
+		}
+		gen++
+		last = arg
+		if timer != nil {
+			timer.Stop()
+		}
+		current := gen
+		timer = time.AfterFunc(wait, func() {
+			mu.Lock()
+			if stopped || current != gen {
+				mu.Unlock()
+				return
+			}
+			arg := last
+			mu.Unlock()
+			f(arg)
+		})
+	}
+
+	stop = func() {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	return call, stop
+}
+
+// Throttle 返回节流后的函数，距上次执行不足 interval 的调用被丢弃并返回 false
+// f 在调用者的goroutine中同步执行
+func Throttle[T any](interval time.Duration, f func(T)) func(T) bool {
+	var (
+		mu   sync.Mutex
+		next time.Time // 下次允许执行的时间
+	)
+	return func(arg T) bool {
+		mu.Lock()
+		now := time.Now()
+		if now.Before(next) {
+			mu.Unlock()
+			return false
+		}
+		next = now.Add(interval)
+		mu.Unlock()
+
+		f(arg)
+		return true
+	}
+}
@@ -0,0 +1,98 @@
+package fn
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebounce(t *testing.T) {
+	fired := make(chan int, 10)
+	call, stop := Debounce(100*time.Millisecond, func(v int) { fired <- v })
+	defer stop()
+
+	// 连续调用只执行一次，参数为最后一次的
+	for i := 1; i <= 5; i++ {
+		call(i)
+	}
+	select {
+	case v := <-fired:
+		if v != 5 {
+			t.Fatalf("debounced call got %d; want 5", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("debounced call never ran")
+	}
+	select {
+	case v := <-fired:
+		t.Fatalf("extra call with %d", v)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// 执行之后的新调用重新计时
+	call(6)
+	if v := <-fired; v != 6 {
+		t.Fatalf("second burst got %d; want 6", v)
+	}
+}
+
+func TestDebounceStop(t *testing.T) {
+	var calls atomic.Int32
+	call, stop := Debounce(20*time.Millisecond, func(int) { calls.Add(1) })
+	call(1)
+	stop()
+	call(2) // stop 之后的调用被忽略
+	stop()  // 重复 stop 没有影响
+	time.Sleep(100 * time.Millisecond)
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("f ran %d times after stop", n)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	var got []int
+	throttled := Throttle(time.Hour, func(v int) { got = append(got, v) })
+	if !throttled(1) {
+		t.Fatal("first call throttled")
+	}
+	for i := 2; i <= 5; i++ {
+		if throttled(i) {
+			t.Fatalf("call %d within interval ran", i)
+		}
+	}
+	if len(got) != 1 || got[0] != 1 {
+		t.Fatalf("f called with %v; want [1]", got)
+	}
+
+	// 间隔极短时每次调用都执行
+	var n int
+	every := Throttle(time.Nanosecond, func(int) { n++ })
+	for i := range 3 {
+		every(i)
+		time.Sleep(time.Millisecond)
+	}
+	if n != 3 {
+		t.Fatalf("f ran %d times; want 3", n)
+	}
+}
+
+// TestThrottleConcurrent 并发调用时同一个间隔内只有一个调用者执行 f
+func TestThrottleConcurrent(t *testing.T) {
+	var ran, allowed atomic.Int32
+	throttled := Throttle(time.Hour, func(int) { ran.Add(1) })
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if throttled(i) {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if ran.Load() != 1 || allowed.Load() != 1 {
+		t.Fatalf("ran %d, allowed %d; want 1, 1", ran.Load(), allowed.Load())
+	}
+}
@@ -0,0 +1,200 @@
+// ============================= 1. 分片并发Map ====================
+// 普通map并发读写会 fatal error；sync.Map 没有类型约束且适合读多写少
+// ConcurrentMap 把键按哈希分到多个分片，每个分片一把读写锁：
+// - 不同分片的操作互不阻塞，写冲突概率约为 1/分片数
+// - Compute 系列在分片锁内完成"读-改-写"，保证原子性
+
+package cmap
+
+import (
+	"hash/maphash"
+	"iter"
+	"runtime"
+	"sync"
+)
+
+// ConcurrentMap 类型安全的分片并发Map
+type ConcurrentMap[K comparable, V any] struct {
+	seed   maphash.Seed
+	shards []*shard[K, V]
+	mask   uint64
+}
+
+type shard[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+}
+
+// New 创建并发Map，shards 会向上取整为2的幂，<=0 时按CPU数自动选择
+func New[K comparable, V any](shards int) *ConcurrentMap[K, V] {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0) * 4
+	}
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+
+	cm := &ConcurrentMap[K, V]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]*shard[K, V], n),
+		mask:   uint64(n - 1),
+	}
+	for i := range cm.shards {
+		cm.shards[i] = &shard[K, V]{m: make(map[K]V)}
+	}
+	return cm
+}
+
+func (cm *ConcurrentMap[K, V]) shardFor(key K) *shard[K, V] {
+	return cm.shards[maphash.Comparable(cm.seed, key)&cm.mask]
+}
+
+// ============================= 2. 基本操作 ====================
+
+// Load 读取键对应的值
+func (cm *ConcurrentMap[K, V]) Load(key K) (V, bool) {
+	s := cm.shardFor(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.m[key]
+	return v, ok
+}
+
+// Store 写入键值
+func (cm *ConcurrentMap[K, V]) Store(key K, value V) {
+	s := cm.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[key] = value
+}
+
+// LoadOrStore 键存在时返回已有值(loaded=true)，否则写入 value
+func (cm *ConcurrentMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	s := cm.shardFor(key)
+
+	// 先用读锁快速判断，命中时避免写锁竞争
+	s.mu.RLock()
+	actual, loaded = s.m[key]
+	s.mu.RUnlock()
+	if loaded {
+		return actual, true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if actual, loaded = s.m[key]; loaded {
+		return actual, true
+	}
+	s.m[key] = value
+	return value, false
+}
+
+// LoadAndDelete 删除键并返回删除前的值
+func (cm *ConcurrentMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	s := cm.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.m[key]
+	if ok {
+		delete(s.m, key)
+	}
+	return v, ok
+}
+
+// Delete 删除键
+func (cm *ConcurrentMap[K, V]) Delete(key K) {
+	cm.LoadAndDelete(key)
+}
+
+// ============================= 3. 原子计算 ====================
+
+// Compute 在分片锁内根据旧值计算新值；fn 返回 keep=false 时删除该键
+// fn 中不能再访问同一个 ConcurrentMap，否则可能死锁
+func (cm *ConcurrentMap[K, V]) Compute(key K, fn func(old V, loaded bool) (value V, keep bool)) (V, bool) {
+	s := cm.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, loaded := s.m[key]
+	value, keep := fn(old, loaded)
+	if !keep {
+		delete(s.m, key)
+		var zero V
+		return zero, false
+	}
+	s.m[key] = value
+	return value, true
+}
+
+// ComputeIfAbsent 键不存在时调用 fn 创建值，fn 对同一个键最多执行一次
+func (cm *ConcurrentMap[K, V]) ComputeIfAbsent(key K, fn func() V) (actual V, loaded bool) {
+	if v, ok := cm.Load(key); ok {
+		return v, true
+	}
+
+	s := cm.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.m[key]; ok {
+		return v, true
+	}
+	v := fn()
+	s.m[key] = v
+	return v, false
+}
+
+// ============================= 4. 遍历与统计 ====================
+
+// Len 所有分片的元素总数（并发修改时为近似值）
+func (cm *ConcurrentMap[K, V]) Len() int {
+	n := 0
+	for _, s := range cm.shards {
+		s.mu.RLock()
+		n += len(s.m)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Snapshot 逐个分片复制出普通map，复制期间只短暂持有单个分片的读锁
+func (cm *ConcurrentMap[K, V]) Snapshot() map[K]V {
+	out := make(map[K]V, cm.Len())
+	for _, s := range cm.shards {
+		s.mu.RLock()
+		for k, v := range s.m {
+			out[k] = v
+		}
+		s.mu.RUnlock()
+	}
+	return out
+}
+
+// All 基于快照的迭代器，遍历时可以安全地修改 ConcurrentMap
+func (cm *ConcurrentMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range cm.Snapshot() {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Range 与 sync.Map.Range 相同的回调风格，返回 false 停止遍历
+func (cm *ConcurrentMap[K, V]) Range(fn func(key K, value V) bool) {
+	for k, v := range cm.All() {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// Clear 清空所有分片
+func (cm *ConcurrentMap[K, V]) Clear() {
+	for _, s := range cm.shards {
+		s.mu.Lock()
+		clear(s.m)
+		s.mu.Unlock()
+	}
+}
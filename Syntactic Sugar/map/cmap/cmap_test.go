@@ -0,0 +1,257 @@
+package cmap
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// parallel 启动 n 个 goroutine 执行 fn(i) 并等待全部结束
+func parallel(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCompute(t *testing.T) {
+	const workers, rounds, keys = 16, 512, 8
+	cm := New[int, int](2) // 分片少，制造锁竞争
+
+	// 并发自增：读-改-写在分片锁内完成，不会丢失更新
+	parallel(workers, func(int) {
+		for i := range rounds {
+			cm.Compute(i%keys, func(old int, _ bool) (int, bool) {
+				return old + 1, true
+			})
+		}
+	})
+	for k := range keys {
+		if v, _ := cm.Load(k); v != workers*rounds/keys {
+			t.Errorf("key %d = %d, want %d", k, v, workers*rounds/keys)
+		}
+	}
+
+	// keep=false 删除键
+	if v, ok := cm.Compute(0, func(int, bool) (int, bool) { return 0, false }); ok || v != 0 {
+		t.Errorf("Compute delete = %d, %t", v, ok)
+	}
+	if _, ok := cm.Load(0); ok {
+		t.Error("key 0 still present after Compute delete")
+	}
+	if _, ok := cm.Compute(-1, func(_ int, loaded bool) (int, bool) {
+		if loaded {
+			t.Error("missing key reported as loaded")
+		}
+		return 0, false
+	}); ok || cm.Len() != keys-1 {
+		t.Errorf("Compute on missing key with keep=false: Len = %d", cm.Len())
+	}
+}
+
+func TestComputeIfAbsentOnce(t *testing.T) {
+	const workers, keys = 32, 64
+	cm := New[int, *int](4)
+	var calls [keys]atomic.Int32
+	results := make([][keys]*int, workers)
+
+	parallel(workers, func(w int) {
+		for k := range keys {
+			v, _ := cm.ComputeIfAbsent(k, func() *int {
+				calls[k].Add(1)
+				return new(int)
+			})
+			results[w][k] = v
+		}
+	})
+
+	// 每个键的 fn 恰好执行一次，所有调用方拿到同一个值
+	for k := range keys {
+		if n := calls[k].Load(); n != 1 {
+			t.Errorf("key %d: fn called %d times, want 1", k, n)
+		}
+		for w := range workers {
+			if results[w][k] != results[0][k] {
+				t.Fatalf("key %d: worker %d got a different value", k, w)
+			}
+		}
+	}
+}
+
+func TestLoadOrStore(t *testing.T) {
+	const workers, keys = 32, 64
+	cm := New[int, int](4)
+	var stored [keys]atomic.Int32
+	actuals := make([][keys]int, workers)
+
+	parallel(workers, func(w int) {
+		for k := range keys {
+			v, loaded := cm.LoadOrStore(k, w)
+			if !loaded {
+				stored[k].Add(1)
+			}
+			actuals[w][k] = v
+		}
+	})
+
+	// 每个键只有一个调用方写入成功，其余都读到它写入的值
+	for k := range keys {
+		if n := stored[k].Load(); n != 1 {
+			t.Errorf("key %d stored %d times, want 1", k, n)
+		}
+		want, _ := cm.Load(k)
+		for w := range workers {
+			if actuals[w][k] != want {
+				t.Fatalf("key %d: worker %d saw %d, want %d", k, w, actuals[w][k], want)
+			}
+		}
+	}
+}
+
+func TestRangeDuringWrite(t *testing.T) {
+	const stable = 100
+	cm := New[int, int](4)
+	for k := range stable {
+		cm.Store(k, k)
+	}
+
+	// 写入方不断增删 [stable, stable+100) 的键，稳定键始终可见且值不变
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			k := stable + i%100
+			cm.Store(k, -1)
+			cm.Delete(k)
+		}
+	}()
+
+	for range 200 {
+		seen := 0
+		cm.Range(func(k, v int) bool {
+			if k < stable {
+				if v != k {
+					t.Errorf("key %d = %d during writes", k, v)
+				}
+				seen++
+			} else if v != -1 {
+				t.Errorf("transient key %d = %d", k, v)
+			}
+			return true
+		})
+		if seen != stable {
+			t.Fatalf("Range saw %d stable keys, want %d", seen, stable)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	// 回调中可以修改 Map，返回 false 提前结束
+	visited := 0
+	cm.Range(func(k, _ int) bool {
+		cm.Delete(k)
+		visited++
+		return visited < 10
+	})
+	if visited != 10 || cm.Len() != stable-10 {
+		t.Errorf("early stop: visited %d, Len %d", visited, cm.Len())
+	}
+}
+
+// mutexMap 一把读写锁保护的普通map，作为性能对比基准
+type mutexMap struct {
+	mu sync.RWMutex
+	m  map[int]int
+}
+
+func (mm *mutexMap) Load(key int) (int, bool) {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	v, ok := mm.m[key]
+	return v, ok
+}
+
+func (mm *mutexMap) Store(key, value int) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.m[key] = value
+}
+
+// syncMapAdapter 把 sync.Map 包装成相同接口
+type syncMapAdapter struct{ m sync.Map }
+
+func (a *syncMapAdapter) Load(key int) (int, bool) {
+	v, ok := a.m.Load(key)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+
+func (a *syncMapAdapter) Store(key, value int) { a.m.Store(key, value) }
+
+// 三种实现共用的读写接口
+type benchMap interface {
+	Load(key int) (int, bool)
+	Store(key, value int)
+}
+
+// BenchmarkConcurrentMaps 读多(90%读)、写多(90%写)、混合(50%读) 三种负载下的并行读写
+//
+//	go test -bench . -cpu 1,4,8 ./map/cmap
+func BenchmarkConcurrentMaps(b *testing.B) {
+	const keys = 1 << 12
+
+	impls := []struct {
+		name string
+		make func() benchMap
+	}{
+		{"SyncMap", func() benchMap { return &syncMapAdapter{} }},
+		{"MutexMap", func() benchMap { return &mutexMap{m: make(map[int]int)} }},
+		{"ConcurrentMap", func() benchMap { return New[int, int](0) }},
+	}
+	workloads := []struct {
+		name    string
+		readPct int
+	}{
+		{"ReadHeavy", 90},
+		{"WriteHeavy", 10},
+		{"Mixed", 50},
+	}
+
+	for _, w := range workloads {
+		for _, impl := range impls {
+			b.Run(w.name+"/"+impl.name, func(b *testing.B) {
+				m := impl.make()
+				for i := 0; i < keys; i++ {
+					m.Store(i, i)
+				}
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					r := rand.New(rand.NewSource(rand.Int63()))
+					for pb.Next() {
+						key := r.Intn(keys)
+						if r.Intn(100) < w.readPct {
+							m.Load(key)
+						} else {
+							m.Store(key, key)
+						}
+					}
+				})
+			})
+		}
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"sync"
+
+	"Syntactic_Sugar/map/cmap"
 )
 
 func main() {
@@ -148,6 +151,16 @@ func main() {
 
 	// 演示并发不安全（实际运行可能触发panic）
 	demoConcurrentIssue()
+
+	// ======================= 9. 分片并发Map =======================
+
+	fmt.Println("\n分片并发Map演示:")
+	demoConcurrentMap()
+
+	// ======================= 10. 并发Map性能对比 =======================
+
+	// sync.Map、读写锁map与分片map的并行基准测试见 cmap/cmap_test.go：
+	//   go test -bench . -cpu 1,4,8 ./map/cmap
 }
 
 // 演示并发问题（运行时可能触发panic）
@@ -174,6 +187,44 @@ func demoConcurrentIssue() {
 	}
 }
 
+// 分片并发Map：类型安全 + 原子的读改写
+func demoConcurrentMap() {
+	counts := cmap.New[string, int](0)
+
+	// 9.1 并发计数：Compute 在分片锁内完成读-改-写
+	var wg sync.WaitGroup
+	words := []string{"go", "map", "go", "sync", "go", "map"}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			word := words[i%len(words)]
+			counts.Compute(word, func(old int, loaded bool) (int, bool) {
+				return old + 1, true
+			})
+		}()
+	}
+	wg.Wait()
+	fmt.Printf("单词计数: %v, 键数量: %d\n", counts.Snapshot(), counts.Len())
+
+	// 9.2 ComputeIfAbsent：只在第一次访问时创建
+	cache := cmap.New[int, []int](16)
+	squares, loaded := cache.ComputeIfAbsent(3, func() []int { return []int{1, 4, 9} })
+	fmt.Printf("首次计算: %v, 已存在: %t\n", squares, loaded)
+	_, loaded = cache.ComputeIfAbsent(3, func() []int { panic("不会执行") })
+	fmt.Printf("再次访问已存在: %t\n", loaded)
+
+	// 9.3 LoadOrStore 和快照遍历（遍历时修改不会死锁）
+	actual, loaded := counts.LoadOrStore("go", 100)
+	fmt.Printf("LoadOrStore: %d, 已存在: %t\n", actual, loaded)
+	for word, n := range counts.All() {
+		if n < 20 {
+			counts.Delete(word)
+		}
+	}
+	fmt.Printf("删除计数<20后: %v\n", counts.Snapshot())
+}
+
 // ============================= 总结知识点 ============================
 /*
 1. 初始化:
@@ -214,7 +265,13 @@ func demoConcurrentIssue() {
    - 并发读写会触发fatal error
    - 高并发用sync.Map
 
-9. 键类型要求:
+9. 分片并发Map:
+   - 按哈希分片，每个分片一把读写锁，降低锁竞争
+   - Compute/ComputeIfAbsent 原子地读改写
+   - 基于快照遍历，遍历时修改不会死锁
+   - sync.Map 适合键集合稳定的读多场景，写多场景分片锁更有优势，以基准测试结果为准
+
+10. 键类型要求:
    - 必须是可比较类型
    - 不能是slice, map, function等不可比较类型
 */
@@ -0,0 +1,185 @@
+// ============================= 2. 帧格式 ====================
+// 写入时把头部和载荷拼成一次 Write，避免并发写入交错或被拆成两个TCP包
+
+package framing
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// PrefixWidth 长度前缀的字节数
+type PrefixWidth int
+
+const (
+	Uint16 PrefixWidth = 2
+	Uint32 PrefixWidth = 4
+)
+
+// LengthPrefix 长度前缀帧：长度 + 载荷
+type LengthPrefix struct {
+	Width   PrefixWidth      // 默认 Uint32
+	Order   binary.ByteOrder // 默认大端（网络字节序）
+	MaxSize int              // 默认 DefaultMaxSize，Uint16 最大 65535
+}
+
+func (f LengthPrefix) params() (PrefixWidth, binary.ByteOrder, int) {
+	width, order, limit := f.Width, f.Order, maxSize(f.MaxSize)
+	if width != Uint16 {
+		width = Uint32
+	}
+	if order == nil {
+		order = binary.BigEndian
+	}
+	if width == Uint16 {
+		limit = min(limit, 0xFFFF)
+	}
+	return width, order, limit
+}
+
+func (f LengthPrefix) ReadFrame(r *bufio.Reader) ([]byte, error) {
+	width, order, limit := f.params()
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:width]); err != nil {
+		return nil, err // 没读到任何字节时是 io.EOF
+	}
+	var n uint64
+	if width == Uint16 {
+		n = uint64(order.Uint16(hdr[:]))
+	} else {
+		n = uint64(order.Uint32(hdr[:]))
+	}
+	if n > uint64(limit) {
+		return nil, fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, n, limit)
+	}
+	return readFull(r, int(n))
+}
+
+func (f LengthPrefix) WriteFrame(w io.Writer, p []byte) error {
+	width, order, limit := f.params()
+	if len(p) > limit {
+		return fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, len(p), limit)
+	}
+	buf := make([]byte, int(width)+len(p))
+	if width == Uint16 {
+		order.PutUint16(buf, uint16(len(p)))
+	} else {
+		order.PutUint32(buf, uint32(len(p)))
+	}
+	copy(buf[width:], p)
+	_, err := w.Write(buf)
+	return err
+}
+
+var errEmptyDelimiter = errors.New("framing: empty delimiter")
+
+// Lines 以换行符分隔的帧
+var Lines = Delimited{Delim: []byte("\n")}
+
+// Delimited 分隔符帧：载荷 + 分隔符，读取时返回的帧不含分隔符
+type Delimited struct {
+	Delim   []byte // 不能为空
+	MaxSize int    // 载荷最大长度，默认 DefaultMaxSize
+}
+
+func (f Delimited) ReadFrame(r *bufio.Reader) ([]byte, error) {
+	if len(f.Delim) == 0 {
+		return nil, errEmptyDelimiter
+	}
+	limit := maxSize(f.MaxSize)
+	last := f.Delim[len(f.Delim)-1]
+	var frame []byte
+	for {
+		// 多字节分隔符：读到最后一个字节后检查累积的数据是否以分隔符结尾
+		chunk, err := r.ReadSlice(last)
+		frame = append(frame, chunk...)
+		if err == nil && bytes.HasSuffix(frame, f.Delim) {
+			payload := frame[:len(frame)-len(f.Delim)]
+			if len(payload) > limit {
+				return nil, fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, len(payload), limit)
+			}
+			return payload, nil
+		}
+		// 还没结束但已经放不下：再来一个字节载荷就超长了
+		if len(frame) >= limit+len(f.Delim) {
+			return nil, fmt.Errorf("%w: more than %d bytes without delimiter", ErrFrameTooLarge, limit)
+		}
+		switch {
+		case err == nil, err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && len(frame) > 0:
+			return nil, io.ErrUnexpectedEOF
+		default:
+			return nil, err
+		}
+	}
+}
+
+func (f Delimited) WriteFrame(w io.Writer, p []byte) error {
+	if len(f.Delim) == 0 {
+		return errEmptyDelimiter
+	}
+	if limit := maxSize(f.MaxSize); len(p) > limit {
+		return fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, len(p), limit)
+	}
+	// 分隔符可能跨越载荷末尾和分隔符本身，例如载荷以 "\r" 结尾、分隔符为 "\r\n"
+	if bytes.Contains(append(p[:len(p):len(p)], f.Delim[:len(f.Delim)-1]...), f.Delim) {
+		return ErrDelimiterInPayload
+	}
+	_, err := w.Write(append(p[:len(p):len(p)], f.Delim...))
+	return err
+}
+
+// Fixed 固定长度帧
+type Fixed struct {
+	Size int
+}
+
+func (f Fixed) ReadFrame(r *bufio.Reader) ([]byte, error) {
+	if f.Size <= 0 {
+		return nil, fmt.Errorf("%w: size %d", ErrFrameSize, f.Size)
+	}
+	buf := make([]byte, f.Size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (f Fixed) WriteFrame(w io.Writer, p []byte) error {
+	if f.Size <= 0 || len(p) != f.Size {
+		return fmt.Errorf("%w: got %d, want %d", ErrFrameSize, len(p), f.Size)
+	}
+	_, err := w.Write(p)
+	return err
+}
+
+// Varint varint 长度前缀帧
+type Varint struct {
+	MaxSize int // 默认 DefaultMaxSize
+}
+
+func (f Varint) ReadFrame(r *bufio.Reader) ([]byte, error) {
+	limit := maxSize(f.MaxSize)
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err // 没读到任何字节时是 io.EOF，varint 溢出时是格式错误
+	}
+	if n > uint64(limit) {
+		return nil, fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, n, limit)
+	}
+	return readFull(r, int(n))
+}
+
+func (f Varint) WriteFrame(w io.Writer, p []byte) error {
+	if limit := maxSize(f.MaxSize); len(p) > limit {
+		return fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, len(p), limit)
+	}
+	buf := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(p)), uint64(len(p)))
+	_, err := w.Write(append(buf, p...))
+	return err
+}
@@ -0,0 +1,80 @@
+// ============================= 1. 消息分帧 ====================
+// TCP 是字节流，一次 Read 可能只返回半条消息，也可能包含多条消息，需要约定消息边界：
+// - LengthPrefix：uint16/uint32 长度前缀，大端或小端
+// - Delimited：分隔符结尾，例如换行符，载荷中不能包含分隔符
+// - Fixed：固定长度
+// - Varint：varint 长度前缀，小消息只多一个字节
+// 读取前先检查 MaxSize，不会因为对方声明了超大长度而分配大块内存
+// 读到超大帧或格式错误后流已经错位，应当关闭连接
+
+package framing
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+)
+
+// DefaultMaxSize MaxSize 为 0 时的最大帧长度
+const DefaultMaxSize = 1 << 20
+
+var (
+	// ErrFrameTooLarge 帧超过 MaxSize
+	ErrFrameTooLarge = errors.New("framing: frame too large")
+	// ErrFrameSize 固定长度帧的长度不对
+	ErrFrameSize = errors.New("framing: wrong frame size")
+	// ErrDelimiterInPayload 载荷中包含分隔符
+	ErrDelimiterInPayload = errors.New("framing: payload contains delimiter")
+)
+
+// Framer 帧格式，实现不保存状态，可以被多个连接共用
+// ReadFrame 在帧边界处遇到流结束返回 io.EOF，帧读到一半返回 io.ErrUnexpectedEOF
+type Framer interface {
+	ReadFrame(r *bufio.Reader) ([]byte, error)
+	WriteFrame(w io.Writer, p []byte) error
+}
+
+func maxSize(n int) int {
+	if n <= 0 {
+		return DefaultMaxSize
+	}
+	return n
+}
+
+// readFull 读取帧的剩余部分，此时流结束都是意外结束
+func readFull(r io.Reader, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Conn 按帧读写的连接，客户端和服务器都可以使用
+type Conn struct {
+	framer Framer
+	r      *bufio.Reader
+	w      io.Writer
+	wmu    sync.Mutex
+}
+
+// NewConn 用 framer 包装 rw
+func NewConn(rw io.ReadWriter, framer Framer) *Conn {
+	return &Conn{framer: framer, r: bufio.NewReader(rw), w: rw}
+}
+
+// ReadFrame 读取下一帧，同一时刻只能有一个goroutine读取
+func (c *Conn) ReadFrame() ([]byte, error) {
+	return c.framer.ReadFrame(c.r)
+}
+
+// WriteFrame 写入一帧，可以被多个goroutine并发调用，帧之间不会交错
+func (c *Conn) WriteFrame(p []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.framer.WriteFrame(c.w, p)
+}
@@ -0,0 +1,263 @@
+package framing
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// chunkReader 每次 Read 只返回1~7个字节，模拟TCP把消息拆成多个分段
+type chunkReader struct {
+	data []byte
+	rnd  *rand.Rand
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data[:min(len(r.data), 1+r.rnd.Intn(7))])
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// randBytes 从 alphabet 中随机取字节，alphabet 为空时取任意字节
+func randBytes(rnd *rand.Rand, alphabet string, minLen, maxLen int) []byte {
+	b := make([]byte, minLen+rnd.Intn(maxLen-minLen+1))
+	for i := range b {
+		if alphabet == "" {
+			b[i] = byte(rnd.Intn(256))
+		} else {
+			b[i] = alphabet[rnd.Intn(len(alphabet))]
+		}
+	}
+	return b
+}
+
+// readAll 读取直到出错
+func readAll(f Framer, r io.Reader) ([][]byte, error) {
+	br := bufio.NewReader(r)
+	var frames [][]byte
+	for {
+		frame, err := f.ReadFrame(br)
+		if err != nil {
+			return frames, err
+		}
+		frames = append(frames, frame)
+	}
+}
+
+// checkFramer 写入 payloads 后按随机分段读回，再在 cut 处截断读取，最后把 garbage 当作输入读取
+// 超过 limit 的载荷和包含分隔符的载荷写入时应被拒绝，其余载荷必须原样读回
+func checkFramer(t *testing.T, f Framer, limit int, payloads [][]byte, cut int, garbage []byte, rnd *rand.Rand) {
+	t.Helper()
+	var (
+		stream     bytes.Buffer
+		want       [][]byte
+		boundaries = map[int]int{0: 0} // 帧边界偏移 -> 之前的帧数
+	)
+	for _, p := range payloads {
+		err := f.WriteFrame(&stream, p)
+		switch {
+		case len(p) > limit:
+			if !errors.Is(err, ErrFrameTooLarge) {
+				t.Fatalf("WriteFrame(%d bytes) = %v, want ErrFrameTooLarge", len(p), err)
+			}
+			continue
+		case errors.Is(err, ErrDelimiterInPayload):
+			continue
+		case err != nil:
+			t.Fatalf("WriteFrame(%q) = %v", p, err)
+		}
+		want = append(want, p)
+		boundaries[stream.Len()] = len(want)
+	}
+
+	// 往返：按随机分段读回，结果完全一致
+	data := stream.Bytes()
+	got, err := readAll(f, &chunkReader{data: data, rnd: rnd})
+	if err != io.EOF {
+		t.Fatalf("round trip err = %v, want io.EOF", err)
+	}
+	if !slices.EqualFunc(got, want, bytes.Equal) {
+		t.Fatalf("round trip = %q, want %q", got, want)
+	}
+
+	// 截断：之前的帧完整读出，断在帧中间时返回 io.ErrUnexpectedEOF
+	cut %= len(data) + 1
+	got, err = readAll(f, &chunkReader{data: data[:cut], rnd: rnd})
+	if n, atBoundary := boundaries[cut]; atBoundary {
+		if err != io.EOF || len(got) != n {
+			t.Fatalf("cut at boundary %d: %d frames, err %v; want %d frames, io.EOF", cut, len(got), err, n)
+		}
+	} else if err != io.ErrUnexpectedEOF {
+		t.Fatalf("cut inside frame at %d: err %v, want io.ErrUnexpectedEOF", cut, err)
+	}
+	if !slices.EqualFunc(got, want[:min(len(got), len(want))], bytes.Equal) {
+		t.Fatalf("cut at %d = %q, want prefix of %q", cut, got, want)
+	}
+
+	// 任意字节：不能返回超过 limit 的帧，panic 由测试框架报告
+	got, _ = readAll(f, &chunkReader{data: garbage, rnd: rnd})
+	for _, frame := range got {
+		if len(frame) > limit {
+			t.Fatalf("garbage input produced %d-byte frame, limit %d", len(frame), limit)
+		}
+	}
+}
+
+func TestFramers(t *testing.T) {
+	cases := []struct {
+		name    string
+		framer  Framer
+		limit   int
+		payload func(*rand.Rand) []byte
+	}{
+		{"Uint16BigEndian", LengthPrefix{Width: Uint16, MaxSize: 64}, 64,
+			func(rnd *rand.Rand) []byte { return randBytes(rnd, "", 0, 80) }},
+		{"Uint32LittleEndian", LengthPrefix{Order: binary.LittleEndian, MaxSize: 64}, 64,
+			func(rnd *rand.Rand) []byte { return randBytes(rnd, "", 0, 80) }},
+		{"Lines", Lines, DefaultMaxSize,
+			func(rnd *rand.Rand) []byte { return randBytes(rnd, "abc xyz", 0, 64) }},
+		{"CRLFCRLF", Delimited{Delim: []byte("\r\n\r\n"), MaxSize: 64}, 64,
+			func(rnd *rand.Rand) []byte { return randBytes(rnd, "a\r\n", 0, 16) }}, // 经常包含分隔符，应被拒绝
+		{"Fixed8", Fixed{Size: 8}, 8,
+			func(rnd *rand.Rand) []byte { return randBytes(rnd, "", 8, 8) }},
+		{"Varint", Varint{MaxSize: 300}, 300,
+			func(rnd *rand.Rand) []byte { return randBytes(rnd, "", 0, 320) }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(1))
+			for range 300 {
+				payloads := make([][]byte, 1+rnd.Intn(10))
+				for i := range payloads {
+					payloads[i] = c.payload(rnd)
+				}
+				checkFramer(t, c.framer, c.limit, payloads, rnd.Int(), randBytes(rnd, "", 0, 200), rnd)
+			}
+		})
+	}
+}
+
+func TestDelimiterAcrossPayloadEnd(t *testing.T) {
+	// 载荷以 "\r\n" 结尾时，读取方会在载荷末尾的 "\r\n" 加上分隔符前两个字节处提前结束
+	f := Delimited{Delim: []byte("\r\n\r\n")}
+	if err := f.WriteFrame(io.Discard, []byte("abc\r\n")); !errors.Is(err, ErrDelimiterInPayload) {
+		t.Fatalf("payload ending in \\r\\n: err = %v, want ErrDelimiterInPayload", err)
+	}
+	if err := f.WriteFrame(io.Discard, []byte("abc\r")); err != nil {
+		t.Fatalf("payload ending in \\r: err = %v", err)
+	}
+}
+
+func TestOversized(t *testing.T) {
+	// 读取时先检查声明的长度，不会分配 2GB 内存
+	huge := binary.BigEndian.AppendUint32(nil, 1<<31)
+	if _, err := (LengthPrefix{MaxSize: 64}).ReadFrame(bufio.NewReader(bytes.NewReader(huge))); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("declared 2GB frame: err = %v, want ErrFrameTooLarge", err)
+	}
+	huge = binary.AppendUvarint(nil, 1<<40)
+	if _, err := (Varint{}).ReadFrame(bufio.NewReader(bytes.NewReader(huge))); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("declared 1TB varint frame: err = %v, want ErrFrameTooLarge", err)
+	}
+	if _, err := Lines.ReadFrame(bufio.NewReader(bytes.NewReader(make([]byte, DefaultMaxSize+1)))); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("no newline in %d bytes: err = %v, want ErrFrameTooLarge", DefaultMaxSize+1, err)
+	}
+	// Uint16 忽略更大的 MaxSize
+	if err := (LengthPrefix{Width: Uint16}).WriteFrame(io.Discard, make([]byte, 70000)); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("70000 bytes with uint16 prefix: err = %v, want ErrFrameTooLarge", err)
+	}
+	if err := (Fixed{Size: 8}).WriteFrame(io.Discard, make([]byte, 9)); !errors.Is(err, ErrFrameSize) {
+		t.Errorf("9 bytes with Fixed{8}: err = %v, want ErrFrameSize", err)
+	}
+}
+
+// fuzzPayloads 用 sep 把模糊输入切成多个载荷
+func fuzzPayloads(data []byte, sep byte) [][]byte {
+	return bytes.Split(data, []byte{sep})
+}
+
+// checkDeclared 只有头部、声明长度为 n 的帧：超过 limit 返回 ErrFrameTooLarge，否则是截断
+func checkDeclared(t *testing.T, f Framer, hdr []byte, n uint64, limit int) {
+	t.Helper()
+	frame, err := f.ReadFrame(bufio.NewReader(bytes.NewReader(hdr)))
+	switch {
+	case n > uint64(limit):
+		if !errors.Is(err, ErrFrameTooLarge) {
+			t.Fatalf("declared %d > %d: err = %v, want ErrFrameTooLarge", n, limit, err)
+		}
+	case n == 0:
+		if err != nil || len(frame) != 0 {
+			t.Fatalf("declared 0: frame %q, err %v", frame, err)
+		}
+	case err != io.ErrUnexpectedEOF:
+		t.Fatalf("declared %d without payload: err = %v, want io.ErrUnexpectedEOF", n, err)
+	}
+}
+
+func FuzzLengthPrefix(f *testing.F) {
+	f.Add([]byte("hello,,world"), byte(','), uint16(7), uint32(5), false, false)
+	f.Add([]byte{0, 0, 0, 0xff, 0xff}, byte(0), uint16(3), uint32(1<<31), true, true)
+	f.Add(bytes.Repeat([]byte{'x'}, 100), byte(','), uint16(0), uint32(65), true, false)
+	f.Fuzz(func(t *testing.T, data []byte, sep byte, cut uint16, declared uint32, narrow, little bool) {
+		fr := LengthPrefix{MaxSize: 64}
+		var order interface {
+			binary.ByteOrder
+			binary.AppendByteOrder
+		} = binary.BigEndian
+		if little {
+			order, fr.Order = binary.LittleEndian, binary.LittleEndian
+		}
+		hdr := order.AppendUint32(nil, declared)
+		if narrow {
+			fr.Width = Uint16
+			declared &= 0xFFFF
+			hdr = order.AppendUint16(nil, uint16(declared))
+		}
+		rnd := rand.New(rand.NewSource(int64(cut)))
+		checkFramer(t, fr, 64, fuzzPayloads(data, sep), int(cut), data, rnd)
+		checkDeclared(t, fr, hdr, uint64(declared), 64)
+	})
+}
+
+func FuzzDelimited(f *testing.F) {
+	f.Add([]byte("GET / HTTP/1.1\r\n\r\nbody"), []byte("\r\n\r\n"), byte(' '), uint16(9))
+	f.Add([]byte("a\nb\n\nc"), []byte("\n"), byte('\n'), uint16(3))
+	f.Add([]byte("abc\r"), []byte("\r\n"), byte(0), uint16(0))
+	f.Add(bytes.Repeat([]byte{'x'}, 100), []byte("|"), byte('|'), uint16(50))
+	f.Fuzz(func(t *testing.T, data, delim []byte, sep byte, cut uint16) {
+		if len(delim) == 0 || len(delim) > 8 {
+			return
+		}
+		fr := Delimited{Delim: delim, MaxSize: 64}
+		rnd := rand.New(rand.NewSource(int64(cut)))
+		checkFramer(t, fr, 64, fuzzPayloads(data, sep), int(cut), data, rnd)
+
+		// 超长：没有分隔符的数据超过 MaxSize 时读取失败，不会一直缓存
+		long := bytes.Repeat([]byte{delim[0] + 1}, 64+len(delim)+1)
+		if bytes.Contains(long, delim) {
+			return
+		}
+		if _, err := fr.ReadFrame(bufio.NewReader(bytes.NewReader(long))); !errors.Is(err, ErrFrameTooLarge) {
+			t.Fatalf("%d bytes without delimiter: err = %v, want ErrFrameTooLarge", len(long), err)
+		}
+	})
+}
+
+func FuzzVarint(f *testing.F) {
+	f.Add([]byte("hello,,world"), byte(','), uint16(7), uint64(5))
+	f.Add([]byte{0x80, 0x80, 0x80}, byte(0), uint16(2), uint64(1<<40))
+	f.Add(bytes.Repeat([]byte{'x'}, 400), byte(','), uint16(0), uint64(301))
+	f.Fuzz(func(t *testing.T, data []byte, sep byte, cut uint16, declared uint64) {
+		fr := Varint{MaxSize: 300}
+		rnd := rand.New(rand.NewSource(int64(cut)))
+		checkFramer(t, fr, 300, fuzzPayloads(data, sep), int(cut), data, rnd)
+		checkDeclared(t, fr, binary.AppendUvarint(nil, declared), declared, 300)
+	})
+}
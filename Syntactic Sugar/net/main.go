@@ -1,11 +1,24 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"Syntactic_Sugar/net/framing"
+	"Syntactic_Sugar/net/rpcx"
+	"Syntactic_Sugar/net/tcpserver"
 )
 
 func main() {
@@ -52,88 +65,287 @@ func main() {
 	}
 
 	//============================= 8. TCP服务器示例 ================
-	var wg sync.WaitGroup
-	wg.Add(1)
+	// 8.1 用 tcpserver 启动服务器：最多同时处理2个连接，读、写、空闲都有超时
+	listener, err := net.Listen("tcp", "localhost:12345")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var errLog bytes.Buffer // panic日志带堆栈，演示中只打印第一行
+	srv := tcpserver.New(tcpserver.HandlerFunc(handleConnection), tcpserver.Options{
+		MaxConns:     2,
+		ReadTimeout:  2 * time.Second,
+		IdleTimeout:  500 * time.Millisecond,
+		WriteTimeout: time.Second,
+		ErrorLog:     log.New(&errLog, "", 0),
+	})
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(listener) }()
+	fmt.Println("8. TCP服务器启动在 localhost:12345")
 
-	go func() {
-		defer wg.Done()
+	//============================= 9. TCP客户端示例 ================
+	// 9.1 一条消息分两次写入，服务器按帧读取，不会只处理半条消息
+	conn, err := net.Dial("tcp", "localhost:12345")
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := framing.NewConn(conn, framer)
+	conn.Write([]byte("Hello "))
+	time.Sleep(50 * time.Millisecond)
+	conn.Write([]byte("TCP Server!\n"))
+	reply, err := client.ReadFrame()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("9.1 客户端收到: %s\n", reply)
 
-		// 8.1 启动TCP服务器
-		listener, err := net.Listen("tcp", "localhost:12345")
-		if err != nil {
-			log.Fatal(err)
-		}
-		defer listener.Close()
-		fmt.Println("8. TCP服务器启动在 localhost:12345")
+	// 9.2 Handler panic：只关闭这个连接，服务器继续运行
+	client.WriteFrame([]byte("panic"))
+	_, err = client.ReadFrame()
+	fmt.Printf("9.2 panic后连接被关闭: %v\n", err)
+	conn.Close()
 
-		for {
-			// 8.2 接受客户端连接
-			conn, err := listener.Accept()
-			if err != nil {
-				log.Print(err)
-				continue
-			}
+	// 9.3 连接数上限 + 空闲超时：两个空闲连接占满名额，第三个连接要等它们超时被关闭
+	idleA, _ := net.Dial("tcp", "localhost:12345")
+	idleB, _ := net.Dial("tcp", "localhost:12345")
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	queued, _ := net.Dial("tcp", "localhost:12345") // 在内核backlog中等待 Accept
+	queuedConn := framing.NewConn(queued, framer)
+	queuedConn.WriteFrame([]byte("queued"))
+	reply, _ = queuedConn.ReadFrame()
+	_, errA := idleA.Read(make([]byte, 1))
+	fmt.Printf("9.3 排队%v后收到: %s    空闲连接: %v\n", time.Since(start).Round(100*time.Millisecond), reply, errA)
+	idleA.Close()
+	idleB.Close()
+	queued.Close()
 
-			// 8.3 为每个连接创建goroutine处理
-			go handleConnection(conn)
-		}
+	// 9.4 优雅关闭：已经开始的消息处理完再关闭，空闲连接立即关闭
+	busy, _ := net.Dial("tcp", "localhost:12345")
+	idle, _ := net.Dial("tcp", "localhost:12345")
+	busy.Write([]byte("slow "))
+	time.Sleep(50 * time.Millisecond)
+	shutdownErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		shutdownErr <- srv.Shutdown(ctx)
 	}()
+	_, errIdle := idle.Read(make([]byte, 1))
+	busy.Write([]byte("request\n"))
+	reply, _ = framing.NewConn(busy, framer).ReadFrame()
+	fmt.Printf("9.4 关闭中空闲连接: %v, 处理中的消息: %s\n", errIdle, reply)
+	fmt.Printf("   Shutdown: %v, Serve: %v\n", <-shutdownErr, <-serveErr)
+	busy.Close()
+	idle.Close()
 
-	//============================= 9. TCP客户端示例 ================
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+	st := srv.Stats()
+	fmt.Printf("   指标: 接受%d 活跃%d panic%d 超时%d 读%dB 写%dB\n",
+		st.Accepted, st.Active, st.Panics, st.Timeouts, st.BytesRead, st.BytesWritten)
+	fmt.Printf("   panic日志: %s\n", strings.SplitN(errLog.String(), "\n", 2)[0]) // Shutdown 返回后连接goroutine都已结束
+
+	framingDemo()
+	rpcDemo()
+}
+
+// ============================= 10. 连接处理函数 =================
+// 一次 Read 可能只返回半条消息，也可能包含多条消息，按帧读取完整的消息
+// 服务器和客户端共用同一个帧格式，换成 framing.LengthPrefix 等格式只需要改这里
+var framer framing.Framer = framing.Lines
 
-		// 9.1 连接到服务器
-		conn, err := net.Dial("tcp", "localhost:12345")
+func handleConnection(ctx context.Context, conn *tcpserver.Conn) {
+	fc := framing.NewConn(conn, framer)
+	for {
+		// 10.1 读取一条消息；客户端关闭、超时、服务器关闭、帧格式错误时结束
+		msg, err := fc.ReadFrame()
 		if err != nil {
-			log.Fatal(err)
+			if err != io.EOF && !errors.Is(err, os.ErrDeadlineExceeded) {
+				log.Print(err)
+			}
+			return
+		}
+		fmt.Printf("10. 服务器收到: %s\n", msg)
+		if string(msg) == "panic" {
+			panic("bad message")
 		}
-		defer conn.Close()
 
-		// 9.2 发送数据
-		message := "Hello TCP Server!"
-		_, err = conn.Write([]byte(message))
-		if err != nil {
-			log.Fatal(err)
+		// 10.2 发送响应，标记这条消息处理完毕
+		if err := fc.WriteFrame(fmt.Appendf(nil, "Message received: %s", msg)); err != nil {
+			log.Print(err)
+			return
 		}
-		fmt.Printf("9. 客户端发送: %s\n", message)
+		conn.EndMessage()
+	}
+}
+
+// ============================= 11. 消息分帧 =================
+// 往返、截断、随机输入和超长帧的检查见 framing/framing_test.go（含模糊测试）
+func framingDemo() {
+	fmt.Println("11. 消息分帧")
 
-		// 9.3 读取响应
-		buffer := make([]byte, 1024)
-		n, err := conn.Read(buffer)
-		if err != nil && err != io.EOF {
-			log.Fatal(err)
+	cases := []struct {
+		name   string
+		framer framing.Framer
+	}{
+		{"uint16大端", framing.LengthPrefix{Width: framing.Uint16}},
+		{"uint32小端", framing.LengthPrefix{Order: binary.LittleEndian}},
+		{"换行分隔", framing.Lines},
+		{"\\r\\n\\r\\n分隔", framing.Delimited{Delim: []byte("\r\n\r\n")}},
+		{"定长5字节", framing.Fixed{Size: 5}},
+		{"varint", framing.Varint{}},
+	}
+	for _, c := range cases {
+		// 11.1 写入两帧，再从同一个流中逐帧读回
+		var stream bytes.Buffer
+		for _, msg := range []string{"hello", "world"} {
+			if err := c.framer.WriteFrame(&stream, []byte(msg)); err != nil {
+				log.Fatal(err)
+			}
 		}
-		fmt.Printf("   收到服务器响应: %s\n", string(buffer[:n]))
-	}()
+		wire := fmt.Sprintf("%q", stream.Bytes())
+		br := bufio.NewReader(&stream)
+		var frames []string
+		for {
+			frame, err := c.framer.ReadFrame(br)
+			if err != nil {
+				break // 帧边界处流结束返回 io.EOF
+			}
+			frames = append(frames, string(frame))
+		}
+		fmt.Printf("   %-14s %-40s -> %q\n", c.name, wire, frames)
+	}
 
-	wg.Wait()
+	// 11.2 载荷包含分隔符时拒绝写入
+	err := framing.Lines.WriteFrame(io.Discard, []byte("a\nb"))
+	fmt.Println("   载荷包含换行:", err)
+
+	// 11.3 超长帧：读取时先检查声明的长度，不会分配 2GB 内存；写入时同样检查
+	huge := binary.BigEndian.AppendUint32(nil, 1<<31)
+	_, err = framing.LengthPrefix{MaxSize: 64}.ReadFrame(bufio.NewReader(bytes.NewReader(huge)))
+	fmt.Println("   声明2GB的帧:", err)
+	_, err = framing.Lines.ReadFrame(bufio.NewReader(bytes.NewReader(make([]byte, framing.DefaultMaxSize+1))))
+	fmt.Println("   没有换行的超长数据:", err)
+	err = framing.LengthPrefix{Width: framing.Uint16}.WriteFrame(io.Discard, make([]byte, 70000))
+	fmt.Println("   写入超过uint16的帧:", err)
 }
 
-// ============================= 10. 连接处理函数 =================
-func handleConnection(conn net.Conn) {
-	defer conn.Close()
+// ============================= 12. RPC ====================
+// rpcx：请求ID多路复用、ctx超时传递、方法注册、错误传递、JSON/gob编码、连接池
+type DivRequest struct{ A, B int }
+
+type DivReply struct{ Quotient, Remainder int }
+
+func rpcDemo() {
+	fmt.Println("12. RPC")
 
-	// 10.1 读取客户端数据
-	buffer := make([]byte, 1024)
-	n, err := conn.Read(buffer)
+	// 12.1 服务端：注册方法，交给 tcpserver 处理连接
+	var slowCanceled atomic.Int64
+	newService := func(opts rpcx.Options) *rpcx.Server {
+		s := rpcx.NewServer(opts)
+		rpcx.Register(s, "Calc.Div", func(ctx context.Context, req DivRequest) (DivReply, error) {
+			if req.B == 0 {
+				return DivReply{}, &rpcx.Error{Code: "invalid_argument", Message: "division by zero"}
+			}
+			return DivReply{req.A / req.B, req.A % req.B}, nil
+		})
+		rpcx.Register(s, "Calc.Slow", func(ctx context.Context, d time.Duration) (string, error) {
+			select {
+			case <-time.After(d):
+				return "done", nil
+			case <-ctx.Done(): // 客户端的超时随请求传过来
+				slowCanceled.Add(1)
+				return "", ctx.Err()
+			}
+		})
+		rpcx.Register(s, "Calc.Panic", func(ctx context.Context, _ struct{}) (struct{}, error) {
+			panic("boom")
+		})
+		return s
+	}
+	listener, err := net.Listen("tcp", "localhost:12346")
 	if err != nil {
-		if err != io.EOF {
-			log.Print(err)
-		}
-		return
+		log.Fatal(err)
 	}
+	srv := tcpserver.New(newService(rpcx.Options{Codec: rpcx.Gob}), tcpserver.Options{IdleTimeout: time.Minute})
+	go srv.Serve(listener)
 
-	clientMsg := string(buffer[:n])
-	fmt.Printf("10. 服务器收到: %s\n", clientMsg)
+	// 12.2 多路复用：一个连接上100个并发调用，响应乱序到达也能对应到调用者
+	client, err := rpcx.Dial(context.Background(), "localhost:12346", rpcx.Options{Codec: rpcx.Gob})
+	if err != nil {
+		log.Fatal(err)
+	}
+	var (
+		wg    sync.WaitGroup
+		wrong atomic.Int64
+	)
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reply, err := rpcx.Call[DivRequest, DivReply](context.Background(), client, "Calc.Div", DivRequest{i * 7, 7})
+			if err != nil || reply.Quotient != i {
+				wrong.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Printf("   100个并发调用, 错误%d, 服务端接受连接%d个\n", wrong.Load(), srv.Stats().Accepted)
 
-	// 10.2 发送响应
-	response := "Message received!"
-	_, err = conn.Write([]byte(response))
+	// 12.3 错误传递：方法返回的错误码、未注册的方法、panic
+	_, err = rpcx.Call[DivRequest, DivReply](context.Background(), client, "Calc.Div", DivRequest{1, 0})
+	var rpcErr *rpcx.Error
+	if errors.As(err, &rpcErr) {
+		fmt.Printf("   除零: code=%s message=%s\n", rpcErr.Code, rpcErr.Message)
+	}
+	_, err = rpcx.Call[int, int](context.Background(), client, "Calc.Mul", 1)
+	fmt.Printf("   未注册的方法: %v (ErrUnknownMethod: %t)\n", err, errors.Is(err, rpcx.ErrUnknownMethod))
+	_, err = rpcx.Call[struct{}, struct{}](context.Background(), client, "Calc.Panic", struct{}{})
+	fmt.Println("   方法panic:", err)
+
+	// 12.4 超时：客户端按时返回，服务端方法的ctx同时结束
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	start := time.Now()
+	_, err = rpcx.Call[time.Duration, string](ctx, client, "Calc.Slow", time.Second)
+	cancel()
+	time.Sleep(20 * time.Millisecond) // 等服务端处理完取消
+	fmt.Printf("   %v后返回: %v, 服务端取消%d次\n", time.Since(start).Round(100*time.Millisecond), err, slowCanceled.Load())
+	client.Close()
+
+	// 12.5 连接池 + JSON：连接按需建立，断开后自动重连
+	jsonListener, err := net.Listen("tcp", "localhost:12347")
 	if err != nil {
-		log.Print(err)
+		log.Fatal(err)
+	}
+	jsonSrv := tcpserver.New(newService(rpcx.Options{Codec: rpcx.JSON}), tcpserver.Options{IdleTimeout: 200 * time.Millisecond})
+	go jsonSrv.Serve(jsonListener)
+	pool := rpcx.NewPool("localhost:12347", 3, rpcx.Options{Codec: rpcx.JSON})
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rpcx.Call[DivRequest, DivReply](context.Background(), pool, "Calc.Div", DivRequest{i, 3})
+		}()
 	}
+	wg.Wait()
+	fmt.Printf("   连接池: %d个连接\n", pool.Conns())
+	time.Sleep(300 * time.Millisecond) // 服务端空闲超时关闭了所有连接
+	fmt.Printf("   空闲超时后: %d个连接\n", pool.Conns())
+	reply, err := rpcx.Call[DivRequest, DivReply](context.Background(), pool, "Calc.Div", DivRequest{17, 5})
+	fmt.Printf("   重连后调用: %+v %v, 服务端共接受连接%d个\n", reply, err, jsonSrv.Stats().Accepted)
+	pool.Close()
+
+	// 12.6 优雅关闭：处理中的调用完成后服务端才关闭连接
+	pool = rpcx.NewPool("localhost:12346", 1, rpcx.Options{Codec: rpcx.Gob})
+	result := make(chan string, 1)
+	go func() {
+		s, err := rpcx.Call[time.Duration, string](context.Background(), pool, "Calc.Slow", 100*time.Millisecond)
+		result <- fmt.Sprintf("%s %v", s, err)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	fmt.Printf("   Shutdown: %v, 处理中的调用: %s\n", srv.Shutdown(shutdownCtx), <-result)
+	jsonSrv.Shutdown(shutdownCtx)
+	pool.Close()
 }
 
 // 总结知识点:
@@ -147,4 +359,12 @@ func handleConnection(conn net.Conn) {
 // 8. TCP服务器 - Listen()监听, Accept()接受连接, goroutine处理并发
 // 9. TCP客户端 - Dial()建立连接, Write()发送, Read()接收
 // 10. 网络编程模式 - 每个连接独立goroutine处理, 非阻塞IO
+// 11. tcpserver - Handler接口, MaxConns信号量, 读/写/空闲超时, panic恢复, 连接指标
+// 12. 优雅关闭 - Shutdown停止Accept, 关闭空闲连接, 等处理中的消息完成; ctx超时后强制关闭
+// 13. 消息边界 - 一次Read可能只返回半条消息, 用bufio按行(或按帧)读取完整消息
+// 14. framing - 长度前缀(uint16/uint32, 大小端)、分隔符、定长、varint四种帧格式, 读取前检查MaxSize
+// 15. 分帧测试 - framing_test.go 的模糊测试: 随机分段往返、随机截断、任意字节输入, 验证不丢帧、不panic、不返回超长帧
+// 16. rpcx - 请求ID让多个并发调用共用一个连接, 响应乱序返回; Register/Call 按类型编解码
+// 17. RPC超时与错误 - ctx剩余时间随请求发送, 取消会通知服务端; *Error 携带错误码传回客户端
+// 18. 连接池 - 固定数量的多路复用连接轮流使用, 断开后按需重连; 调用不自动重试
 // 关键优势: Go的goroutine让网络编程简洁高效，轻松处理高并发连接
@@ -0,0 +1,210 @@
+// ============================= 5. 客户端 ====================
+// 一个 Client 对应一个连接，多个goroutine可以同时调用：
+// - 每个请求分配一个ID，读取goroutine按ID把响应交给对应的调用者，响应可以乱序到达
+// - ctx 的剩余时间随请求发送给服务端；ctx 结束时立即返回，并通知服务端取消
+// - 写入请求最多阻塞到 ctx 的截止时间，对方不读取时不会一直卡在写入上
+// - 连接断开后所有等待中的调用返回 ErrClosed，Client 不再可用，由 Pool 重新建立连接
+
+package rpcx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"Syntactic_Sugar/net/framing"
+)
+
+// Caller 可以发起调用的对象：*Client 或 *Pool
+type Caller interface {
+	call(ctx context.Context, method string, req, resp any) error
+}
+
+// Call 调用远程方法，服务端返回的错误为 *Error
+func Call[Req, Resp any](ctx context.Context, c Caller, method string, req Req) (Resp, error) {
+	var resp Resp
+	err := c.call(ctx, method, req, &resp)
+	return resp, err
+}
+
+// Client 单个连接上的RPC客户端，并发安全
+type Client struct {
+	codec Codec
+	conn  net.Conn
+	fc    *framing.Conn
+	wmu   sync.Mutex // 写截止时间是整个连接共用的，设置截止时间和写入要一起完成
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan *message // 关闭表示连接已断开
+	err     error                    // 非 nil 后不再接受调用
+
+	done chan struct{}
+}
+
+// cancelWriteTimeout 发送取消帧的最长时间
+const cancelWriteTimeout = time.Second
+
+// Dial 连接服务端
+func Dial(ctx context.Context, addr string, opts Options) (*Client, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn, opts), nil
+}
+
+// NewClient 在已建立的连接上创建客户端，Client 关闭时关闭 conn
+func NewClient(conn net.Conn, opts Options) *Client {
+	c := &Client{
+		codec:   opts.codec(),
+		conn:    conn,
+		fc:      framing.NewConn(conn, opts.framer()),
+		pending: make(map[uint64]chan *message),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+	return c
+}
+
+// Close 关闭连接，等待中的调用返回 ErrClosed
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = ErrClosed
+	}
+	c.mu.Unlock()
+	return c.conn.Close()
+}
+
+// Done 连接断开后关闭
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Client) readLoop() {
+	for {
+		frame, err := c.fc.ReadFrame()
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		m, err := parseMessage(frame)
+		if err != nil || (m.kind != kindResponse && m.kind != kindError) {
+			c.fail(errMalformed)
+			return
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[m.id]
+		delete(c.pending, m.id)
+		c.mu.Unlock()
+		if ok {
+			ch <- m // 已超时或取消的调用不在 pending 中，响应直接丢弃
+		}
+	}
+}
+
+// fail 连接断开：记录原因，唤醒所有等待中的调用
+func (c *Client) fail(err error) {
+	c.conn.Close()
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	for _, ch := range pending {
+		close(ch)
+	}
+	close(c.done)
+}
+
+func (c *Client) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Client) forget(id uint64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// write 写入一帧，deadline 非零时最多阻塞到 deadline
+// 除了帧太大（什么都没写入）以外，写入失败时可能写了半个帧，流已经错位，关闭连接
+func (c *Client) write(m *message, deadline time.Time) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if !deadline.IsZero() {
+		c.conn.SetWriteDeadline(deadline)
+		defer c.conn.SetWriteDeadline(time.Time{})
+	}
+	err := c.fc.WriteFrame(m.marshal())
+	if err != nil && !errors.Is(err, framing.ErrFrameTooLarge) {
+		c.conn.Close()
+	}
+	return err
+}
+
+func (c *Client) call(ctx context.Context, method string, req, resp any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	body, err := c.codec.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan *message, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	m := &message{kind: kindRequest, id: id, method: method, body: body}
+	deadline, ok := ctx.Deadline()
+	if ok {
+		m.timeout = max(time.Until(deadline), 1)
+	}
+	if err := c.write(m, deadline); err != nil {
+		c.forget(id)
+		if errors.Is(err, framing.ErrFrameTooLarge) {
+			return err // 什么都没写入，连接仍然可用
+		}
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			<-ctx.Done() // 写截止时间就是 ctx 的截止时间，ctx 的计时器可能稍晚触发
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+
+	select {
+	case r, ok := <-ch:
+		if !ok {
+			return c.closedErr()
+		}
+		if r.kind == kindError {
+			return &Error{Code: r.code, Message: r.text}
+		}
+		return c.codec.Unmarshal(r.body, resp)
+	case <-ctx.Done():
+		c.forget(id)
+		// 尽力通知服务端，失败也不影响结果；在后台写入，对方不读取时调用者也能立即返回
+		cancel := &message{kind: kindCancel, id: id}
+		go c.write(cancel, time.Now().Add(cancelWriteTimeout))
+		return ctx.Err()
+	}
+}
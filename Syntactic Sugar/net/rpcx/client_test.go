@@ -0,0 +1,270 @@
+package rpcx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+	"Syntactic_Sugar/net/framing"
+	"Syntactic_Sugar/net/tcpserver"
+)
+
+// testService 测试用的服务端，Slow 开始执行时把 ctx 是否有截止时间发送到 started，ctx 结束时发送到 canceled
+type testService struct {
+	started  chan bool
+	canceled chan struct{}
+
+	mu      sync.Mutex
+	arrived int
+	all     chan struct{} // 第 n 个 Gather 请求到达时关闭
+}
+
+// codecs 与编解码无关的测试对每种 Codec 各运行一次
+var codecs = []struct {
+	name  string
+	codec Codec
+}{
+	{"JSON", JSON},
+	{"Gob", Gob},
+}
+
+// startServer 在随机端口上启动服务，测试结束时关闭；codec 为 nil 时使用默认的 JSON
+func startServer(t *testing.T, codec Codec, opts tcpserver.Options, gather int) (string, *tcpserver.Server, *testService) {
+	t.Helper()
+	svc := &testService{
+		started:  make(chan bool, 1),
+		canceled: make(chan struct{}, 1),
+		all:      make(chan struct{}),
+	}
+	s := NewServer(Options{Codec: codec})
+	Register(s, "Echo", func(ctx context.Context, n int) (int, error) {
+		return n, nil
+	})
+	// Gather 等 gather 个请求都到达后才返回，请求如果被串行处理就永远等不到
+	Register(s, "Gather", func(ctx context.Context, n int) (int, error) {
+		svc.mu.Lock()
+		svc.arrived++
+		if svc.arrived == gather {
+			close(svc.all)
+		}
+		svc.mu.Unlock()
+		select {
+		case <-svc.all:
+			return n * 2, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	})
+	Register(s, "Slow", func(ctx context.Context, _ struct{}) (struct{}, error) {
+		_, ok := ctx.Deadline()
+		svc.started <- ok
+		<-ctx.Done()
+		svc.canceled <- struct{}{}
+		return struct{}{}, ctx.Err()
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := tcpserver.New(s, opts)
+	served := make(chan struct{})
+	go func() {
+		srv.Serve(l)
+		close(served)
+	}()
+	t.Cleanup(func() {
+		srv.Close()
+		<-served
+	})
+	return l.Addr().String(), srv, svc
+}
+
+func TestMultiplexing(t *testing.T) {
+	for _, tc := range codecs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(leakcheck.Verify(t, leakcheck.Options{Timeout: 2 * time.Second})) // 最先注册，在关闭服务端之后检查
+			const n = 50
+			addr, srv, _ := startServer(t, tc.codec, tcpserver.Options{}, n)
+			c, err := Dial(context.Background(), addr, Options{Codec: tc.codec})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			var wg sync.WaitGroup
+			for i := range n {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					got, err := Call[int, int](ctx, c, "Gather", i)
+					if err != nil || got != i*2 {
+						t.Errorf("Gather(%d) = %d, %v; want %d", i, got, err, i*2)
+					}
+				}()
+			}
+			wg.Wait()
+			if got := srv.Stats().Accepted; got != 1 {
+				t.Errorf("server accepted %d connections, want 1", got)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, tc := range codecs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(leakcheck.Verify(t, leakcheck.Options{Timeout: 2 * time.Second}))
+			addr, _, _ := startServer(t, tc.codec, tcpserver.Options{}, 1)
+			c, err := Dial(context.Background(), addr, Options{Codec: tc.codec})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			if _, err := Call[int, int](context.Background(), c, "Missing", 1); !errors.Is(err, ErrUnknownMethod) {
+				t.Errorf("unknown method: err = %v, want ErrUnknownMethod", err)
+			}
+			if _, err := Call[string, int](context.Background(), c, "Echo", "x"); !errors.Is(err, ErrBadRequest) {
+				t.Errorf("wrong request type: err = %v, want ErrBadRequest", err)
+			}
+			// 错误不影响连接上的其他调用
+			if got, err := Call[int, int](context.Background(), c, "Echo", 7); err != nil || got != 7 {
+				t.Errorf("Echo(7) = %d, %v", got, err)
+			}
+			c.Close()
+			if _, err := Call[int, int](context.Background(), c, "Echo", 7); !errors.Is(err, ErrClosed) {
+				t.Errorf("after Close: err = %v, want ErrClosed", err)
+			}
+		})
+	}
+}
+
+func TestCancellation(t *testing.T) {
+	cases := []struct {
+		name string
+		ctx  func() (context.Context, context.CancelFunc)
+		want error
+	}{
+		{"Cancel", func() (context.Context, context.CancelFunc) {
+			return context.WithCancel(context.Background())
+		}, context.Canceled},
+		// 超时随请求发送，服务端方法的ctx也有截止时间
+		{"Deadline", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 100*time.Millisecond)
+		}, context.DeadlineExceeded},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(leakcheck.Verify(t, leakcheck.Options{Timeout: 2 * time.Second}))
+			addr, _, svc := startServer(t, nil, tcpserver.Options{}, 1)
+			c, err := Dial(context.Background(), addr, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			ctx, cancel := tc.ctx()
+			defer cancel()
+			errc := make(chan error, 1)
+			go func() {
+				_, err := Call[struct{}, struct{}](ctx, c, "Slow", struct{}{})
+				errc <- err
+			}()
+			hasDeadline := <-svc.started
+			if hasDeadline != (tc.want == context.DeadlineExceeded) {
+				t.Errorf("server ctx has deadline: %t", hasDeadline)
+			}
+			if tc.want == context.Canceled {
+				cancel()
+			}
+			if err := <-errc; err != tc.want {
+				t.Errorf("Call err = %v, want %v", err, tc.want)
+			}
+			select {
+			case <-svc.canceled:
+			case <-time.After(2 * time.Second):
+				t.Fatal("server method was not canceled")
+			}
+			// 被取消的调用不影响连接
+			if got, err := Call[int, int](context.Background(), c, "Echo", 3); err != nil || got != 3 {
+				t.Errorf("Echo(3) after cancel = %d, %v", got, err)
+			}
+		})
+	}
+}
+
+// pipeClient 用 net.Pipe 连接客户端，net.Pipe 没有缓冲，对端不读取时写入一直阻塞
+func pipeClient(t *testing.T) (*Client, net.Conn) {
+	t.Helper()
+	cc, sc := net.Pipe()
+	c := NewClient(cc, Options{})
+	t.Cleanup(func() {
+		c.Close()
+		sc.Close()
+	})
+	return c, sc
+}
+
+func TestRequestWriteDeadline(t *testing.T) {
+	t.Cleanup(leakcheck.Verify(t, leakcheck.Options{Timeout: 2 * time.Second}))
+	c, _ := pipeClient(t) // 对端从不读取
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := Call[int, int](ctx, c, "Echo", 1)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Call err = %v, want context.DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Call returned after %v", d)
+	}
+	// 可能写了半个帧，连接必须关闭
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("client not closed after write timeout")
+	}
+}
+
+func TestCancelWriteDoesNotBlock(t *testing.T) {
+	t.Cleanup(leakcheck.Verify(t, leakcheck.Options{Timeout: 2 * cancelWriteTimeout}))
+	c, sc := pipeClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := Call[int, int](ctx, c, "Echo", 1)
+		errc <- err
+	}()
+	// 读走请求后不再读取，取消帧写不出去
+	frame, err := framing.NewConn(sc, Options{}.framer()).ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err := parseMessage(frame); err != nil || m.kind != kindRequest || m.method != "Echo" {
+		t.Fatalf("server read %+v, %v; want Echo request", m, err)
+	}
+	cancel()
+	select {
+	case err := <-errc:
+		if err != context.Canceled {
+			t.Fatalf("Call err = %v, want context.Canceled", err)
+		}
+	case <-time.After(cancelWriteTimeout / 2):
+		t.Fatal("Call blocked on writing the cancel frame")
+	}
+	// 取消帧写入超时后关闭连接
+	select {
+	case <-c.Done():
+	case <-time.After(3 * cancelWriteTimeout):
+		t.Fatal("client not closed after cancel write timeout")
+	}
+}
@@ -0,0 +1,42 @@
+// ============================= 1. 编解码 ====================
+// 请求和响应的载荷由 Codec 编码，客户端和服务器必须使用同一个 Codec：
+// - JSON：可读，跨语言，调试方便
+// - Gob：Go 专用，更紧凑；每条消息是独立的gob流，都带类型信息，不依赖连接上的状态
+
+package rpcx
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec 载荷编解码
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	JSON Codec = jsonCodec{}
+	Gob  Codec = gobCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
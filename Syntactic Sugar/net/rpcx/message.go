@@ -0,0 +1,119 @@
+// ============================= 2. 消息格式 ====================
+// 每条消息是一个 uint32 长度前缀帧，帧内是大端编码的头部和载荷：
+// - 请求：类型(1) | ID(8) | 剩余超时纳秒(8，0表示没有) | 方法名(2+n) | 载荷
+// - 响应：类型(1) | ID(8) | 载荷
+// - 错误：类型(1) | ID(8) | 错误码(2+n) | 错误信息(2+n)
+// - 取消：类型(1) | ID(8)
+// 请求ID让多个调用共用一个连接，响应可以乱序返回
+// 传递剩余时间而不是截止时刻，不依赖两台机器的时钟一致
+
+package rpcx
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+type msgKind byte
+
+const (
+	kindRequest msgKind = iota + 1
+	kindResponse
+	kindError
+	kindCancel
+)
+
+var errMalformed = errors.New("rpcx: malformed message")
+
+type message struct {
+	kind    msgKind
+	id      uint64
+	timeout time.Duration // 请求
+	method  string        // 请求
+	code    string        // 错误
+	text    string        // 错误
+	body    []byte        // 请求、响应
+}
+
+func appendString(b []byte, s string) []byte {
+	s = s[:min(len(s), 0xFFFF)]
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func (m *message) marshal() []byte {
+	b := make([]byte, 0, 1+8+8+2+len(m.method)+len(m.body))
+	b = append(b, byte(m.kind))
+	b = binary.BigEndian.AppendUint64(b, m.id)
+	switch m.kind {
+	case kindRequest:
+		b = binary.BigEndian.AppendUint64(b, uint64(m.timeout))
+		b = appendString(b, m.method)
+		b = append(b, m.body...)
+	case kindResponse:
+		b = append(b, m.body...)
+	case kindError:
+		b = appendString(b, m.code)
+		b = appendString(b, m.text)
+	}
+	return b
+}
+
+// reader 按顺序读取头部字段，越界时记录错误
+type reader struct {
+	b   []byte
+	err bool
+}
+
+func (r *reader) take(n int) []byte {
+	if r.err || len(r.b) < n {
+		r.err = true
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *reader) uint64() uint64 {
+	if v := r.take(8); v != nil {
+		return binary.BigEndian.Uint64(v)
+	}
+	return 0
+}
+
+func (r *reader) string() string {
+	v := r.take(2)
+	if v == nil {
+		return ""
+	}
+	return string(r.take(int(binary.BigEndian.Uint16(v))))
+}
+
+func parseMessage(b []byte) (*message, error) {
+	r := &reader{b: b}
+	kind := r.take(1)
+	if kind == nil {
+		return nil, errMalformed
+	}
+	m := &message{kind: msgKind(kind[0]), id: r.uint64()}
+	switch m.kind {
+	case kindRequest:
+		m.timeout = time.Duration(r.uint64())
+		m.method = r.string()
+		m.body = r.b
+	case kindResponse:
+		m.body = r.b
+	case kindError:
+		m.code = r.string()
+		m.text = r.string()
+	case kindCancel:
+	default:
+		return nil, errMalformed
+	}
+	if r.err || m.timeout < 0 {
+		return nil, errMalformed
+	}
+	return m, nil
+}
@@ -0,0 +1,99 @@
+// ============================= 6. 连接池 ====================
+// 每个连接都可以多路复用，连接池只是固定数量的连接轮流使用，而不是借出和归还：
+// - 连接在第一次使用时建立，断开后下一次使用时重新建立
+// - 多个连接分散单个连接的写入竞争和队头阻塞（一个大响应会挡住后面的响应）
+// - 调用失败不会自动重试，方法不一定是幂等的，由调用者决定
+
+package rpcx
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Pool 连接同一个服务端的客户端连接池，并发安全
+type Pool struct {
+	addr   string
+	opts   Options
+	slots  []poolSlot
+	next   atomic.Uint64
+	closed atomic.Bool
+}
+
+type poolSlot struct {
+	mu sync.Mutex
+	c  *Client
+}
+
+// NewPool 创建连接池，size <= 0 时为 4
+func NewPool(addr string, size int, opts Options) *Pool {
+	if size <= 0 {
+		size = 4
+	}
+	return &Pool{addr: addr, opts: opts, slots: make([]poolSlot, size)}
+}
+
+// client 轮流选择一个连接，不可用时重新建立
+func (p *Pool) client(ctx context.Context) (*Client, error) {
+	s := &p.slots[p.next.Add(1)%uint64(len(p.slots))]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p.closed.Load() {
+		return nil, ErrClosed
+	}
+	if s.c != nil {
+		select {
+		case <-s.c.Done():
+		default:
+			return s.c, nil
+		}
+	}
+	c, err := Dial(ctx, p.addr, p.opts)
+	if err != nil {
+		return nil, err
+	}
+	s.c = c
+	return c, nil
+}
+
+func (p *Pool) call(ctx context.Context, method string, req, resp any) error {
+	c, err := p.client(ctx)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, method, req, resp)
+}
+
+// Conns 当前已建立且可用的连接数
+func (p *Pool) Conns() int {
+	n := 0
+	for i := range p.slots {
+		s := &p.slots[i]
+		s.mu.Lock()
+		if s.c != nil {
+			select {
+			case <-s.c.Done():
+			default:
+				n++
+			}
+		}
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Close 关闭所有连接，之后的调用返回 ErrClosed
+func (p *Pool) Close() error {
+	p.closed.Store(true)
+	for i := range p.slots {
+		s := &p.slots[i]
+		s.mu.Lock()
+		if s.c != nil {
+			s.c.Close()
+			s.c = nil
+		}
+		s.mu.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package rpcx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+	"Syntactic_Sugar/net/tcpserver"
+)
+
+// waitFor 轮询直到 cond 成立，超时则失败
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPoolReconnect(t *testing.T) {
+	t.Cleanup(leakcheck.Verify(t, leakcheck.Options{Timeout: 2 * time.Second}))
+	// 服务端空闲超时关闭连接，模拟连接断开
+	addr, srv, _ := startServer(t, nil, tcpserver.Options{IdleTimeout: 100 * time.Millisecond}, 1)
+	p := NewPool(addr, 3, Options{})
+	defer p.Close()
+
+	// 连接在第一次使用时建立
+	if n := p.Conns(); n != 0 {
+		t.Fatalf("new pool has %d conns, want 0", n)
+	}
+	var wg sync.WaitGroup
+	for i := range 9 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := Call[int, int](context.Background(), p, "Echo", i); err != nil || got != i {
+				t.Errorf("Echo(%d) = %d, %v", i, got, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := p.Conns(); n != 3 {
+		t.Fatalf("after 9 calls: %d conns, want 3", n)
+	}
+
+	waitFor(t, "server to close idle conns", func() bool { return p.Conns() == 0 })
+	// 断开的连接在下一次使用时重新建立
+	for i := range 3 {
+		if got, err := Call[int, int](context.Background(), p, "Echo", i); err != nil || got != i {
+			t.Errorf("Echo(%d) after reconnect = %d, %v", i, got, err)
+		}
+	}
+	if got := srv.Stats().Accepted; got != 6 {
+		t.Errorf("server accepted %d conns, want 6", got)
+	}
+
+	p.Close()
+	if _, err := Call[int, int](context.Background(), p, "Echo", 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("after Close: err = %v, want ErrClosed", err)
+	}
+	if n := p.Conns(); n != 0 {
+		t.Errorf("after Close: %d conns, want 0", n)
+	}
+}
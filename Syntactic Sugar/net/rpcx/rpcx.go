@@ -0,0 +1,86 @@
+// ============================= 3. 错误和配置 ====================
+// 服务端方法返回的错误传回客户端后变成 *Error：
+// - 方法返回 *Error 时原样传递错误码和信息
+// - ctx 超时或取消分别对应 CodeDeadlineExceeded、CodeCanceled
+// - 其他错误和panic对应 CodeInternal
+// 客户端用 errors.Is(err, ErrUnknownMethod) 按错误码判断，用 errors.As 取出详细信息
+
+package rpcx
+
+import (
+	"context"
+	"errors"
+
+	"Syntactic_Sugar/net/framing"
+)
+
+// 内置错误码
+const (
+	CodeUnknownMethod    = "unknown_method"
+	CodeBadRequest       = "bad_request"
+	CodeInternal         = "internal"
+	CodeDeadlineExceeded = "deadline_exceeded"
+	CodeCanceled         = "canceled"
+)
+
+var (
+	// ErrUnknownMethod 服务端没有注册该方法
+	ErrUnknownMethod = &Error{Code: CodeUnknownMethod}
+	// ErrBadRequest 服务端无法解码请求
+	ErrBadRequest = &Error{Code: CodeBadRequest}
+	// ErrClosed 客户端或连接池已关闭，或连接已断开
+	ErrClosed = errors.New("rpcx: connection closed")
+	// ErrDuplicateMethod 方法名重复注册
+	ErrDuplicateMethod = errors.New("rpcx: duplicate method")
+)
+
+// Error 服务端返回的错误
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return "rpcx: " + e.Code
+	}
+	return "rpcx: " + e.Code + ": " + e.Message
+}
+
+// Is 没有 Message 的 *Error 作为哨兵，按错误码匹配
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Message == "" && t.Code == e.Code
+}
+
+// toError 把方法返回的错误转换为可以传输的 *Error
+func toError(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{Code: CodeDeadlineExceeded, Message: err.Error()}
+	case errors.Is(err, context.Canceled):
+		return &Error{Code: CodeCanceled, Message: err.Error()}
+	default:
+		return &Error{Code: CodeInternal, Message: err.Error()}
+	}
+}
+
+// Options 客户端和服务器共用的配置，两端必须一致
+type Options struct {
+	Codec        Codec // 默认 JSON
+	MaxFrameSize int   // 单条消息的最大字节数，默认 framing.DefaultMaxSize
+}
+
+func (o Options) codec() Codec {
+	if o.Codec == nil {
+		return JSON
+	}
+	return o.Codec
+}
+
+func (o Options) framer() framing.Framer {
+	return framing.LengthPrefix{Width: framing.Uint32, MaxSize: o.MaxFrameSize}
+}
@@ -0,0 +1,137 @@
+// ============================= 4. 服务端 ====================
+// Server 实现 tcpserver.Handler，由 tcpserver 负责连接数限制、超时和优雅关闭
+// - Register 按方法名注册类型化的处理函数
+// - 每个请求在自己的goroutine中处理，同一连接上的请求可以并发执行、乱序返回
+// - 请求携带的超时设置到方法的 ctx 上，客户端取消时方法的 ctx 也被取消
+// - 连接不再读取新请求后（客户端断开或服务器优雅关闭），等处理中的请求都返回再关闭连接
+
+package rpcx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"Syntactic_Sugar/net/framing"
+	"Syntactic_Sugar/net/tcpserver"
+)
+
+// handler 解码请求、调用方法、编码响应
+type handler func(ctx context.Context, body []byte) ([]byte, error)
+
+// Server RPC服务端，使用 NewServer 创建
+type Server struct {
+	opts Options
+
+	mu      sync.RWMutex
+	methods map[string]handler
+}
+
+// NewServer 创建服务端
+func NewServer(opts Options) *Server {
+	return &Server{opts: opts, methods: make(map[string]handler)}
+}
+
+// Register 注册方法，name 通常写成 "服务.方法"，例如 "Calc.Add"
+func Register[Req, Resp any](s *Server, name string, fn func(ctx context.Context, req Req) (Resp, error)) error {
+	codec := s.opts.codec()
+	h := func(ctx context.Context, body []byte) ([]byte, error) {
+		var req Req
+		if err := codec.Unmarshal(body, &req); err != nil {
+			return nil, &Error{Code: CodeBadRequest, Message: err.Error()}
+		}
+		resp, err := fn(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return codec.Marshal(resp)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.methods[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateMethod, name)
+	}
+	s.methods[name] = h
+	return nil
+}
+
+// ServeConn 处理一个连接上的所有请求
+func (s *Server) ServeConn(ctx context.Context, c *tcpserver.Conn) {
+	fc := framing.NewConn(c, s.opts.framer())
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		inflight = make(map[uint64]context.CancelFunc) // 用于客户端取消
+	)
+	defer wg.Wait()
+
+	for {
+		frame, err := fc.ReadFrame()
+		if err != nil {
+			return // 断开、超时、优雅关闭或帧格式错误
+		}
+		c.EndMessage()
+		m, err := parseMessage(frame)
+		if err != nil {
+			return // 协议错误，后面的数据已经不可信
+		}
+
+		switch m.kind {
+		case kindRequest:
+			callCtx, cancel := context.WithCancel(ctx)
+			if m.timeout > 0 {
+				callCtx, cancel = context.WithTimeout(ctx, m.timeout)
+			}
+			mu.Lock()
+			inflight[m.id] = cancel
+			mu.Unlock()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() {
+					mu.Lock()
+					delete(inflight, m.id)
+					mu.Unlock()
+					cancel()
+				}()
+				s.handle(callCtx, fc, m)
+			}()
+		case kindCancel:
+			mu.Lock()
+			if cancel, ok := inflight[m.id]; ok {
+				cancel()
+			}
+			mu.Unlock()
+		}
+	}
+}
+
+// handle 调用方法并写回响应，写入失败说明连接已断开，由读取循环结束连接
+func (s *Server) handle(ctx context.Context, fc *framing.Conn, m *message) {
+	body, err := s.invoke(ctx, m)
+	resp := &message{kind: kindResponse, id: m.id, body: body}
+	if err != nil {
+		e := toError(err)
+		resp = &message{kind: kindError, id: m.id, code: e.Code, text: e.Message}
+	}
+	fc.WriteFrame(resp.marshal())
+}
+
+// invoke 查找并调用方法，把panic转换为 CodeInternal 错误
+func (s *Server) invoke(ctx context.Context, m *message) (body []byte, err error) {
+	s.mu.RLock()
+	h, ok := s.methods[m.method]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, &Error{Code: CodeUnknownMethod, Message: m.method}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = &Error{Code: CodeInternal, Message: fmt.Sprintf("panic in %s: %v", m.method, r)}
+		}
+	}()
+	return h(ctx, m.body)
+}
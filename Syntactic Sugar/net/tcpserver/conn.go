@@ -0,0 +1,114 @@
+// ============================= 2. 连接 ====================
+// Conn 包装 net.Conn，在每次 Read/Write 前设置截止时间：
+// - 连接处于空闲状态（还没收到下一条消息）时，读取截止时间为 IdleTimeout
+// - 收到消息的第一个字节后进入消息中，截止时间为消息开始时刻 + ReadTimeout
+// - Handler 处理完一条消息后调用 EndMessage，连接回到空闲状态
+// 截止时间由 Conn 管理，Handler 不应再自己调用 SetReadDeadline/SetWriteDeadline
+
+package tcpserver
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Conn 服务器端的连接，同一时刻只能有一个goroutine读、一个goroutine写
+type Conn struct {
+	net.Conn
+	srv *Server
+
+	mu       sync.Mutex
+	idle     bool      // 等待下一条消息
+	reading  bool      // 有 Read 阻塞中
+	draining bool      // 服务器正在优雅关闭
+	msgStart time.Time // 当前消息第一个字节到达的时刻
+}
+
+func newConn(s *Server, raw net.Conn) *Conn {
+	return &Conn{Conn: raw, srv: s, idle: true}
+}
+
+// Read 读取数据；优雅关闭期间，空闲连接的 Read 返回 io.EOF
+func (c *Conn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	if c.idle && c.draining {
+		c.mu.Unlock()
+		return 0, io.EOF
+	}
+	c.Conn.SetReadDeadline(c.readDeadline())
+	c.reading = true
+	c.mu.Unlock()
+
+	n, err := c.Conn.Read(p)
+
+	c.mu.Lock()
+	c.reading = false
+	wasIdle := c.idle
+	if n > 0 && c.idle {
+		c.idle = false
+		c.msgStart = time.Now()
+	}
+	draining := c.draining
+	c.mu.Unlock()
+
+	c.srv.stats.bytesRead.Add(int64(n))
+	if err != nil && errors.Is(err, os.ErrDeadlineExceeded) {
+		if wasIdle && n == 0 && draining {
+			return 0, io.EOF // Shutdown 打断了空闲等待
+		}
+		c.srv.stats.timeouts.Add(1)
+	}
+	return n, err
+}
+
+// readDeadline 按连接状态计算读取截止时间，调用时持有 c.mu
+func (c *Conn) readDeadline() time.Time {
+	opts := c.srv.opts
+	if c.idle {
+		if opts.IdleTimeout > 0 {
+			return time.Now().Add(opts.IdleTimeout)
+		}
+		if opts.ReadTimeout > 0 {
+			return time.Now().Add(opts.ReadTimeout)
+		}
+		return time.Time{}
+	}
+	if opts.ReadTimeout > 0 {
+		return c.msgStart.Add(opts.ReadTimeout)
+	}
+	return time.Time{}
+}
+
+// Write 写入数据，每次调用都有 WriteTimeout 的截止时间
+func (c *Conn) Write(p []byte) (int, error) {
+	if d := c.srv.opts.WriteTimeout; d > 0 {
+		c.Conn.SetWriteDeadline(time.Now().Add(d))
+	}
+	n, err := c.Conn.Write(p)
+	c.srv.stats.bytesWritten.Add(int64(n))
+	if err != nil && errors.Is(err, os.ErrDeadlineExceeded) {
+		c.srv.stats.timeouts.Add(1)
+	}
+	return n, err
+}
+
+// EndMessage 标记当前消息处理完毕，连接回到空闲状态
+func (c *Conn) EndMessage() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.idle = true
+}
+
+// drain 进入优雅关闭：打断阻塞在空闲等待中的 Read
+func (c *Conn) drain() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.draining = true
+	if c.idle && c.reading {
+		c.Conn.SetReadDeadline(time.Now())
+	}
+}
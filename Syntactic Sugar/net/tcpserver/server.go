@@ -0,0 +1,275 @@
+// ============================= 1. TCP 服务器框架 ====================
+// 在 Listen + Accept + go handleConnection 的基础上补齐生产环境需要的部分：
+// - Handler 接口处理一个连接，和 http.Handler 一样可以用 HandlerFunc 适配普通函数
+// - MaxConns 信号量限制同时处理的连接数，满了以后暂停 Accept，由内核的backlog排队
+// - 读、写、空闲超时由 Conn 自动设置，慢客户端和僵死连接不会一直占用goroutine
+// - Shutdown 停止接受新连接，关闭空闲连接，等待处理中的消息完成后返回
+// - 每个连接的panic被恢复并记录，不会拖垮整个进程
+// - Stats 统计连接数和读写字节数
+
+package tcpserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrServerClosed Shutdown 或 Close 之后 Serve 返回的错误
+var ErrServerClosed = errors.New("tcpserver: server closed")
+
+// Handler 处理一个连接，返回后连接被关闭
+// ctx 在服务器强制关闭（Close 或 Shutdown 超时）时取消
+type Handler interface {
+	ServeConn(ctx context.Context, c *Conn)
+}
+
+// HandlerFunc 把函数转换为 Handler
+type HandlerFunc func(ctx context.Context, c *Conn)
+
+func (f HandlerFunc) ServeConn(ctx context.Context, c *Conn) {
+	f(ctx, c)
+}
+
+// Options 服务器配置，零值表示不限制
+type Options struct {
+	MaxConns int // 同时处理的最大连接数
+
+	// ReadTimeout 读取一条消息的最长时间，从消息的第一个字节开始计算
+	// IdleTimeout 等待下一条消息的最长时间，为 0 时使用 ReadTimeout
+	// 消息的边界由 Handler 调用 Conn.EndMessage 标记
+	ReadTimeout  time.Duration
+	IdleTimeout  time.Duration
+	WriteTimeout time.Duration // 每次 Write 的最长时间
+
+	ErrorLog *log.Logger // Accept 错误和panic的日志，默认 log.Default()
+}
+
+// Stats 服务器指标
+type Stats struct {
+	Accepted     int64 // 接受的连接总数
+	Active       int64 // 正在处理的连接数
+	Panics       int64 // Handler panic 次数
+	Timeouts     int64 // 读写超时次数
+	BytesRead    int64
+	BytesWritten int64
+}
+
+type counters struct {
+	accepted, active, panics, timeouts, bytesRead, bytesWritten atomic.Int64
+}
+
+// Server TCP服务器，使用 New 创建
+type Server struct {
+	handler Handler
+	opts    Options
+	logger  *log.Logger
+	sem     chan struct{} // MaxConns 信号量，不限制时为 nil
+
+	ctx    context.Context // 所有连接的ctx，强制关闭时取消
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*Conn]struct{}
+	closing   chan struct{} // Shutdown 或 Close 时关闭
+	closed    bool
+	wg        sync.WaitGroup // 处理中的连接
+
+	stats counters
+}
+
+// New 创建服务器
+func New(handler Handler, opts Options) *Server {
+	s := &Server{
+		handler:   handler,
+		opts:      opts,
+		logger:    opts.ErrorLog,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[*Conn]struct{}),
+		closing:   make(chan struct{}),
+	}
+	if s.logger == nil {
+		s.logger = log.Default()
+	}
+	if opts.MaxConns > 0 {
+		s.sem = make(chan struct{}, opts.MaxConns)
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s
+}
+
+// ListenAndServe 监听 addr 并调用 Serve
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve 在 l 上接受连接，每个连接一个goroutine；返回时关闭 l
+// 服务器关闭后返回 ErrServerClosed，其他情况返回 Accept 的错误
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, l)
+		s.mu.Unlock()
+		l.Close()
+	}()
+
+	var backoff time.Duration
+	for {
+		// 先占用名额再 Accept：满了以后新连接留在内核队列里，而不是接受后立刻关闭
+		if s.sem != nil {
+			select {
+			case s.sem <- struct{}{}:
+			case <-s.closing:
+				return ErrServerClosed
+			}
+		}
+
+		raw, err := l.Accept()
+		if err != nil {
+			s.release()
+			select {
+			case <-s.closing:
+				return ErrServerClosed
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			// 文件描述符耗尽等临时错误：退避后重试
+			backoff = min(max(backoff*2, 5*time.Millisecond), time.Second)
+			s.logger.Printf("tcpserver: accept error: %v; retrying in %v", err, backoff)
+			time.Sleep(backoff)
+			continue
+		}
+		backoff = 0
+
+		c := newConn(s, raw)
+		if !s.track(c) {
+			raw.Close()
+			s.release()
+			return ErrServerClosed
+		}
+		s.stats.accepted.Add(1)
+		s.stats.active.Add(1)
+		go s.serveConn(c)
+	}
+}
+
+// track 登记连接；服务器已关闭时返回 false
+func (s *Server) track(c *Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[c] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) release() {
+	if s.sem != nil {
+		<-s.sem
+	}
+}
+
+func (s *Server) serveConn(c *Conn) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.stats.panics.Add(1)
+			s.logger.Printf("tcpserver: panic serving %v: %v\n%s", c.RemoteAddr(), r, debug.Stack())
+		}
+		c.Conn.Close()
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		s.stats.active.Add(-1)
+		s.release()
+		s.wg.Done()
+	}()
+	s.handler.ServeConn(s.ctx, c)
+}
+
+// Shutdown 优雅关闭：停止接受新连接，关闭空闲连接，等待处理中的连接结束
+// 正在读取消息中途的连接会继续处理，读完当前消息后的下一次 Read 返回 io.EOF
+// ctx 结束时强制关闭剩余连接，返回 ctx.Err()
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closeLocked()
+	conns := make([]*Conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		c.drain()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.Close()
+		return ctx.Err()
+	}
+}
+
+// Close 立即关闭监听和所有连接，取消 Handler 的ctx
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closeLocked()
+	for c := range s.conns {
+		c.Conn.Close()
+	}
+	s.mu.Unlock()
+	s.cancel()
+	return nil
+}
+
+// closeLocked 标记关闭并关闭所有监听，可以重复调用
+func (s *Server) closeLocked() {
+	if !s.closed {
+		s.closed = true
+		close(s.closing)
+	}
+	for l := range s.listeners {
+		l.Close()
+	}
+}
+
+// Stats 当前指标
+func (s *Server) Stats() Stats {
+	return Stats{
+		Accepted:     s.stats.accepted.Load(),
+		Active:       s.stats.active.Load(),
+		Panics:       s.stats.panics.Load(),
+		Timeouts:     s.stats.timeouts.Load(),
+		BytesRead:    s.stats.bytesRead.Load(),
+		BytesWritten: s.stats.bytesWritten.Load(),
+	}
+}
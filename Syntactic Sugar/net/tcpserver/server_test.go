@@ -0,0 +1,317 @@
+package tcpserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+)
+
+// start 在随机端口上启动服务器，测试结束时关闭
+func start(t *testing.T, h HandlerFunc, opts Options) (*Server, string) {
+	t.Helper()
+	if opts.ErrorLog == nil {
+		opts.ErrorLog = log.New(io.Discard, "", 0)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New(h, opts)
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(l) }()
+	t.Cleanup(func() {
+		srv.Close()
+		if err := <-served; !errors.Is(err, ErrServerClosed) {
+			t.Errorf("Serve = %v; want ErrServerClosed", err)
+		}
+	})
+	return srv, l.Addr().String()
+}
+
+func dial(t *testing.T, addr string) net.Conn {
+	t.Helper()
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// waitFor 轮询直到 cond 成立
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// echoLines 按行回显，每行是一条消息；返回时把最后一次读取的错误发送到 errs
+func echoLines(errs chan<- error) HandlerFunc {
+	return func(ctx context.Context, c *Conn) {
+		r := bufio.NewReader(c)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				errs <- err
+				return
+			}
+			if _, err := io.WriteString(c, line); err != nil {
+				errs <- err
+				return
+			}
+			c.EndMessage()
+		}
+	}
+}
+
+func roundTrip(t *testing.T, c net.Conn, r *bufio.Reader, msg string) {
+	t.Helper()
+	io.WriteString(c, msg+"\n")
+	got, err := r.ReadString('\n')
+	if err != nil || got != msg+"\n" {
+		t.Fatalf("echo = %q, %v; want %q", got, err, msg)
+	}
+}
+
+// TestMaxConns 达到上限后不再 Accept，新连接留在内核队列里，有空位后才被处理
+func TestMaxConns(t *testing.T) {
+	t.Cleanup(leakcheck.Verify(t, leakcheck.Options{}))
+	var (
+		mu      sync.Mutex
+		started []string
+		release = make(chan struct{})
+	)
+	srv, addr := start(t, func(ctx context.Context, c *Conn) {
+		line, _ := bufio.NewReader(c).ReadString('\n')
+		mu.Lock()
+		started = append(started, strings.TrimSpace(line))
+		mu.Unlock()
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+	}, Options{MaxConns: 2})
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(started)
+	}
+
+	// 逐个连接，保证前两个先被处理
+	for i, name := range []string{"a", "b", "c"} {
+		io.WriteString(dial(t, addr), name+"\n")
+		if i < 2 {
+			waitFor(t, "connection "+name, func() bool { return count() == i+1 })
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n, st := count(), srv.Stats(); n != 2 || st.Accepted != 2 || st.Active != 2 {
+		t.Fatalf("handled %d, stats %+v; want 2 handled, Accepted 2, Active 2", n, st)
+	}
+
+	close(release)
+	waitFor(t, "third connection", func() bool { return count() == 3 })
+	waitFor(t, "all handlers to return", func() bool { return srv.Stats().Active == 0 })
+	if st := srv.Stats(); st.Accepted != 3 {
+		t.Fatalf("Accepted = %d; want 3", st.Accepted)
+	}
+}
+
+// TestShutdownIdle 空闲连接在 Shutdown 时 Read 返回 io.EOF，Shutdown 不必等到 IdleTimeout
+func TestShutdownIdle(t *testing.T) {
+	t.Cleanup(leakcheck.Verify(t, leakcheck.Options{}))
+	errs := make(chan error, 1)
+	srv, addr := start(t, echoLines(errs), Options{IdleTimeout: time.Minute})
+
+	c := dial(t, addr)
+	r := bufio.NewReader(c)
+	roundTrip(t, c, r, "hello")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+	if err := <-errs; err != io.EOF {
+		t.Fatalf("idle Read during Shutdown = %v; want io.EOF", err)
+	}
+	if _, err := r.ReadString('\n'); err != io.EOF {
+		t.Fatalf("client read = %v; want io.EOF", err)
+	}
+	if st := srv.Stats(); st.Timeouts != 0 || st.Active != 0 {
+		t.Fatalf("stats = %+v; want no timeouts, Active 0", st)
+	}
+}
+
+// TestShutdownDrainsInFlight 读到一半的消息继续处理完，下一次 Read 才返回 io.EOF
+func TestShutdownDrainsInFlight(t *testing.T) {
+	t.Cleanup(leakcheck.Verify(t, leakcheck.Options{}))
+	errs := make(chan error, 1)
+	srv, addr := start(t, echoLines(errs), Options{IdleTimeout: time.Minute, ReadTimeout: time.Minute})
+
+	c := dial(t, addr)
+	io.WriteString(c, "hel")
+	waitFor(t, "partial message", func() bool { return srv.Stats().BytesRead == 3 })
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- srv.Shutdown(context.Background()) }()
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned %v with a message in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	io.WriteString(c, "lo\n")
+	if got, err := bufio.NewReader(c).ReadString('\n'); err != nil || got != "hello\n" {
+		t.Fatalf("reply = %q, %v; want hello", got, err)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+	if err := <-errs; err != io.EOF {
+		t.Fatalf("Read after the message = %v; want io.EOF", err)
+	}
+}
+
+// TestShutdownTimeout ctx 结束时强制关闭：取消 Handler 的ctx、关闭连接，返回 ctx.Err()
+func TestShutdownTimeout(t *testing.T) {
+	t.Cleanup(leakcheck.Verify(t, leakcheck.Options{}))
+	entered := make(chan struct{})
+	canceled := make(chan struct{})
+	srv, addr := start(t, func(ctx context.Context, c *Conn) {
+		close(entered)
+		<-ctx.Done() // 不读取连接，Shutdown 无法打断
+		close(canceled)
+	}, Options{})
+
+	c := dial(t, addr)
+	<-entered
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v; want context.DeadlineExceeded", err)
+	}
+	<-canceled
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("client read after forced close = %v; want io.EOF", err)
+	}
+
+	// 关闭后不再接受连接
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Serve(l); !errors.Is(err, ErrServerClosed) {
+		t.Fatalf("Serve after Shutdown = %v; want ErrServerClosed", err)
+	}
+}
+
+// TestPanicRecovered 一个连接的 panic 被记录，连接被关闭，服务器继续处理其他连接
+func TestPanicRecovered(t *testing.T) {
+	t.Cleanup(leakcheck.Verify(t, leakcheck.Options{}))
+	var logs bytes.Buffer
+	srv, addr := start(t, func(ctx context.Context, c *Conn) {
+		r := bufio.NewReader(c)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if line == "panic\n" {
+				panic("boom")
+			}
+			io.WriteString(c, line)
+			c.EndMessage()
+		}
+	}, Options{ErrorLog: log.New(&logs, "", 0)})
+
+	bad := dial(t, addr)
+	io.WriteString(bad, "panic\n")
+	if _, err := bad.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("read from panicked connection = %v; want io.EOF", err)
+	}
+	waitFor(t, "panic to be counted", func() bool { return srv.Stats().Active == 0 })
+	if st := srv.Stats(); st.Panics != 1 {
+		t.Fatalf("Panics = %d; want 1", st.Panics)
+	}
+	if !strings.Contains(logs.String(), "panic serving") || !strings.Contains(logs.String(), "boom") {
+		t.Errorf("log = %q", logs.String())
+	}
+
+	good := dial(t, addr)
+	r := bufio.NewReader(good)
+	roundTrip(t, good, r, "still")
+	roundTrip(t, good, r, "serving")
+}
+
+// TestTimeouts 空闲、读、写超时都返回 os.ErrDeadlineExceeded 并计入 Stats.Timeouts
+func TestTimeouts(t *testing.T) {
+	t.Cleanup(leakcheck.Verify(t, leakcheck.Options{}))
+	chunk := make([]byte, 1<<20)
+	tests := []struct {
+		name string
+		opts Options
+		// client 向服务器发送的数据，之后不再读写
+		client string
+		handle func(c *Conn) error
+	}{
+		{"Idle", Options{IdleTimeout: 20 * time.Millisecond, ReadTimeout: time.Minute}, "", func(c *Conn) error {
+			_, err := c.Read(make([]byte, 1))
+			return err
+		}},
+		// 消息的第一个字节到达后按 ReadTimeout 计算，与 IdleTimeout 无关
+		{"Read", Options{IdleTimeout: time.Minute, ReadTimeout: 30 * time.Millisecond}, "partial", func(c *Conn) error {
+			_, err := bufio.NewReader(c).ReadString('\n')
+			return err
+		}},
+		// 只设置 ReadTimeout 时空闲等待也使用它
+		{"IdleFallback", Options{ReadTimeout: 20 * time.Millisecond}, "", func(c *Conn) error {
+			_, err := c.Read(make([]byte, 1))
+			return err
+		}},
+		// 客户端不读，发送缓冲区写满后 Write 超时
+		{"Write", Options{WriteTimeout: 30 * time.Millisecond}, "", func(c *Conn) error {
+			for {
+				if _, err := c.Write(chunk); err != nil {
+					return err
+				}
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := make(chan error, 1)
+			srv, addr := start(t, func(ctx context.Context, c *Conn) { errs <- tt.handle(c) }, tt.opts)
+			c := dial(t, addr)
+			io.WriteString(c, tt.client)
+
+			select {
+			case err := <-errs:
+				if !errors.Is(err, os.ErrDeadlineExceeded) {
+					t.Fatalf("handler error = %v; want os.ErrDeadlineExceeded", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout never fired")
+			}
+			if st := srv.Stats(); st.Timeouts != 1 {
+				t.Fatalf("Timeouts = %d; want 1", st.Timeouts)
+			}
+		})
+	}
+}
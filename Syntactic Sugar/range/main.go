@@ -9,6 +9,9 @@ import (
 	"iter"
 	"maps"
 	"slices"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
 )
 
 func Fibonaccii(n int) func(yield func(int) bool) {
@@ -94,6 +97,17 @@ func main() {
 		fmt.Println("拉取值:", value)
 	}
 
+	// 4.1 提前退出却忘记调用 stop，iter.Pull 内部的goroutine会一直挂起
+	snap := leakcheck.Take()
+	nextFib, stopFib := iter.Pull(Fibonacci(100))
+	first, _ := nextFib()
+	fmt.Println("只取第一个值:", first)
+	for _, g := range snap.Leaked(leakcheck.Options{Timeout: 50 * time.Millisecond}) {
+		fmt.Println("未调用stop, 泄漏:", g)
+	}
+	stopFib()
+	fmt.Println("调用stop后泄漏检查:", snap.Check(leakcheck.Options{}))
+
 	// ============================= 5. 错误处理迭代器 ====================
 	fmt.Println("\n=== 带错误处理的迭代器 ===")
 
@@ -218,6 +232,7 @@ func customIteratorDemo() {
 3. 拉取式迭代器 (特殊场景使用)
    - 调用者通过 next() 主动拉取数据
    - 需要手动调用 stop() 释放资源
+   - 忘记 stop() 会泄漏goroutine，可用 leakcheck 检测
    - 性能开销较大
 
 4. 标准库支持
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"iter"
+	"slices"
+	"testing"
+	"time"
+
+	"Syntactic_Sugar/concurrent1/leakcheck"
+)
+
+func TestFibonacci(t *testing.T) {
+	want := []int{0, 1, 1, 2, 3, 5, 8, 13}
+	if got := slices.Collect(Fibonacci(8)); !slices.Equal(got, want) {
+		t.Fatalf("Fibonacci(8) = %v; want %v", got, want)
+	}
+	if got := slices.Collect(Fibonaccii(8)); !slices.Equal(got, want) {
+		t.Fatalf("Fibonaccii(8) = %v; want %v", got, want)
+	}
+}
+
+func TestCustomIteratorFilter(t *testing.T) {
+	it := NewCustomIterator([]int{1, 2, 3, 4, 5, 6})
+	even := slices.Collect(it.Filter(func(v int) bool { return v%2 == 0 }))
+	if !slices.Equal(even, []int{2, 4, 6}) {
+		t.Fatalf("Filter = %v; want [2 4 6]", even)
+	}
+}
+
+// TestPullStop 提前退出时必须调用 stop，否则 iter.Pull 的goroutine一直挂起
+func TestPullStop(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+
+	snap := leakcheck.Take()
+	next, stop := iter.Pull(Fibonacci(100))
+	if v, ok := next(); !ok || v != 0 {
+		t.Fatalf("first value = %d, %t; want 0, true", v, ok)
+	}
+	if leaked := snap.Leaked(leakcheck.Options{Timeout: 20 * time.Millisecond}); len(leaked) == 0 {
+		t.Fatal("suspended iter.Pull goroutine not reported before stop")
+	}
+	stop()
+}
+
+func TestMainNoLeak(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.Options{})()
+	main()
+}
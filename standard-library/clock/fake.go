@@ -0,0 +1,321 @@
+// ============================= 2. 可控的假时钟 ====================
+// FakeClock 的时间只在 Advance/Set 时前进：
+// - 到期的计时器按到期时间顺序触发，Now() 在触发时等于该计时器的到期时间
+// - AfterFunc 的回调在调用 Advance 的goroutine中同步执行，结果可预测；
+//   d<=0 的 AfterFunc 不会另起goroutine，而是在下一次 Advance/Set（包括 Advance(0)）时执行
+// - Stop/Reset 会清掉管道中尚未读取的触发值，与 Go 1.23 起的 time.Timer 一致
+// - 计时器管道缓冲为1，消费者来不及读取时丢弃多余的触发（与 Ticker 相同）
+// - BlockUntil 等待被测代码注册好计时器后再推进时间，避免竞态
+// - WithTimeout 的截止时间只用假时间计算：父ctx的真实截止时间不参与比较，
+//   只有同一个假时钟创建的父ctx会让截止时间提前；父ctx结束时仍会传递取消
+
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FakeClock 测试用时钟，并发安全
+type FakeClock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond // 计时器数量变化时广播
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake 创建从 start 开始的假时钟；start 为零值时使用固定的 2024-01-01 00:00:00 UTC
+func NewFake(start time.Time) *FakeClock {
+	if start.IsZero() {
+		start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	f := &FakeClock{now: start}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now 当前的假时间
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since 距 t 经过的假时间
+func (f *FakeClock) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Sleep 阻塞直到假时间前进 d
+func (f *FakeClock) Sleep(d time.Duration) {
+	<-f.NewTimer(d).C()
+}
+
+// After 假时间前进 d 后收到当时的时间
+func (f *FakeClock) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer 创建一次性计时器，d<=0 时立即触发
+func (f *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scheduleLocked(t, d)
+	return t
+}
+
+// NewTicker 创建周期计时器，d 必须大于0
+func (f *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1), period: d}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scheduleLocked(t, d)
+	return fakeTicker{t}
+}
+
+// AfterFunc 假时间前进 d 后在 Advance 的goroutine中执行 fn；d<=0 时在下一次 Advance/Set 中执行
+func (f *FakeClock) AfterFunc(d time.Duration, fn func()) Timer {
+	t := &fakeTimer{clock: f, fn: fn}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scheduleLocked(t, d)
+	return t
+}
+
+// WithTimeout 返回在假时间前进 d 后以 context.DeadlineExceeded 结束的ctx
+func (f *FakeClock) WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	deadline := f.Now().Add(d)
+	if cur, ok := ctx.Value(fakeDeadlineKey{f}).(time.Time); ok && cur.Before(deadline) {
+		deadline = cur
+	}
+
+	c := &timeoutCtx{Context: ctx, clock: f, deadline: deadline, done: make(chan struct{})}
+	stopParent := context.AfterFunc(ctx, func() { c.cancel(ctx.Err()) })
+	if d <= 0 {
+		stopParent()
+		c.cancel(context.DeadlineExceeded)
+		return c, func() {}
+	}
+
+	timer := f.AfterFunc(d, func() {
+		stopParent()
+		c.cancel(context.DeadlineExceeded)
+	})
+	return c, func() {
+		timer.Stop()
+		stopParent()
+		c.cancel(context.Canceled)
+	}
+}
+
+// Advance 时间前进 d，途中到期的计时器依次触发
+func (f *FakeClock) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set 把时间设置为 t；t 早于当前时间时不触发任何计时器
+func (f *FakeClock) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for {
+		next := f.nextLocked(t)
+		if next == nil {
+			break
+		}
+		f.now = next.when
+		f.fireLocked(next)
+	}
+	if t.After(f.now) {
+		f.now = t
+	}
+}
+
+// Waiters 当前等待触发的计时器数量（包括 Sleep 和 After）
+func (f *FakeClock) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// BlockUntil 阻塞直到至少有 n 个等待触发的计时器
+func (f *FakeClock) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.timers) < n {
+		f.cond.Wait()
+	}
+}
+
+// nextLocked 最早在 until 之前到期的计时器
+func (f *FakeClock) nextLocked(until time.Time) *fakeTimer {
+	var next *fakeTimer
+	for _, t := range f.timers {
+		if t.when.After(until) {
+			continue
+		}
+		if next == nil || t.when.Before(next.when) {
+			next = t
+		}
+	}
+	return next
+}
+
+// fireLocked 触发计时器；执行回调期间会临时释放锁
+func (f *FakeClock) fireLocked(t *fakeTimer) {
+	if t.period > 0 {
+		t.when = t.when.Add(t.period)
+	} else {
+		f.removeLocked(t)
+	}
+
+	if t.c != nil {
+		select {
+		case t.c <- f.now:
+		default:
+		}
+	}
+	if t.fn != nil {
+		f.mu.Unlock()
+		t.fn()
+		f.mu.Lock()
+	}
+}
+
+func (f *FakeClock) scheduleLocked(t *fakeTimer, d time.Duration) {
+	if d <= 0 && t.period == 0 && t.c != nil {
+		// 立即到期：与 time.NewTimer(0) 一样马上可读
+		t.when = f.now
+		select {
+		case t.c <- f.now:
+		default:
+		}
+		return
+	}
+	// d<=0 的回调按已到期处理，留给下一次 Advance/Set 同步执行，
+	// 不能在这里持锁调用，也不能另起goroutine破坏确定性
+	t.when = f.now.Add(max(d, 0))
+	f.timers = append(f.timers, t)
+	f.cond.Broadcast()
+}
+
+// removeLocked 移除计时器，返回它是否仍在等待
+func (f *FakeClock) removeLocked(t *fakeTimer) bool {
+	for i, cur := range f.timers {
+		if cur == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			f.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// fakeTimer Timer 的实现，Ticker 由 fakeTicker 包装
+type fakeTimer struct {
+	clock  *FakeClock
+	when   time.Time
+	period time.Duration // >0 表示Ticker
+	c      chan time.Time
+	fn     func()
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+// Stop 停止计时器，返回它是否在等待中被停止（Timer 接口）
+// 已触发但未被读取的值会被清掉并视为被停止，之后从 C() 读取一定阻塞
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.removeLocked(t)
+	return t.drain() || active
+}
+
+// Reset 重新从当前假时间开始计时，先清掉尚未读取的旧触发值
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.removeLocked(t)
+	active = t.drain() || active
+	if t.period > 0 {
+		if d <= 0 {
+			panic("clock: non-positive interval for Ticker.Reset")
+		}
+		t.period = d
+	}
+	t.clock.scheduleLocked(t, d)
+	return active
+}
+
+// drain 取走管道中尚未读取的值，返回是否取到；调用方需持有时钟的锁
+func (t *fakeTimer) drain() bool {
+	select {
+	case <-t.c:
+		return true
+	default:
+		return false
+	}
+}
+
+// fakeTicker 适配 Ticker 接口（Stop 无返回值）
+type fakeTicker struct{ *fakeTimer }
+
+func (t fakeTicker) Stop()                 { t.fakeTimer.Stop() }
+func (t fakeTicker) Reset(d time.Duration) { t.fakeTimer.Reset(d) }
+
+// timeoutCtx 由假时钟控制截止时间的ctx
+// 自己维护 done 和 err，子ctx通过 Done() 感知取消并读取 Err()
+type timeoutCtx struct {
+	context.Context // 父ctx，提供 Value
+	clock           *FakeClock
+	deadline        time.Time
+	done            chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// fakeDeadlineKey 查询某个假时钟创建的最近一层 timeoutCtx 的截止时间
+type fakeDeadlineKey struct{ clock *FakeClock }
+
+func (c *timeoutCtx) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *timeoutCtx) Value(key any) any {
+	if k, ok := key.(fakeDeadlineKey); ok && k.clock == c.clock {
+		return c.deadline
+	}
+	return c.Context.Value(key)
+}
+
+func (c *timeoutCtx) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *timeoutCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *timeoutCtx) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
+
+var (
+	_ Clock  = (*FakeClock)(nil)
+	_ Timer  = (*fakeTimer)(nil)
+	_ Ticker = fakeTicker{}
+)
@@ -0,0 +1,214 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestFakeTimerStopDrains Stop 之后不能再读到已触发的旧值（Go 1.23 语义）
+func TestFakeTimerStopDrains(t *testing.T) {
+	f := NewFake(time.Time{})
+	timer := f.NewTimer(time.Second)
+	f.Advance(time.Second)
+
+	if !timer.Stop() {
+		t.Fatal("Stop on fired-but-unread timer = false; want true")
+	}
+	select {
+	case v := <-timer.C():
+		t.Fatalf("received stale value %v after Stop", v)
+	default:
+	}
+	if timer.Stop() {
+		t.Fatal("second Stop = true; want false")
+	}
+}
+
+func TestFakeTimerResetDrains(t *testing.T) {
+	f := NewFake(time.Time{})
+	start := f.Now()
+	timer := f.NewTimer(time.Second)
+	f.Advance(time.Second)
+
+	timer.Reset(2 * time.Second)
+	select {
+	case v := <-timer.C():
+		t.Fatalf("received stale value %v after Reset", v)
+	default:
+	}
+	f.Advance(2 * time.Second)
+	if v := <-timer.C(); !v.Equal(start.Add(3 * time.Second)) {
+		t.Fatalf("fired at %v; want %v", v, start.Add(3*time.Second))
+	}
+}
+
+func TestFakeTickerResetDrains(t *testing.T) {
+	f := NewFake(time.Time{})
+	ticker := f.NewTicker(time.Second)
+	f.Advance(time.Second)
+	ticker.Reset(time.Minute)
+	select {
+	case v := <-ticker.C():
+		t.Fatalf("received stale tick %v after Reset", v)
+	default:
+	}
+}
+
+// TestFakeAfterFuncNonPositive d<=0 的回调不另起goroutine，在下一次 Advance 中同步执行
+func TestFakeAfterFuncNonPositive(t *testing.T) {
+	f := NewFake(time.Time{})
+	ran := 0
+	f.AfterFunc(0, func() { ran++ })
+	f.AfterFunc(-time.Second, func() { ran++ })
+	if ran != 0 {
+		t.Fatalf("callbacks ran %d times before Advance", ran)
+	}
+
+	f.Advance(0)
+	if ran != 2 {
+		t.Fatalf("callbacks ran %d times after Advance(0); want 2", ran)
+	}
+
+	timer := f.AfterFunc(time.Hour, func() { ran++ })
+	timer.Reset(0)
+	f.Advance(0)
+	if ran != 3 {
+		t.Fatalf("Reset(0) callback ran %d times in total; want 3", ran)
+	}
+}
+
+func TestFakeNewTimerZeroFiresImmediately(t *testing.T) {
+	f := NewFake(time.Time{})
+	select {
+	case <-f.NewTimer(0).C():
+	default:
+		t.Fatal("NewTimer(0) not immediately readable")
+	}
+}
+
+type testKey struct{}
+
+func TestFakeWithTimeout(t *testing.T) {
+	f := NewFake(time.Time{})
+	start := f.Now()
+	parent := context.WithValue(context.Background(), testKey{}, "v")
+
+	ctx, cancel := f.WithTimeout(parent, time.Second)
+	defer cancel()
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(start.Add(time.Second)) {
+		t.Fatalf("Deadline = %v, %t; want %v", d, ok, start.Add(time.Second))
+	}
+	if v := ctx.Value(testKey{}); v != "v" {
+		t.Fatalf("Value = %v, want parent value", v)
+	}
+
+	f.Advance(time.Second - time.Nanosecond)
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("Err before deadline = %v", err)
+	}
+	f.Advance(time.Nanosecond)
+	select {
+	case <-ctx.Done():
+	default:
+		t.Fatal("ctx not done at deadline")
+	}
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Fatalf("Err = %v, want DeadlineExceeded", err)
+	}
+
+	// d<=0 立即结束
+	ctx, cancel = f.WithTimeout(context.Background(), 0)
+	defer cancel()
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Fatalf("WithTimeout(0) Err = %v", err)
+	}
+}
+
+func TestFakeWithTimeoutCancel(t *testing.T) {
+	f := NewFake(time.Time{})
+
+	// cancel 后计时器被移除
+	ctx, cancel := f.WithTimeout(context.Background(), time.Second)
+	cancel()
+	if err := ctx.Err(); err != context.Canceled || f.Waiters() != 0 {
+		t.Fatalf("after cancel: Err = %v, Waiters = %d", err, f.Waiters())
+	}
+
+	// 父ctx取消向下传递
+	parent, parentCancel := context.WithCancel(context.Background())
+	ctx, cancel = f.WithTimeout(parent, time.Hour)
+	defer cancel()
+	parentCancel()
+	<-ctx.Done()
+	if err := ctx.Err(); err != context.Canceled {
+		t.Fatalf("Err after parent cancel = %v, want Canceled", err)
+	}
+}
+
+// TestFakeWithTimeoutDeadlineSource 截止时间只按假时间计算
+func TestFakeWithTimeoutDeadlineSource(t *testing.T) {
+	// 假时间远在真实时间之后：父ctx的真实截止时间不能让假截止时间倒退
+	f := NewFake(time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC))
+	realCtx, realCancel := context.WithTimeout(context.Background(), time.Hour)
+	defer realCancel()
+	ctx, cancel := f.WithTimeout(realCtx, time.Minute)
+	defer cancel()
+	if d, _ := ctx.Deadline(); !d.Equal(f.Now().Add(time.Minute)) {
+		t.Errorf("Deadline with real-time parent = %v, want %v", d, f.Now().Add(time.Minute))
+	}
+
+	// 同一假时钟的父ctx截止时间更早：子ctx沿用父ctx的截止时间，并随父ctx一起超时
+	outer, outerCancel := f.WithTimeout(context.Background(), time.Second)
+	defer outerCancel()
+	inner, innerCancel := f.WithTimeout(context.WithValue(outer, testKey{}, 1), time.Hour)
+	defer innerCancel()
+	if d, _ := inner.Deadline(); !d.Equal(f.Now().Add(time.Second)) {
+		t.Errorf("nested Deadline = %v, want %v", d, f.Now().Add(time.Second))
+	}
+	f.Advance(time.Second)
+	<-inner.Done()
+	if err := inner.Err(); err != context.DeadlineExceeded {
+		t.Errorf("nested Err = %v, want DeadlineExceeded", err)
+	}
+
+	// 其他假时钟创建的父ctx不参与比较
+	other := NewFake(f.Now().Add(-time.Hour))
+	foreign, foreignCancel := other.WithTimeout(context.Background(), time.Second)
+	defer foreignCancel()
+	ctx, cancel = f.WithTimeout(foreign, time.Minute)
+	defer cancel()
+	if d, _ := ctx.Deadline(); !d.Equal(f.Now().Add(time.Minute)) {
+		t.Errorf("Deadline with other clock's parent = %v, want %v", d, f.Now().Add(time.Minute))
+	}
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	f := NewFake(time.Time{})
+	f.BlockUntil(0) // 不需要等待
+
+	done := make(chan struct{})
+	for range 2 {
+		go func() {
+			f.Sleep(time.Second)
+			done <- struct{}{}
+		}()
+	}
+
+	// 两个 Sleep 都注册好计时器后再推进时间，否则可能错过触发
+	f.BlockUntil(2)
+	if n := f.Waiters(); n != 2 {
+		t.Fatalf("Waiters = %d, want 2", n)
+	}
+	f.Advance(time.Second)
+	for range 2 {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Sleep did not return after Advance")
+		}
+	}
+	if n := f.Waiters(); n != 0 {
+		t.Errorf("Waiters after firing = %d, want 0", n)
+	}
+}
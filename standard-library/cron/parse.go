@@ -0,0 +1,339 @@
+// ============================= 1. Cron表达式解析 ====================
+// 支持的格式：
+// - 5段：分 时 日 月 周，例如 "30 9 * * MON-FRI"
+// - 6段：秒 分 时 日 月 周，例如 "*/10 * * * * *"
+// - 每段支持 *、?、数字、a-b 范围、/n 步长、逗号列表，月份和星期支持英文缩写
+// - 描述符：@yearly @monthly @weekly @daily @midnight @hourly，以及 @every 5m
+// - 前缀 CRON_TZ=Asia/Shanghai 指定时区
+// 日和周同时限定时，任一满足即可（与标准cron一致）
+// 夏令时：跳过的时刻顺延到跳变之后运行，重复的时刻只运行一次（小时段为 * 时除外，见 Next）
+
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 计算下一次运行时间
+type Schedule interface {
+	// Next 返回严格晚于 t 的下一次运行时间，找不到时返回零值
+	Next(t time.Time) time.Time
+}
+
+// field 每段的取值范围
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = field{name: "second", min: 0, max: 59}
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// Parse 解析表达式，未指定 CRON_TZ 时使用 time.Local
+func Parse(spec string) (Schedule, error) {
+	return ParseInLocation(spec, time.Local)
+}
+
+// ParseInLocation 解析表达式，未指定 CRON_TZ 时按 loc 计算时间
+func ParseInLocation(spec string, loc *time.Location) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if rest, ok := cutTZ(spec); ok {
+		name, expr, _ := strings.Cut(rest, " ")
+		tz, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("cron: bad time zone %q: %w", name, err)
+		}
+		loc, spec = tz, strings.TrimSpace(expr)
+	}
+
+	if every, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(every))
+		if err != nil {
+			return nil, fmt.Errorf("cron: bad @every duration %q: %w", every, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("cron: @every duration must be positive, got %v", d)
+		}
+		return Every(d), nil
+	}
+	if strings.HasPrefix(spec, "@") {
+		expr, ok := descriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("cron: unknown descriptor %q", spec)
+		}
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron: expected 5 or 6 fields, got %d in %q", len(fields), spec)
+	}
+
+	s := &cronSchedule{loc: loc}
+	var err error
+	if s.second, err = parseField(fields[0], secondField); err != nil {
+		return nil, err
+	}
+	if s.minute, err = parseField(fields[1], minuteField); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[2], hourField); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[3], domField); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[4], monthField); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[5], dowField); err != nil {
+		return nil, err
+	}
+	// 7 也表示周日
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = isAny(fields[3])
+	s.dowAny = isAny(fields[5])
+	return s, nil
+}
+
+func cutTZ(spec string) (string, bool) {
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if rest, ok := strings.CutPrefix(spec, prefix); ok {
+			return rest, true
+		}
+	}
+	return "", false
+}
+
+func isAny(expr string) bool {
+	return expr == "*" || expr == "?"
+}
+
+// parseField 把一段表达式解析为位图，第 i 位表示取值 i
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(expr, ",") {
+		b, err := parseRange(part, f)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+// parseRange 解析 *、a、a-b，以及可选的 /step
+func parseRange(expr string, f field) (uint64, error) {
+	rangeExpr, stepExpr, hasStep := strings.Cut(expr, "/")
+
+	lo, hi := f.min, f.max
+	switch {
+	case isAny(rangeExpr):
+	case strings.Contains(rangeExpr, "-"):
+		a, b, _ := strings.Cut(rangeExpr, "-")
+		var err error
+		if lo, err = parseValue(a, f); err != nil {
+			return 0, err
+		}
+		if hi, err = parseValue(b, f); err != nil {
+			return 0, err
+		}
+	default:
+		v, err := parseValue(rangeExpr, f)
+		if err != nil {
+			return 0, err
+		}
+		lo = v
+		hi = v
+		if hasStep {
+			hi = f.max // "5/15" 表示从5开始每15
+		}
+	}
+	if lo > hi {
+		return 0, fmt.Errorf("cron: bad %s range %q", f.name, expr)
+	}
+
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepExpr)
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("cron: bad %s step %q", f.name, expr)
+		}
+	}
+
+	var bits uint64
+	for v := lo; v <= hi; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("cron: bad %s value %q", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("cron: %s value %d out of range [%d, %d]", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// ============================= 2. 计算下一次运行时间 ====================
+
+// cronSchedule 各段取值的位图
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	domAny, dowAny                        bool
+	loc                                   *time.Location
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
+
+// allHours 小时段为 * 时的位图
+const allHours = 1<<24 - 1
+
+// Next 返回严格晚于 t 的下一次运行时间。夏令时的处理：
+//   - 小时段为 * 的表达式按绝对时间推进：跳过的小时没有运行，重复的小时运行两次
+//   - 其余表达式每个墙上时间最多运行一次：跳过的时刻顺延到跳变之后（02:30 → 03:30），
+//     重复出现的时刻只在第一次出现时运行
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	loc := s.loc
+	if loc == nil {
+		loc = t.Location()
+	}
+	t = t.In(loc)
+	if s.hour == allHours {
+		return s.match(t.Truncate(time.Second).Add(time.Second), loc)
+	}
+
+	// 在没有夏令时的 UTC 中按墙上时间匹配，再换算回 loc
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	for {
+		wall = s.match(wall.Add(time.Second), time.UTC)
+		if wall.IsZero() {
+			return wall
+		}
+		// 换算结果不晚于 t：t 位于重复的时段，该墙上时间已经运行过
+		if next := wallTime(wall, loc); next.After(t) {
+			return next
+		}
+	}
+}
+
+// match 从 t 开始逐级匹配：不满足的段直接跳到该段的下一个值
+func (s *cronSchedule) match(t time.Time, loc *time.Location) time.Time {
+	yearLimit := t.Year() + 5
+
+	for t.Year() <= yearLimit {
+		prev := t
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !has(s.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !has(s.minute, t.Minute()):
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		case !has(s.second, t.Second()):
+			t = t.Add(time.Second)
+		default:
+			return t
+		}
+		if !t.After(prev) {
+			// 夏令时回拨导致 time.Date 返回更早的时刻，按绝对时间前进
+			t = prev.Add(time.Hour).Truncate(time.Hour)
+		}
+	}
+	return time.Time{}
+}
+
+// wallTime 把墙上时间 w（以 UTC 表示）换算为 loc 中的时刻
+// time.Date 对跳过和重复的时刻不保证结果，这里分别用跳变前后的偏移换算：
+// 墙上时间一致的取最早的一个；都不一致说明落在跳过的区间，按跳变前的偏移换算即顺延
+func wallTime(w time.Time, loc *time.Location) time.Time {
+	guess := time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), 0, loc)
+
+	var first time.Time
+	for _, probe := range []time.Duration{-12 * time.Hour, 0, 12 * time.Hour} {
+		_, offset := guess.Add(probe).Zone()
+		t := w.Add(-time.Duration(offset) * time.Second).In(loc)
+		if sameWall(t, w) && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+	if !first.IsZero() {
+		return first
+	}
+	_, before := guess.Add(-12 * time.Hour).Zone()
+	return w.Add(-time.Duration(before) * time.Second).In(loc)
+}
+
+func sameWall(t, w time.Time) bool {
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := w.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2 &&
+		t.Hour() == w.Hour() && t.Minute() == w.Minute() && t.Second() == w.Second()
+}
+
+// dayMatches 日和周都限定时任一满足即可，否则两者都要满足
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domOK := has(s.dom, t.Day())
+	dowOK := has(s.dow, int(t.Weekday()))
+	if !s.domAny && !s.dowAny {
+		return domOK || dowOK
+	}
+	return domOK && dowOK
+}
+
+// ============================= 3. 固定间隔 ====================
+
+// Every 每隔 d 运行一次，从上一次运行时间开始计算
+func Every(d time.Duration) Schedule {
+	return everySchedule(d)
+}
+
+type everySchedule time.Duration
+
+func (e everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
@@ -0,0 +1,210 @@
+package cron
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s unavailable: %v", name, err)
+	}
+	return loc
+}
+
+// utc 构造 UTC 时间，用于表示重复时段中无法用墙上时间区分的时刻
+func utc(y int, m time.Month, d, h, min int) time.Time {
+	return time.Date(y, m, d, h, min, 0, 0, time.UTC)
+}
+
+// runs 从 from 开始连续调用 Next n 次
+func runs(s Schedule, from time.Time, n int) []time.Time {
+	var out []time.Time
+	for t := from; len(out) < n; {
+		t = s.Next(t)
+		if t.IsZero() {
+			break
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func checkRuns(t *testing.T, got, want []time.Time) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d runs %v, want %v", len(got), got, want)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("run %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		spec string
+		msg  string
+	}{
+		{"* * * *", "expected 5 or 6 fields"},
+		{"* * * * * * *", "expected 5 or 6 fields"},
+		{"60 * * * *", "minute value 60 out of range"},
+		{"* 24 * * *", "hour value 24 out of range"},
+		{"* * 0 * *", "day of month value 0 out of range"},
+		{"* * * 13 *", "month value 13 out of range"},
+		{"* * * * 8", "day of week value 8 out of range"},
+		{"* * * foo *", `bad month value "foo"`},
+		{"10-5 * * * *", `bad minute range "10-5"`},
+		{"*/0 * * * *", `bad minute step "*/0"`},
+		{"*/x * * * *", `bad minute step "*/x"`},
+		{"@fortnightly", "unknown descriptor"},
+		{"@every soon", "bad @every duration"},
+		{"@every -5s", "must be positive"},
+		{"CRON_TZ=Mars/Olympus 0 9 * * *", "bad time zone"},
+	}
+	for _, c := range cases {
+		_, err := ParseInLocation(c.spec, time.UTC)
+		if err == nil || !strings.Contains(err.Error(), c.msg) {
+			t.Errorf("Parse(%q) = %v, want error containing %q", c.spec, err, c.msg)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	cases := []struct {
+		spec string
+		from time.Time
+		want []time.Time
+	}{
+		// 5段：分 时 日 月 周；2024-01-05 是周五
+		{"30 9 * * MON-FRI", utc(2024, 1, 5, 10, 0), []time.Time{utc(2024, 1, 8, 9, 30), utc(2024, 1, 9, 9, 30)}},
+		// 6段带秒
+		{"*/20 * * * * *", utc(2024, 1, 1, 0, 0), []time.Time{
+			utc(2024, 1, 1, 0, 0).Add(20 * time.Second), utc(2024, 1, 1, 0, 0).Add(40 * time.Second), utc(2024, 1, 1, 0, 1),
+		}},
+		// 严格晚于 from：from 本身匹配时返回下一次
+		{"0 12 * * *", utc(2024, 1, 1, 12, 0), []time.Time{utc(2024, 1, 2, 12, 0)}},
+		// 范围加步长、单值加步长
+		{"0 8-18/5 * * *", utc(2024, 1, 1, 0, 0), []time.Time{utc(2024, 1, 1, 8, 0), utc(2024, 1, 1, 13, 0), utc(2024, 1, 1, 18, 0), utc(2024, 1, 2, 8, 0)}},
+		{"5/15 3 * * *", utc(2024, 1, 1, 0, 0), []time.Time{utc(2024, 1, 1, 3, 5), utc(2024, 1, 1, 3, 20), utc(2024, 1, 1, 3, 35), utc(2024, 1, 1, 3, 50)}},
+		// 月份名列表
+		{"0 0 1 jan,JUL *", utc(2024, 2, 1, 0, 0), []time.Time{utc(2024, 7, 1, 0, 0), utc(2025, 1, 1, 0, 0)}},
+		// 日和周都限定：任一满足即可；2024-10-13 是周日
+		{"0 0 13 * FRI", utc(2024, 10, 1, 0, 0), []time.Time{utc(2024, 10, 4, 0, 0), utc(2024, 10, 11, 0, 0), utc(2024, 10, 13, 0, 0), utc(2024, 10, 18, 0, 0)}},
+		// 只限定日：跳过没有31日的月份
+		{"0 0 31 * ?", utc(2024, 1, 1, 0, 0), []time.Time{utc(2024, 1, 31, 0, 0), utc(2024, 3, 31, 0, 0)}},
+		// 只限定周：7 与 0 都表示周日
+		{"0 0 * * 7", utc(2024, 1, 1, 0, 0), []time.Time{utc(2024, 1, 7, 0, 0), utc(2024, 1, 14, 0, 0)}},
+		// 闰日
+		{"0 0 29 2 *", utc(2024, 3, 1, 0, 0), []time.Time{utc(2028, 2, 29, 0, 0)}},
+		// 永远不会匹配：返回零值
+		{"0 0 30 2 *", utc(2024, 1, 1, 0, 0), nil},
+		// 描述符
+		{"@hourly", utc(2024, 1, 1, 0, 30), []time.Time{utc(2024, 1, 1, 1, 0), utc(2024, 1, 1, 2, 0)}},
+		{"@daily", utc(2024, 1, 1, 0, 30), []time.Time{utc(2024, 1, 2, 0, 0)}},
+		{"@midnight", utc(2024, 1, 1, 0, 30), []time.Time{utc(2024, 1, 2, 0, 0)}},
+		{"@weekly", utc(2024, 1, 1, 0, 0), []time.Time{utc(2024, 1, 7, 0, 0)}},
+		{"@monthly", utc(2024, 1, 15, 0, 0), []time.Time{utc(2024, 2, 1, 0, 0)}},
+		{"@YEARLY", utc(2024, 1, 15, 0, 0), []time.Time{utc(2025, 1, 1, 0, 0)}},
+		{"@annually", utc(2024, 1, 15, 0, 0), []time.Time{utc(2025, 1, 1, 0, 0)}},
+		{"@every 90s", utc(2024, 1, 1, 0, 0), []time.Time{utc(2024, 1, 1, 0, 0).Add(90 * time.Second), utc(2024, 1, 1, 0, 3)}},
+		// CRON_TZ 优先于 ParseInLocation 的 loc：东京 09:00 即 UTC 00:00
+		{"CRON_TZ=Asia/Tokyo 0 9 * * *", utc(2023, 12, 31, 23, 0), []time.Time{utc(2024, 1, 1, 0, 0), utc(2024, 1, 2, 0, 0)}},
+		{"TZ=Asia/Tokyo 0 9 * * *", utc(2023, 12, 31, 23, 0), []time.Time{utc(2024, 1, 1, 0, 0)}},
+	}
+	for _, c := range cases {
+		t.Run(c.spec, func(t *testing.T) {
+			s, err := ParseInLocation(c.spec, time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := len(c.want)
+			if n == 0 {
+				n = 1
+			}
+			checkRuns(t, runs(s, c.from, n), c.want)
+		})
+	}
+}
+
+func TestNextDST(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	sp := mustLoad(t, "America/Sao_Paulo")
+
+	cases := []struct {
+		name string
+		spec string
+		loc  *time.Location
+		from time.Time
+		want []time.Time
+	}{
+		// 2024-11-03 纽约 02:00 EDT 回拨到 01:00 EST，01:xx 出现两次：只在第一次运行
+		{"FallBackOnce", "30 1 * * *", ny, time.Date(2024, 11, 2, 12, 0, 0, 0, ny), []time.Time{
+			utc(2024, 11, 3, 5, 30), // 01:30 EDT
+			utc(2024, 11, 4, 6, 30), // 01:30 EST
+		}},
+		// 从重复时段的第二次出现开始计算：该墙上时间已经运行过
+		{"FallBackFromRepeat", "30 1 * * *", ny, utc(2024, 11, 3, 6, 10), []time.Time{utc(2024, 11, 4, 6, 30)}},
+		{"FallBackStepOnce", "*/30 1 * * *", ny, time.Date(2024, 11, 3, 0, 0, 0, 0, ny), []time.Time{
+			utc(2024, 11, 3, 5, 0), utc(2024, 11, 3, 5, 30), utc(2024, 11, 4, 6, 0),
+		}},
+		// 小时段为 *：按绝对时间，重复的小时运行两次
+		{"FallBackHourly", "0 * * * *", ny, time.Date(2024, 11, 3, 0, 30, 0, 0, ny), []time.Time{
+			utc(2024, 11, 3, 5, 0), // 01:00 EDT
+			utc(2024, 11, 3, 6, 0), // 01:00 EST
+			utc(2024, 11, 3, 7, 0), // 02:00 EST
+		}},
+		// 2024-03-10 纽约 02:00 EST 跳到 03:00 EDT：02:30 顺延到 03:30
+		{"SpringForwardShift", "30 2 * * *", ny, time.Date(2024, 3, 9, 12, 0, 0, 0, ny), []time.Time{
+			time.Date(2024, 3, 10, 3, 30, 0, 0, ny), time.Date(2024, 3, 11, 2, 30, 0, 0, ny),
+		}},
+		// 跳过时段顺延后与 03:30 重合，只运行一次
+		{"SpringForwardMerge", "30 2,3 * * *", ny, time.Date(2024, 3, 10, 0, 0, 0, 0, ny), []time.Time{
+			time.Date(2024, 3, 10, 3, 30, 0, 0, ny), time.Date(2024, 3, 11, 2, 30, 0, 0, ny),
+		}},
+		{"SpringForwardHourly", "0 * * * *", ny, time.Date(2024, 3, 10, 1, 30, 0, 0, ny), []time.Time{
+			time.Date(2024, 3, 10, 3, 0, 0, 0, ny), time.Date(2024, 3, 10, 4, 0, 0, 0, ny),
+		}},
+		// 2018-11-04 圣保罗 00:00 跳到 01:00：午夜任务顺延到 01:00
+		{"MidnightSkipped", "0 0 * * *", sp, time.Date(2018, 11, 3, 12, 0, 0, 0, sp), []time.Time{
+			time.Date(2018, 11, 4, 1, 0, 0, 0, sp), time.Date(2018, 11, 5, 0, 0, 0, 0, sp),
+		}},
+		// CRON_TZ 指定的时区同样适用
+		{"CronTZ", "CRON_TZ=America/New_York 30 1 * * *", time.UTC, utc(2024, 11, 3, 0, 0), []time.Time{
+			utc(2024, 11, 3, 5, 30), utc(2024, 11, 4, 6, 30),
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := ParseInLocation(c.spec, c.loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRuns(t, runs(s, c.from, len(c.want)), c.want)
+		})
+	}
+}
+
+func TestNextRuns(t *testing.T) {
+	s, _ := newTestScheduler(Options{}) // 2024-01-01 00:00 UTC，周一
+	noop := func(ctx context.Context) error { return nil }
+
+	id, err := s.AddFunc("0 9 * * MON-FRI", noop, JobOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRuns(t, s.NextRuns(id, 3), []time.Time{utc(2024, 1, 1, 9, 0), utc(2024, 1, 2, 9, 0), utc(2024, 1, 3, 9, 0)})
+
+	never, _ := s.AddFunc("0 0 30 2 *", noop, JobOptions{})
+	if got := s.NextRuns(never, 3); len(got) != 0 {
+		t.Errorf("NextRuns of impossible schedule = %v", got)
+	}
+	if got := s.NextRuns(EntryID(999), 3); got != nil {
+		t.Errorf("NextRuns of unknown entry = %v", got)
+	}
+}
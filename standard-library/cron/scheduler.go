@@ -0,0 +1,399 @@
+// ============================= 4. 定时任务调度器 ====================
+// 单个调度goroutine按最早的下次运行时间等待，到期后在独立goroutine中执行任务：
+// - 时间来源是 clock.Clock，测试时用 FakeClock 推进时间
+// - Overlap 决定上一次还没结束时的行为：并发运行、跳过或排队
+// - Jitter 给每次运行加上随机延迟，避免大量任务同一时刻触发
+// - 任务panic被恢复并通过 OnError 报告，不影响调度器
+// - 下一次运行从上一次的计划时间（不含抖动）推算，抖动不会累积
+// - 错过的运行不会补跑，时间跳跃后从当前时间重新计算
+// - Stop 停止调度并等待运行中的任务，超时则取消任务的ctx
+
+package cron
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"Standard_Library/clock"
+)
+
+// Overlap 上一次运行尚未结束时的处理策略
+type Overlap int
+
+const (
+	AllowOverlap  Overlap = iota // 直接并发运行
+	SkipIfRunning                // 跳过本次
+	Queue                        // 排队，上一次结束后立即运行
+)
+
+// Job 定时任务，ctx 在调度器停止超时时取消
+type Job func(ctx context.Context) error
+
+// JobOptions 单个任务的选项
+type JobOptions struct {
+	Name    string
+	Overlap Overlap
+	Jitter  time.Duration // 每次运行在计划时间后随机延迟 [0, Jitter)
+}
+
+// Options 调度器选项
+type Options struct {
+	Clock    clock.Clock    // 默认 clock.Real()
+	Location *time.Location // 表达式未指定 CRON_TZ 时使用，默认 time.Local
+	Rand     *rand.Rand     // 抖动的随机源，nil 时使用全局随机数
+	OnError  func(name string, err error)
+}
+
+// PanicError 任务panic被恢复后转换成的错误
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("cron: job panic: %v\n%s", e.Value, e.Stack)
+}
+
+// EntryID 任务标识
+type EntryID int
+
+// Entry 任务的调度信息快照
+type Entry struct {
+	ID      EntryID
+	Name    string
+	Spec    string
+	Prev    time.Time // 上一次计划运行时间
+	Next    time.Time // 下一次计划运行时间（已包含抖动）
+	Runs    int       // 已启动的运行次数
+	Skipped int       // 因 SkipIfRunning 跳过的次数
+}
+
+type entry struct {
+	Entry
+	scheduled time.Time // Next 去掉抖动后的计划时间
+	schedule  Schedule
+	job       Job
+	opts      JobOptions
+	running   int
+	queued    int
+}
+
+// Scheduler 定时任务调度器
+type Scheduler struct {
+	clock clock.Clock
+	opts  Options
+
+	mu      sync.Mutex
+	entries map[EntryID]*entry
+	nextID  EntryID
+	started bool
+	stopped bool
+
+	wake     chan struct{}
+	stop     chan struct{}
+	loopDone chan struct{}
+	jobs     sync.WaitGroup
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New 创建调度器，调用 Start 后开始运行
+func New(opts Options) *Scheduler {
+	if opts.Clock == nil {
+		opts.Clock = clock.Real()
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		clock:    opts.Clock,
+		opts:     opts,
+		entries:  make(map[EntryID]*entry),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		loopDone: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// AddFunc 按表达式添加任务
+func (s *Scheduler) AddFunc(spec string, job Job, opts JobOptions) (EntryID, error) {
+	schedule, err := ParseInLocation(spec, s.opts.Location)
+	if err != nil {
+		return 0, err
+	}
+	return s.add(spec, schedule, job, opts), nil
+}
+
+// AddSchedule 按自定义 Schedule 添加任务
+func (s *Scheduler) AddSchedule(schedule Schedule, job Job, opts JobOptions) EntryID {
+	return s.add("", schedule, job, opts)
+}
+
+func (s *Scheduler) add(spec string, schedule Schedule, job Job, opts JobOptions) EntryID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	e := &entry{
+		Entry:    Entry{ID: s.nextID, Name: opts.Name, Spec: spec},
+		schedule: schedule,
+		job:      job,
+		opts:     opts,
+	}
+	if e.Name == "" {
+		e.Name = spec
+	}
+	e.Next = s.nextLocked(e, s.clock.Now())
+	s.entries[e.ID] = e
+	s.notify()
+	return e.ID
+}
+
+// Remove 删除任务，正在运行的那一次不受影响
+func (s *Scheduler) Remove(id EntryID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, id)
+	s.notify()
+}
+
+// Entries 按下一次运行时间排序的任务列表
+func (s *Scheduler) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		list = append(list, e.Entry)
+	}
+	slices.SortFunc(list, func(a, b Entry) int {
+		if c := compareNext(a.Next, b.Next); c != 0 {
+			return c
+		}
+		return int(a.ID - b.ID)
+	})
+	return list
+}
+
+// NextRuns 任务接下来 n 次的计划运行时间（不含抖动）
+func (s *Scheduler) NextRuns(id EntryID, n int) []time.Time {
+	s.mu.Lock()
+	e, ok := s.entries[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	runs := make([]time.Time, 0, n)
+	t := s.clock.Now()
+	for range n {
+		t = e.schedule.Next(t)
+		if t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+	return runs
+}
+
+// Start 启动调度goroutine，重复调用无效
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+	// 启动前添加的任务已经在 run 的第一次计算中，丢弃它们产生的唤醒信号
+	select {
+	case <-s.wake:
+	default:
+	}
+	go s.run()
+}
+
+// Stop 停止调度并等待运行中的任务结束
+// ctx 结束时取消任务的ctx并返回 ctx.Err()，排队中的运行会被丢弃
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return nil
+	}
+	s.stopped = true
+	started := s.started
+	close(s.stop)
+	s.mu.Unlock()
+
+	if started {
+		<-s.loopDone
+	}
+	defer s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// run 调度循环：等待最早到期的任务，或者任务列表变化，或者停止
+func (s *Scheduler) run() {
+	defer close(s.loopDone)
+
+	for {
+		s.mu.Lock()
+		wait := time.Duration(1<<63 - 1)
+		if next, ok := s.earliestLocked(); ok {
+			wait = max(next.Sub(s.clock.Now()), 0)
+		}
+		s.mu.Unlock()
+
+		timer := s.clock.NewTimer(wait)
+		select {
+		case <-timer.C():
+			s.dispatchDue()
+		case <-s.wake:
+			timer.Stop()
+		case <-s.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (s *Scheduler) earliestLocked() (time.Time, bool) {
+	var next time.Time
+	for _, e := range s.entries {
+		if e.Next.IsZero() {
+			continue
+		}
+		if next.IsZero() || e.Next.Before(next) {
+			next = e.Next
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// dispatchDue 启动所有已到期的任务并计算它们的下一次运行时间
+func (s *Scheduler) dispatchDue() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	for _, e := range s.entries {
+		if e.Next.IsZero() || e.Next.After(now) {
+			continue
+		}
+		e.Prev = e.scheduled
+		e.Next = s.nextLocked(e, now)
+
+		switch {
+		case e.running == 0 || e.opts.Overlap == AllowOverlap:
+			s.startLocked(e)
+		case e.opts.Overlap == SkipIfRunning:
+			e.Skipped++
+		case e.opts.Overlap == Queue:
+			e.queued++
+		}
+	}
+}
+
+// nextLocked 从上一次计划时间推算下一次运行时间，记入 e.scheduled 后加上随机抖动
+// 推算结果已经过去（首次计算、时间跳跃或抖动超过间隔）时从 now 重新计算，错过的运行不补跑
+func (s *Scheduler) nextLocked(e *entry, now time.Time) time.Time {
+	var next time.Time
+	if !e.scheduled.IsZero() {
+		next = e.schedule.Next(e.scheduled)
+	}
+	if next.IsZero() || !next.After(now) {
+		next = e.schedule.Next(now)
+	}
+	e.scheduled = next
+	if next.IsZero() || e.opts.Jitter <= 0 {
+		return next
+	}
+	var jitter int64
+	if s.opts.Rand != nil {
+		jitter = s.opts.Rand.Int64N(int64(e.opts.Jitter))
+	} else {
+		jitter = rand.Int64N(int64(e.opts.Jitter))
+	}
+	return next.Add(time.Duration(jitter))
+}
+
+// startLocked 在新goroutine中运行任务；Queue 策略下结束后继续运行排队的次数
+func (s *Scheduler) startLocked(e *entry) {
+	e.running++
+	e.Runs++
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		for {
+			s.call(e)
+
+			s.mu.Lock()
+			if e.queued == 0 || s.stopped {
+				e.queued = 0
+				e.running--
+				s.mu.Unlock()
+				return
+			}
+			e.queued--
+			e.Runs++
+			s.mu.Unlock()
+		}
+	}()
+}
+
+// call 执行任务，错误和panic交给 OnError
+func (s *Scheduler) call(e *entry) {
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+		return e.job(s.ctx)
+	}()
+	if err != nil && s.opts.OnError != nil {
+		s.opts.OnError(e.Name, err)
+	}
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// compareNext 零值（不再运行）排在最后
+func compareNext(a, b time.Time) int {
+	switch {
+	case a.IsZero() && b.IsZero():
+		return 0
+	case a.IsZero():
+		return 1
+	case b.IsZero():
+		return -1
+	}
+	return a.Compare(b)
+}
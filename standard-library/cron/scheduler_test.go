@@ -0,0 +1,223 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Standard_Library/clock"
+)
+
+func newTestScheduler(opts Options) (*Scheduler, *clock.FakeClock) {
+	fake := clock.NewFake(time.Time{})
+	opts.Clock = fake
+	opts.Location = time.UTC
+	return New(opts), fake
+}
+
+// tick 等调度器注册好计时器后推进时间，并等它处理完到期任务、重新开始等待
+func tick(fake *clock.FakeClock, d time.Duration) {
+	fake.BlockUntil(1)
+	fake.Advance(d)
+	fake.BlockUntil(1)
+}
+
+func stop(t *testing.T, s *Scheduler) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	tests := []struct {
+		overlap     Overlap
+		runs        int
+		skipped     int
+		concurrency int64
+	}{
+		{AllowOverlap, 3, 0, 3},
+		{SkipIfRunning, 1, 2, 1},
+		{Queue, 3, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run([]string{"Allow", "Skip", "Queue"}[tt.overlap], func(t *testing.T) {
+			s, fake := newTestScheduler(Options{})
+			var active, peak atomic.Int64
+			release := make(chan struct{})
+			started := make(chan struct{}, 10)
+			done := make(chan struct{}, 10)
+			id, err := s.AddFunc("@every 10s", func(ctx context.Context) error {
+				n := active.Add(1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+				started <- struct{}{}
+				<-release
+				active.Add(-1)
+				done <- struct{}{}
+				return nil
+			}, JobOptions{Overlap: tt.overlap})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s.Start()
+			for range 3 {
+				tick(fake, 10*time.Second)
+			}
+			// 等所有应当并发的运行都已开始，再放行
+			for range tt.concurrency {
+				<-started
+			}
+			close(release)
+			for range tt.runs {
+				<-done
+			}
+			stop(t, s)
+
+			e := s.Entries()[0]
+			if e.ID != id || e.Runs != tt.runs || e.Skipped != tt.skipped {
+				t.Fatalf("Runs=%d Skipped=%d; want %d, %d", e.Runs, e.Skipped, tt.runs, tt.skipped)
+			}
+			if p := peak.Load(); p != tt.concurrency {
+				t.Fatalf("peak concurrency = %d; want %d", p, tt.concurrency)
+			}
+		})
+	}
+}
+
+// TestPanicRecovered 任务panic转换为 *PanicError 交给 OnError，调度继续
+func TestPanicRecovered(t *testing.T) {
+	errs := make(chan error, 2)
+	s, fake := newTestScheduler(Options{
+		OnError: func(name string, err error) { errs <- err },
+	})
+	s.AddFunc("@every 1m", func(ctx context.Context) error {
+		panic("boom")
+	}, JobOptions{Name: "panicky"})
+
+	s.Start()
+	for range 2 {
+		tick(fake, time.Minute)
+		var panicErr *PanicError
+		if err := <-errs; !errors.As(err, &panicErr) || panicErr.Value != "boom" {
+			t.Fatalf("OnError got %v; want PanicError(boom)", err)
+		}
+	}
+	stop(t, s)
+	if runs := s.Entries()[0].Runs; runs != 2 {
+		t.Fatalf("Runs = %d; want 2", runs)
+	}
+}
+
+// TestStopCancelsJobs Stop 超时后取消任务ctx，之后不再调度
+func TestStopCancelsJobs(t *testing.T) {
+	s, fake := newTestScheduler(Options{})
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	s.AddFunc("@every 1s", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	}, JobOptions{Overlap: SkipIfRunning})
+
+	s.Start()
+	tick(fake, time.Second)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop = %v; want DeadlineExceeded", err)
+	}
+	select {
+	case <-canceled:
+	default:
+		t.Fatal("Stop returned before the job observed cancellation")
+	}
+
+	if n := fake.Waiters(); n != 0 {
+		t.Fatalf("scheduler still has %d timers after Stop", n)
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("second Stop = %v; want nil", err)
+	}
+}
+
+// TestStopWaitsForJobs Stop 等待运行中的任务正常结束，排队的运行被丢弃
+func TestStopWaitsForJobs(t *testing.T) {
+	s, fake := newTestScheduler(Options{})
+	release := make(chan struct{})
+	var finished atomic.Int32
+	s.AddFunc("@every 1s", func(ctx context.Context) error {
+		<-release
+		finished.Add(1)
+		return nil
+	}, JobOptions{Overlap: Queue})
+
+	s.Start()
+	tick(fake, time.Second)
+	tick(fake, time.Second)
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.Stop(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if err := <-stopped; err != nil {
+		t.Fatal(err)
+	}
+	if n := finished.Load(); n != 1 {
+		t.Fatalf("finished %d runs; want 1 (queued run dropped)", n)
+	}
+}
+
+// TestJitterDoesNotDrift 抖动只影响实际触发时间，计划时间仍按原间隔推进
+func TestJitterDoesNotDrift(t *testing.T) {
+	s, fake := newTestScheduler(Options{Rand: rand.New(rand.NewPCG(1, 2))})
+	start := fake.Now()
+	s.AddSchedule(Every(time.Minute), func(ctx context.Context) error {
+		return nil
+	}, JobOptions{Jitter: 30 * time.Second})
+
+	s.Start()
+	for k := 1; k <= 20; k++ {
+		next := s.Entries()[0].Next
+		fake.BlockUntil(1)
+		fake.Set(next)
+		fake.BlockUntil(1)
+
+		e := s.Entries()[0]
+		want := start.Add(time.Duration(k) * time.Minute)
+		if !e.Prev.Equal(want) {
+			t.Fatalf("run %d: Prev = %v; want %v", k, e.Prev, want)
+		}
+		if lo, hi := want.Add(time.Minute), want.Add(90*time.Second); e.Next.Before(lo) || !e.Next.Before(hi) {
+			t.Fatalf("run %d: Next = %v; want in [%v, %v)", k, e.Next, lo, hi)
+		}
+	}
+	stop(t, s)
+}
+
+// TestTimeJumpSkipsMissedRuns 时间跳跃后从当前时间重新计算，不补跑
+func TestTimeJumpSkipsMissedRuns(t *testing.T) {
+	s, fake := newTestScheduler(Options{})
+	s.AddFunc("@every 1m", func(ctx context.Context) error { return nil }, JobOptions{})
+
+	s.Start()
+	tick(fake, time.Hour)
+	stop(t, s)
+
+	e := s.Entries()[0]
+	if e.Runs != 1 {
+		t.Fatalf("Runs = %d after a one-hour jump; want 1", e.Runs)
+	}
+	if want := fake.Now().Add(time.Minute); !e.Next.Equal(want) {
+		t.Fatalf("Next = %v; want %v", e.Next, want)
+	}
+}
@@ -1,3 +1,5 @@
 module Standard_Library
 
 go 1.24
+
+require gopkg.in/yaml.v3 v3.0.1
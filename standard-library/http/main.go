@@ -8,7 +8,11 @@ import (
 	"log"
 	"net/http"
 	"net/http/httputil"
+	"os"
 	"time"
+
+	"Standard_Library/middleware"
+	"Standard_Library/ratelimit"
 )
 
 // ============================= 数据结构定义 =============================
@@ -142,13 +146,28 @@ func startCustomServer() {
 		IdleTimeout:  30 * time.Second, // 空闲连接超时
 	}
 
+	// 所有路由共用的中间件：panic恢复 → 访问日志 → 5秒超时
+	logger := log.New(os.Stdout, "[HTTP] ", log.LstdFlags)
+	common := middleware.Chain(
+		middleware.HTTPRecover(logger),
+		middleware.HTTPLogging(logger),
+		middleware.HTTPTimeout(5*time.Second),
+	)
+
 	// 注册路由
-	http.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	http.Handle("/", common.Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "欢迎访问首页!\n路径: %s", r.URL.Path)
-	}))
-	http.Handle("/hello", http.HandlerFunc(helloHandler))
-	http.Handle("/user", http.HandlerFunc(userHandler))
-	http.Handle("/custom", &CustomHandler{})
+	})))
+	http.Handle("/hello", common.Then(http.HandlerFunc(helloHandler)))
+	// 每个客户端IP每秒5个请求，允许10个突发；空闲10分钟的IP自动清理
+	userLimiter := ratelimit.NewKeyed(func() ratelimit.Limiter {
+		return ratelimit.NewTokenBucket(5, 10)
+	}, 10*time.Minute)
+	limitByIP := func(next http.Handler) http.Handler {
+		return ratelimit.KeyedMiddleware(userLimiter, ratelimit.ClientIP, next)
+	}
+	http.Handle("/user", common.Append(limitByIP).Then(http.HandlerFunc(userHandler)))
+	http.Handle("/custom", common.Then(&CustomHandler{}))
 
 	fmt.Println("自定义服务器运行在 http://localhost:8080")
 	fmt.Println("可用路由:")
@@ -186,7 +205,12 @@ func reverseProxyHandler(w http.ResponseWriter, r *http.Request) {
 
 // 启动反向代理服务器
 func startProxyServer() {
-	http.HandleFunc("/forward", reverseProxyHandler)
+	// 上游 httpbin.org 整体限制为每分钟60个请求（滑动窗口）
+	proxyLimiter, err := ratelimit.NewSlidingWindow(60, time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+	http.Handle("/forward", ratelimit.Middleware(proxyLimiter, http.HandlerFunc(reverseProxyHandler)))
 
 	fmt.Println("\n反向代理服务器运行在 http://localhost:8081")
 	fmt.Println("访问 http://localhost:8081/forward 将代理到 https://httpbin.org/get")
@@ -241,6 +265,16 @@ HTTP 服务端:
 ✅ httputil.ReverseProxy: 内置反向代理功能
 ✅ Director函数: 用于修改转发的请求(URL、Header等)
 
+限流保护:
+✅ ratelimit.TokenBucket: 令牌桶，固定速率+突发容量
+✅ ratelimit.SlidingWindow: 滑动窗口计数，平滑窗口边界
+✅ ratelimit.KeyedMiddleware: 按客户端IP限流，超限返回429和Retry-After
+
+中间件链:
+✅ middleware.Chain(a, b, c).Then(h): a 在最外层，等价于 a(b(c(h)))
+✅ HTTPRecover/HTTPLogging/HTTPTimeout: panic返回500、访问日志、请求ctx超时
+✅ Append 在公共链后追加路由专用的中间件(如限流)，原链不受影响
+
 最佳实践:
 ✅ 总是检查错误处理
 ✅ 及时关闭响应体避免资源泄漏
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"os"
+	"strconv"
+	"time"
+
+	"Standard_Library/middleware"
 )
 
 // ============================= 1. 基础日志使用 =============================
@@ -108,7 +113,55 @@ func productionRecommendation() {
 	log.Println("✅ zerolog - 零分配JSON日志库")
 }
 
-// ============================= 7. 主函数 =============================
+// ============================= 7. 中间件记录函数调用 =============================
+
+// Processor 与类型系统示例中的处理器签名相同
+type Processor = func(string) (int, error)
+
+func demoMiddlewareLogging() {
+	log.Println("\n--- 7. 中间件记录函数调用 ---")
+
+	logger := log.New(os.Stdout, "[PROC] ", log.Ltime|log.Lmsgprefix)
+	var total time.Duration
+	chain := middleware.Chain(
+		middleware.Logging[string, int](logger, "parse"),
+		middleware.Timing[string, int](func(in string, d time.Duration, err error) { total += d }),
+		middleware.Recover[string, int](),
+		middleware.Timeout[string, int](100*time.Millisecond),
+	)
+
+	var parse Processor = chain.Then(func(s string) (int, error) {
+		switch s {
+		case "boom":
+			panic("unexpected input")
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+		return strconv.Atoi(s)
+	})
+	for _, in := range []string{"42", "abc", "boom", "slow"} {
+		parse(in)
+	}
+	log.Printf("累计耗时: %v", total.Round(time.Millisecond))
+
+	// 重试：临时错误最多尝试3次
+	attempts := 0
+	errBusy := errors.New("busy")
+	var fetch Processor = middleware.Chain(
+		middleware.Logging[string, int](logger, "fetch"),
+		middleware.Retry[string, int](3, 10*time.Millisecond, func(err error) bool { return errors.Is(err, errBusy) }),
+	).Then(func(key string) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errBusy
+		}
+		return len(key), nil
+	})
+	fetch("user:1")
+	log.Printf("fetch 共尝试 %d 次", attempts)
+}
+
+// ============================= 8. 主函数 =============================
 
 func main() {
 	log.Println("🚀 Go Log 包使用示例")
@@ -121,6 +174,7 @@ func main() {
 	demoCustomLogger()
 	demoLogLevels()
 	productionRecommendation()
+	demoMiddlewareLogging()
 
 	log.Println("✅ 日志演示完成")
 }
@@ -152,7 +206,11 @@ func main() {
 ✅ log.New() - 创建自定义Logger实例
 ✅ 可以指定输出位置、前缀、格式
 
-============================= 5. 生产建议 =============================
+============================= 5. 中间件 =============================
+✅ middleware.Chain(...).Then(f) - 为任意函数类型组合日志、计时、恢复、重试、超时
+✅ middleware.Logging - 通过 *log.Logger 记录参数、结果和耗时
+
+============================= 6. 生产建议 =============================
 🌱 开发环境: 标准log包足够
 🚀 生产环境: 使用zap、logrus等第三方库
 
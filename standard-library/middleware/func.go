@@ -0,0 +1,126 @@
+// ============================= 2. 函数中间件 ====================
+// 针对 func(In) (Out, error) 形状的函数，例如 type Processor = func(string) (int, error)
+// Func 是类型别名，Processor 可以直接使用这些中间件
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// Func 输入 In、返回 Out 和错误的函数
+type Func[In, Out any] = func(In) (Out, error)
+
+// PanicError Recover 把 panic 转换成的错误
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("middleware: panic: %v", e.Value)
+}
+
+// Timing 每次调用结束后把耗时和错误交给 observe
+func Timing[In, Out any](observe func(in In, d time.Duration, err error)) Middleware[Func[In, Out]] {
+	return func(next Func[In, Out]) Func[In, Out] {
+		return func(in In) (Out, error) {
+			start := time.Now()
+			out, err := next(in)
+			observe(in, time.Since(start), err)
+			return out, err
+		}
+	}
+}
+
+// Recover 把 panic 转换为 *PanicError 返回
+func Recover[In, Out any]() Middleware[Func[In, Out]] {
+	return func(next Func[In, Out]) Func[In, Out] {
+		return func(in In) (out Out, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = &PanicError{Value: r, Stack: debug.Stack()}
+				}
+			}()
+			return next(in)
+		}
+	}
+}
+
+// Logging 通过 logger 记录每次调用的参数、结果和耗时，logger 为 nil 时使用 log.Default()
+func Logging[In, Out any](logger *log.Logger, name string) Middleware[Func[In, Out]] {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next Func[In, Out]) Func[In, Out] {
+		return func(in In) (Out, error) {
+			start := time.Now()
+			out, err := next(in)
+			if err != nil {
+				logger.Printf("%s(%v) 失败: %v [%v]", name, in, err, time.Since(start))
+			} else {
+				logger.Printf("%s(%v) = %v [%v]", name, in, out, time.Since(start))
+			}
+			return out, err
+		}
+	}
+}
+
+// Retry 失败时最多再重试 attempts-1 次，每次等待时间从 backoff 开始翻倍
+// retryable 为 nil 时重试所有错误
+func Retry[In, Out any](attempts int, backoff time.Duration, retryable func(error) bool) Middleware[Func[In, Out]] {
+	attempts = max(attempts, 1)
+	return func(next Func[In, Out]) Func[In, Out] {
+		return func(in In) (out Out, err error) {
+			wait := backoff
+			for i := range attempts {
+				if i > 0 {
+					time.Sleep(wait)
+					wait *= 2
+				}
+				out, err = next(in)
+				if err == nil || (retryable != nil && !retryable(err)) {
+					return out, err
+				}
+			}
+			return out, err
+		}
+	}
+}
+
+// Timeout 调用超过 d 时返回 context.DeadlineExceeded
+// 函数没有 ctx 参数无法被取消：超时后它仍在后台运行到结束，结果被丢弃
+func Timeout[In, Out any](d time.Duration) Middleware[Func[In, Out]] {
+	type result struct {
+		out Out
+		err error
+	}
+	return func(next Func[In, Out]) Func[In, Out] {
+		return func(in In) (Out, error) {
+			ch := make(chan result, 1) // 带缓冲：超时后后台goroutine也能写入并退出
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						ch <- result{err: &PanicError{Value: r, Stack: debug.Stack()}}
+					}
+				}()
+				out, err := next(in)
+				ch <- result{out, err}
+			}()
+
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			select {
+			case r := <-ch:
+				return r.out, r.err
+			case <-timer.C:
+				var zero Out
+				return zero, fmt.Errorf("middleware: call exceeded %v: %w", d, context.DeadlineExceeded)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecover(t *testing.T) {
+	f := Recover[int, int]()(func(n int) (int, error) {
+		if n == 0 {
+			panic("zero")
+		}
+		return 10 / n, nil
+	})
+
+	if v, err := f(2); v != 5 || err != nil {
+		t.Fatalf("f(2) = %d, %v", v, err)
+	}
+	_, err := f(0)
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "zero" {
+		t.Fatalf("f(0) err = %v; want *PanicError(zero)", err)
+	}
+	if err.Error() != "middleware: panic: zero" || !strings.Contains(string(pe.Stack), "TestRecover") {
+		t.Errorf("Error() = %q, stack:\n%s", err.Error(), pe.Stack)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	errTemp := errors.New("temporary")
+	errFatal := errors.New("fatal")
+
+	cases := []struct {
+		name      string
+		attempts  int
+		failures  []error // 依次返回的错误，用完后成功
+		retryable func(error) bool
+		calls     int
+		err       error
+	}{
+		{"SucceedsFirst", 3, nil, nil, 1, nil},
+		{"SucceedsAfterRetries", 3, []error{errTemp, errTemp}, nil, 3, nil},
+		{"Exhausted", 3, []error{errTemp, errTemp, errTemp, errTemp}, nil, 3, errTemp},
+		{"NotRetryable", 3, []error{errFatal}, func(err error) bool { return err != errFatal }, 1, errFatal},
+		{"RetryableThenFatal", 5, []error{errTemp, errFatal}, func(err error) bool { return err != errFatal }, 2, errFatal},
+		{"ZeroAttempts", 0, []error{errTemp}, nil, 1, errTemp}, // 至少调用一次
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			calls := 0
+			f := Retry[string, int](c.attempts, 0, c.retryable)(func(s string) (int, error) {
+				calls++
+				if calls <= len(c.failures) {
+					return -calls, c.failures[calls-1]
+				}
+				return len(s), nil
+			})
+			v, err := f("abc")
+			if calls != c.calls || err != c.err {
+				t.Fatalf("calls = %d, err = %v; want %d, %v", calls, err, c.calls, c.err)
+			}
+			if err == nil && v != 3 {
+				t.Errorf("value = %d; want 3", v)
+			}
+			if err != nil && v != -calls { // 返回最后一次调用的结果
+				t.Errorf("value = %d; want %d", v, -calls)
+			}
+		})
+	}
+}
+
+// TestRetryBackoff 等待时间从 backoff 开始翻倍：5ms + 10ms + 20ms
+func TestRetryBackoff(t *testing.T) {
+	const backoff = 5 * time.Millisecond
+	var at []time.Time
+	f := Retry[int, int](4, backoff, nil)(func(int) (int, error) {
+		at = append(at, time.Now())
+		return 0, errors.New("fail")
+	})
+	f(0)
+	if len(at) != 4 {
+		t.Fatalf("calls = %d; want 4", len(at))
+	}
+	for i := 1; i < len(at); i++ {
+		if want := backoff << (i - 1); at[i].Sub(at[i-1]) < want {
+			t.Errorf("wait before attempt %d = %v; want >= %v", i+1, at[i].Sub(at[i-1]), want)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	slow := Timeout[int, int](10 * time.Millisecond)(func(n int) (int, error) {
+		defer close(finished)
+		<-release
+		return n, nil
+	})
+	_, err := slow(1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v; want context.DeadlineExceeded", err)
+	}
+	// 超时后函数仍在后台运行，结束时不会阻塞
+	close(release)
+	<-finished
+
+	fast := Timeout[int, int](time.Second)(func(n int) (int, error) { return n * 2, nil })
+	if v, err := fast(21); v != 42 || err != nil {
+		t.Fatalf("fast = %d, %v", v, err)
+	}
+
+	// 后台goroutine中的 panic 转换为 *PanicError
+	boom := Timeout[int, int](time.Second)(func(int) (int, error) { panic("boom") })
+	var pe *PanicError
+	if _, err := boom(0); !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("boom err = %v; want *PanicError(boom)", err)
+	}
+}
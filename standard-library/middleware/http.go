@@ -0,0 +1,147 @@
+// ============================= 3. HTTP 中间件 ====================
+// 使用 Middleware[http.Handler]，http.HandlerFunc 和其他包的处理器都可以放进同一条链
+// 已有的 func(http.Handler) http.Handler 形式的中间件可以直接转换为 Middleware[http.Handler]
+
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// statusRecorder 记录处理器写出的状态码和字节数
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Flush 转发给底层的 http.Flusher，流式响应经过中间件后仍可以断言为 http.Flusher
+// 底层不支持时什么也不做
+func (r *statusRecorder) Flush() {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Hijack 转发给底层的 http.Hijacker，底层不支持时返回 http.ErrNotSupported
+// 接管连接后响应由调用者自己写，状态码记为 101，HTTPRecover/HTTPTimeout 不会再写响应
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err == nil && r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap 让 http.ResponseController 可以访问底层的 SetWriteDeadline、EnableFullDuplex 等
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func record(w http.ResponseWriter) *statusRecorder {
+	if rec, ok := w.(*statusRecorder); ok {
+		return rec // 多个中间件共用同一个记录器
+	}
+	return &statusRecorder{ResponseWriter: w}
+}
+
+// statusOf 处理器什么都没写时，net/http 会返回 200
+func (r *statusRecorder) statusOf() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// HTTPTiming 每个请求结束后把状态码和耗时交给 observe
+func HTTPTiming(observe func(r *http.Request, status int, d time.Duration)) Middleware[http.Handler] {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := record(w)
+			next.ServeHTTP(rec, r)
+			observe(r, rec.statusOf(), time.Since(start))
+		})
+	}
+}
+
+// HTTPLogging 访问日志：方法、路径、状态码、字节数、耗时
+func HTTPLogging(logger *log.Logger) Middleware[http.Handler] {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := record(w)
+			next.ServeHTTP(rec, r)
+			logger.Printf("%s %s %d %dB %v", r.Method, r.URL.RequestURI(), rec.statusOf(), rec.bytes, time.Since(start))
+		})
+	}
+}
+
+// HTTPRecover 处理器 panic 时记录堆栈并返回 500，其他请求不受影响
+// http.ErrAbortHandler 是主动中断连接的信号，继续向上抛出
+func HTTPRecover(logger *log.Logger) Middleware[http.Handler] {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := record(w)
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				logger.Printf("%s %s panic: %v\n%s", r.Method, r.URL.RequestURI(), v, debug.Stack())
+				if rec.status == 0 { // 已经开始写响应时无法再改状态码
+					http.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+// HTTPTimeout 给请求的 ctx 设置超时，处理器应把 r.Context() 传给下游调用
+// 超时后处理器仍未写响应时返回 503
+func HTTPTimeout(d time.Duration) Middleware[http.Handler] {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			rec := record(w)
+			next.ServeHTTP(rec, r.WithContext(ctx))
+			if rec.status == 0 && ctx.Err() == context.DeadlineExceeded {
+				http.Error(rec, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
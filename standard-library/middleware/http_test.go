@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serve(h http.Handler) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+	return w
+}
+
+func TestHTTPRecover(t *testing.T) {
+	var buf bytes.Buffer
+	mw := HTTPRecover(log.New(&buf, "", 0))
+
+	// 还没写响应：返回 500 并记录堆栈
+	w := serve(mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("boom") })))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d; want 500", w.Code)
+	}
+	if !strings.Contains(buf.String(), "GET /x panic: boom") {
+		t.Errorf("log = %q", buf.String())
+	}
+
+	// 已经写了响应头：状态码和已写的内容保持不变
+	w = serve(mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, "partial")
+		panic("late")
+	})))
+	if w.Code != http.StatusAccepted || w.Body.String() != "partial" {
+		t.Errorf("after headers: status = %d, body = %q; want 202, partial", w.Code, w.Body.String())
+	}
+
+	// http.ErrAbortHandler 继续向上抛出，由 net/http 中断连接
+	func() {
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Errorf("recovered %v; want http.ErrAbortHandler", v)
+			}
+		}()
+		serve(mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic(http.ErrAbortHandler) })))
+	}()
+}
+
+func TestHTTPTimeout(t *testing.T) {
+	timeout := HTTPTimeout(10 * time.Millisecond)
+
+	// 处理器等到 ctx 超时也没有写响应：503
+	w := serve(timeout(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d; want 503", w.Code)
+	}
+
+	// 处理器自己写了响应：不覆盖
+	w = serve(timeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		http.Error(w, "gave up", http.StatusGatewayTimeout)
+	})))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d; want 504", w.Code)
+	}
+
+	w = serve(timeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("request ctx has no deadline")
+		}
+	})))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d; want 200", w.Code)
+	}
+}
+
+func TestHTTPLoggingStatus(t *testing.T) {
+	var buf bytes.Buffer
+	var status int
+	h := Chain(
+		HTTPLogging(log.New(&buf, "", 0)),
+		HTTPTiming(func(_ *http.Request, s int, _ time.Duration) { status = s }),
+	).Then(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.WriteHeader(http.StatusOK) // 只记录第一次
+		io.WriteString(w, "missing")
+	}))
+	serve(h)
+	if status != http.StatusNotFound || !strings.HasPrefix(buf.String(), "GET /x 404 7B ") {
+		t.Errorf("status = %d, log = %q", status, buf.String())
+	}
+}
+
+// TestRecorderInterfaces 经过中间件的 ResponseWriter 仍然支持 Flush 和 Hijack
+func TestRecorderInterfaces(t *testing.T) {
+	statuses := make(chan int, 3)
+	stack := Chain(
+		HTTPTiming(func(_ *http.Request, s int, _ time.Duration) { statuses <- s }),
+		HTTPRecover(log.New(io.Discard, "", 0)),
+	)
+
+	w := serve(stack.Then(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		io.WriteString(w, "chunk")
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("ResponseWriter is not an http.Flusher")
+		}
+		f.Flush()
+	})))
+	if !w.Flushed {
+		t.Error("Flush was not forwarded")
+	}
+
+	// httptest.ResponseRecorder 不支持 Hijack
+	serve(stack.Then(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, _, err := w.(http.Hijacker).Hijack(); !errors.Is(err, http.ErrNotSupported) {
+			t.Errorf("Hijack on recorder = %v; want http.ErrNotSupported", err)
+		}
+	})))
+
+	<-statuses
+	<-statuses
+
+	// 真实连接：接管后自己写响应，panic 时 HTTPRecover 不再写 500
+	srv := httptest.NewServer(stack.Then(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 418 I'm a teapot\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+		rw.Flush()
+		panic("after hijack")
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("status = %d; want 418", resp.StatusCode)
+	}
+	if s := <-statuses; s != http.StatusSwitchingProtocols {
+		t.Errorf("recorded status = %d; want 101 for a hijacked connection", s)
+	}
+}
@@ -0,0 +1,30 @@
+// ============================= 1. 通用中间件链 ====================
+// 中间件接收下一个处理函数，返回包装后的函数：func(next F) F
+// F 可以是任意函数类型，例如 func(string) (int, error) 或 http.Handler
+// Chain(a, b, c).Then(f) 等价于 a(b(c(f)))：a 在最外层，最先看到调用，最后看到结果
+
+package middleware
+
+// Middleware 包装 next 并返回新的函数
+type Middleware[F any] func(next F) F
+
+// Stack 有序的中间件列表，创建后不会被修改，可以在多个路由间复用
+type Stack[F any] []Middleware[F]
+
+// Chain 按顺序组合中间件，第一个在最外层
+func Chain[F any](mws ...Middleware[F]) Stack[F] {
+	return append(Stack[F](nil), mws...)
+}
+
+// Append 返回追加了 mws 的新链，原链不受影响
+func (s Stack[F]) Append(mws ...Middleware[F]) Stack[F] {
+	return append(append(Stack[F](nil), s...), mws...)
+}
+
+// Then 用整条链包装 f
+func (s Stack[F]) Then(f F) F {
+	for i := len(s) - 1; i >= 0; i-- {
+		f = s[i](f)
+	}
+	return f
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"slices"
+	"testing"
+)
+
+// tag 在调用前后记录名字，用于检查中间件的嵌套顺序
+func tag(name string, trace *[]string) Middleware[func()] {
+	return func(next func()) func() {
+		return func() {
+			*trace = append(*trace, name+">")
+			next()
+			*trace = append(*trace, "<"+name)
+		}
+	}
+}
+
+func TestStackThen(t *testing.T) {
+	var trace []string
+	handler := func() { trace = append(trace, "f") }
+
+	// 第一个中间件在最外层
+	Chain(tag("a", &trace), tag("b", &trace), tag("c", &trace)).Then(handler)()
+	if want := []string{"a>", "b>", "c>", "f", "<c", "<b", "<a"}; !slices.Equal(trace, want) {
+		t.Errorf("trace = %v; want %v", trace, want)
+	}
+
+	// Append 返回新链，原链不变；共享前缀的链互不影响
+	base := Chain(tag("a", &trace))
+	withB := base.Append(tag("b", &trace))
+	withC := base.Append(tag("c", &trace))
+	for _, c := range []struct {
+		s    Stack[func()]
+		want []string
+	}{
+		{base, []string{"a>", "f", "<a"}},
+		{withB, []string{"a>", "b>", "f", "<b", "<a"}},
+		{withC, []string{"a>", "c>", "f", "<c", "<a"}},
+		{Chain[func()](), []string{"f"}},
+	} {
+		trace = nil
+		c.s.Then(handler)()
+		if !slices.Equal(trace, c.want) {
+			t.Errorf("trace = %v; want %v", trace, c.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// ============================= 2. 令牌桶 ====================
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBucket 令牌桶限流器，并发安全
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒补充的令牌数
+	burst  float64 // 桶容量
+	tokens float64 // 当前令牌数，为负表示已被预订
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewTokenBucket 创建每秒补充 rate 个令牌、容量为 burst 的令牌桶，初始为满桶
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// Every 把时间间隔换算为每秒速率，例如 Every(100*time.Millisecond) == 10
+func Every(interval time.Duration) float64 {
+	if interval <= 0 {
+		return 0
+	}
+	return float64(time.Second) / float64(interval)
+}
+
+// advance 按流逝时间补充令牌，调用方需持有锁
+func (b *TokenBucket) advance(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+}
+
+// Allow 有可用令牌时消耗一个并返回 true
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(b.now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	return false
+}
+
+// Reserve 预订一个令牌，令牌不足时返回需要等待的时间
+func (b *TokenBucket) Reserve() *Reservation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(b.now())
+	if b.tokens < 1 && b.rate <= 0 {
+		return &Reservation{ok: false}
+	}
+
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	return &Reservation{
+		ok:    true,
+		delay: delay,
+		cancel: func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.advance(b.now())
+			b.tokens = min(b.tokens+1, b.burst)
+		},
+	}
+}
+
+// Wait 阻塞直到获得令牌
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return wait(ctx, b)
+}
+
+// Tokens 当前可用令牌数（可能为负，表示已有预订）
+func (b *TokenBucket) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(b.now())
+	return b.tokens
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"Standard_Library/clock"
+)
+
+// fakeBucket 令牌桶的时间来源换成 FakeClock
+func fakeBucket(rate float64, burst int) (*TokenBucket, *clock.FakeClock) {
+	f := clock.NewFake(time.Unix(1000, 0))
+	b := NewTokenBucket(rate, burst)
+	b.now, b.last = f.Now, f.Now()
+	return b, f
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	b, f := fakeBucket(10, 3)
+
+	for i := range 3 {
+		if !b.Allow() {
+			t.Fatalf("Allow %d on full bucket = false", i)
+		}
+	}
+	if b.Allow() {
+		t.Fatal("Allow on empty bucket = true")
+	}
+
+	steps := []struct {
+		advance time.Duration
+		want    bool
+	}{
+		{50 * time.Millisecond, false}, // 0.5 个令牌
+		{50 * time.Millisecond, true},  // 补满 1 个
+		{99 * time.Millisecond, false},
+		{time.Millisecond, true},
+	}
+	for i, s := range steps {
+		f.Advance(s.advance)
+		if got := b.Allow(); got != s.want {
+			t.Errorf("step %d: Allow after %v = %t, want %t", i, s.advance, got, s.want)
+		}
+	}
+
+	// 长时间空闲后令牌数不超过 burst
+	f.Advance(time.Hour)
+	if got := b.Tokens(); got != 3 {
+		t.Errorf("Tokens after idle = %v, want 3", got)
+	}
+}
+
+func TestTokenBucketReserve(t *testing.T) {
+	b, f := fakeBucket(10, 1)
+	if r := b.Reserve(); !r.OK() || r.Delay() != 0 {
+		t.Fatalf("first Reserve = %t, %v; want immediate", r.OK(), r.Delay())
+	}
+
+	// 令牌不足时按速率排队，令牌数变为负
+	r1, r2 := b.Reserve(), b.Reserve()
+	if r1.Delay() != 100*time.Millisecond || r2.Delay() != 200*time.Millisecond {
+		t.Fatalf("queued delays = %v, %v; want 100ms, 200ms", r1.Delay(), r2.Delay())
+	}
+	if got := b.Tokens(); got != -2 {
+		t.Fatalf("Tokens = %v, want -2", got)
+	}
+
+	// Cancel 归还配额，重复 Cancel 无效
+	r2.Cancel()
+	r2.Cancel()
+	if got := b.Tokens(); got != -1 {
+		t.Fatalf("Tokens after Cancel = %v, want -1", got)
+	}
+	f.Advance(100 * time.Millisecond)
+	if got := b.Tokens(); math.Abs(got) > 1e-9 {
+		t.Fatalf("Tokens after 100ms = %v, want 0", got)
+	}
+
+	// 速率为 0 的空桶无法预订
+	z, _ := fakeBucket(0, 1)
+	z.Allow()
+	if r := z.Reserve(); r.OK() {
+		t.Error("Reserve on empty zero-rate bucket = ok")
+	}
+}
+
+func TestTokenBucketWaitDeadline(t *testing.T) {
+	b, _ := fakeBucket(Every(time.Hour), 1)
+	b.Allow()
+
+	// 需要等一小时，ctx 只有1秒：立即返回并归还预订
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := b.Wait(ctx); err != ErrExceedsDeadline {
+		t.Fatalf("Wait = %v, want ErrExceedsDeadline", err)
+	}
+	if got := b.Tokens(); got != 0 {
+		t.Errorf("Tokens after rejected Wait = %v, want 0", got)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := b.Wait(ctx); err != context.Canceled {
+		t.Errorf("Wait on canceled ctx = %v, want context.Canceled", err)
+	}
+}
+
+func TestEvery(t *testing.T) {
+	cases := []struct {
+		interval time.Duration
+		want     float64
+	}{
+		{100 * time.Millisecond, 10},
+		{time.Second, 1},
+		{4 * time.Second, 0.25},
+		{0, 0},
+		{-time.Second, 0},
+	}
+	for _, c := range cases {
+		if got := Every(c.interval); got != c.want {
+			t.Errorf("Every(%v) = %v, want %v", c.interval, got, c.want)
+		}
+	}
+}
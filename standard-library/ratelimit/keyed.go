@@ -0,0 +1,88 @@
+// ============================= 4. 按键限流 ====================
+// 每个键（用户ID、客户端IP等）拥有独立的限流器
+// 超过 idleTTL 未访问的键在下次访问时被批量清理，不需要后台goroutine
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Keyed 按键划分的限流器集合
+type Keyed struct {
+	mu        sync.Mutex
+	factory   func() Limiter
+	idleTTL   time.Duration
+	entries   map[string]*keyedEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type keyedEntry struct {
+	limiter  Limiter
+	lastSeen time.Time
+}
+
+// NewKeyed 创建按键限流器，factory 为每个新键创建限流器
+func NewKeyed(factory func() Limiter, idleTTL time.Duration) *Keyed {
+	return &Keyed{
+		factory:   factory,
+		idleTTL:   idleTTL,
+		entries:   make(map[string]*keyedEntry),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Get 返回键对应的限流器，不存在时创建
+func (k *Keyed) Get(key string) Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := k.now()
+	if k.idleTTL > 0 && now.Sub(k.lastSweep) >= k.idleTTL {
+		k.sweep(now)
+	}
+
+	e, ok := k.entries[key]
+	if !ok {
+		e = &keyedEntry{limiter: k.factory()}
+		k.entries[key] = e
+	}
+	e.lastSeen = now
+	return e.limiter
+}
+
+// sweep 清理空闲的键，调用方需持有锁
+func (k *Keyed) sweep(now time.Time) {
+	for key, e := range k.entries {
+		if now.Sub(e.lastSeen) >= k.idleTTL {
+			delete(k.entries, key)
+		}
+	}
+	k.lastSweep = now
+}
+
+// Allow 判断该键是否放行
+func (k *Keyed) Allow(key string) bool {
+	return k.Get(key).Allow()
+}
+
+// Wait 等待该键获得配额
+func (k *Keyed) Wait(ctx context.Context, key string) error {
+	return k.Get(key).Wait(ctx)
+}
+
+// Reserve 为该键预订配额
+func (k *Keyed) Reserve(key string) *Reservation {
+	return k.Get(key).Reserve()
+}
+
+// Len 当前跟踪的键数量
+func (k *Keyed) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.entries)
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"Standard_Library/clock"
+)
+
+func TestKeyedEviction(t *testing.T) {
+	f := clock.NewFake(time.Unix(1000, 0))
+	created := 0
+	k := NewKeyed(func() Limiter {
+		created++
+		b := NewTokenBucket(0, 1)
+		b.now, b.last = f.Now, f.Now()
+		return b
+	}, time.Minute)
+	k.now, k.lastSweep = f.Now, f.Now()
+
+	// 每个键有独立的限流器
+	if !k.Allow("a") || k.Allow("a") {
+		t.Fatal("key a: want exactly one request allowed")
+	}
+	if !k.Allow("b") {
+		t.Fatal("key b throttled by key a")
+	}
+
+	f.Advance(30 * time.Second)
+	k.Get("a") // a 在 30s 时访问过
+	f.Advance(40 * time.Second)
+	k.Get("c") // 距上次清理 70s，触发清理：b 空闲 70s 被删除，a 空闲 40s 保留
+	if n := k.Len(); n != 2 {
+		t.Fatalf("Len after sweep = %d, want 2 (a, c)", n)
+	}
+	if created != 3 {
+		t.Fatalf("created %d limiters, want 3", created)
+	}
+
+	// a 保留了之前的状态；b 被清理后重新创建，配额恢复
+	if k.Allow("a") {
+		t.Error("key a state lost after sweep")
+	}
+	if !k.Allow("b") || created != 4 {
+		t.Errorf("evicted key b: want a fresh limiter, created = %d", created)
+	}
+
+	// idleTTL <= 0 时从不清理
+	never := NewKeyed(func() Limiter { return NewTokenBucket(1, 1) }, 0)
+	never.now = f.Now
+	never.Get("x")
+	f.Advance(24 * time.Hour)
+	never.Get("y")
+	if n := never.Len(); n != 2 {
+		t.Errorf("Len without TTL = %d, want 2", n)
+	}
+}
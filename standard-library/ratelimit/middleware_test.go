@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// serve 发送一个请求，返回状态码和 Retry-After
+func serve(h http.Handler, remoteAddr string) (int, string) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code, w.Header().Get("Retry-After")
+}
+
+func TestMiddleware(t *testing.T) {
+	cases := []struct {
+		name  string
+		rate  float64
+		burst int
+		retry string
+	}{
+		{"OneSecond", 1, 2, "1"},
+		{"RoundUp", Every(2500 * time.Millisecond), 1, "3"}, // 2.5 秒向上取整
+		{"ZeroRate", 0, 1, "1"},                             // 无法预订时默认 1 秒
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, f := fakeBucket(c.rate, c.burst)
+			h := Middleware(b, okHandler)
+			for i := range c.burst {
+				if code, _ := serve(h, "1.2.3.4:1000"); code != http.StatusOK {
+					t.Fatalf("request %d = %d, want 200", i, code)
+				}
+			}
+			code, retry := serve(h, "1.2.3.4:1000")
+			if code != http.StatusTooManyRequests || retry != c.retry {
+				t.Fatalf("throttled request = %d, Retry-After %q; want 429, %q", code, retry, c.retry)
+			}
+			// 被拒绝的请求归还了预订，不会推迟后面的请求
+			if got := b.Tokens(); got != 0 {
+				t.Fatalf("Tokens after 429 = %v, want 0", got)
+			}
+			if c.rate > 0 {
+				f.Advance(time.Duration(float64(time.Second) / c.rate))
+				if code, _ := serve(h, "1.2.3.4:1000"); code != http.StatusOK {
+					t.Errorf("after refill = %d, want 200", code)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyedMiddleware(t *testing.T) {
+	_, f := fakeBucket(0, 1)
+	k := NewKeyed(func() Limiter {
+		b := NewTokenBucket(1, 1)
+		b.now, b.last = f.Now, f.Now()
+		return b
+	}, time.Minute)
+	k.now = f.Now
+	h := KeyedMiddleware(k, nil, okHandler) // 默认按客户端IP
+
+	steps := []struct {
+		addr string
+		want int
+	}{
+		{"10.0.0.1:1000", http.StatusOK},
+		{"10.0.0.1:2000", http.StatusTooManyRequests}, // 同一IP不同端口
+		{"10.0.0.2:1000", http.StatusOK},
+		{"[::1]:1000", http.StatusOK},
+		{"[::1]:1001", http.StatusTooManyRequests},
+	}
+	for _, s := range steps {
+		if code, _ := serve(h, s.addr); code != s.want {
+			t.Errorf("%s: %d, want %d", s.addr, code, s.want)
+		}
+	}
+
+	// 自定义键函数
+	byHeader := KeyedMiddleware(NewKeyed(func() Limiter { return NewTokenBucket(0, 1) }, 0),
+		func(r *http.Request) string { return r.Header.Get("X-User") }, okHandler)
+	for _, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-User", "alice")
+		w := httptest.NewRecorder()
+		byHeader.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("X-User alice = %d, want %d", w.Code, want)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	cases := map[string]string{
+		"1.2.3.4:80":   "1.2.3.4",
+		"[::1]:8080":   "::1",
+		"no-port-here": "no-port-here",
+	}
+	for addr, want := range cases {
+		r := &http.Request{RemoteAddr: addr}
+		if got := ClientIP(r); got != want {
+			t.Errorf("ClientIP(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// ============================= 1. 限流器接口 ====================
+// 三种限流器实现同一个接口：
+// - TokenBucket：令牌桶，按固定速率补充令牌，允许 burst 个突发请求
+// - SlidingWindow：滑动窗口计数器，用前一窗口按比例加权估算当前请求数
+// - Keyed：按键（用户/IP）分别限流，空闲的键会被自动清理
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrExceedsDeadline 等待时间超过了ctx的截止时间
+var ErrExceedsDeadline = errors.New("ratelimit: wait would exceed context deadline")
+
+// Limiter 限流器通用接口
+type Limiter interface {
+	// Allow 立即判断是否放行，放行时消耗一个配额
+	Allow() bool
+	// Wait 阻塞直到获得配额或ctx取消
+	Wait(ctx context.Context) error
+	// Reserve 预订一个配额，返回需要等待的时间
+	Reserve() *Reservation
+}
+
+// Reservation 预订结果；不打算使用时调用 Cancel 归还配额
+type Reservation struct {
+	ok     bool
+	delay  time.Duration
+	cancel func()
+}
+
+// OK 是否预订成功（请求数超过容量上限时为 false）
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay 需要等待多久才能使用这个配额
+func (r *Reservation) Delay() time.Duration {
+	return r.delay
+}
+
+// Cancel 归还未使用的配额
+func (r *Reservation) Cancel() {
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+}
+
+// wait Limiter.Wait 的通用实现：预订 → 检查截止时间 → 等待
+func wait(ctx context.Context, l Limiter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r := l.Reserve()
+	if !r.OK() {
+		return errors.New("ratelimit: reservation not possible")
+	}
+	if r.Delay() <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < r.Delay() {
+		r.Cancel()
+		return ErrExceedsDeadline
+	}
+
+	timer := time.NewTimer(r.Delay())
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,119 @@
+// ============================= 3. 滑动窗口计数器 ====================
+// 估算值 = 前一窗口计数 × (1 - 当前窗口已过比例) + 当前窗口计数
+// 相比固定窗口，避免了窗口边界处的流量翻倍；预订会记入将来放行时刻所在的窗口
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// SlidingWindow 每个 window 时间内最多放行 limit 个请求
+type SlidingWindow struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	counts map[int64]int // 窗口序号 -> 计数（含将来窗口的预订）
+	now    func() time.Time
+}
+
+// ErrInvalidWindow 滑动窗口的时长必须为正
+var ErrInvalidWindow = errors.New("ratelimit: non-positive sliding window")
+
+// NewSlidingWindow 创建滑动窗口计数器，window <= 0 时返回 ErrInvalidWindow
+func NewSlidingWindow(limit int, window time.Duration) (*SlidingWindow, error) {
+	if window <= 0 {
+		return nil, ErrInvalidWindow
+	}
+	return &SlidingWindow{
+		limit:  limit,
+		window: window,
+		counts: make(map[int64]int),
+		now:    time.Now,
+	}, nil
+}
+
+// estimate 估算时刻 t 的滑动窗口请求数
+func (w *SlidingWindow) estimate(t time.Time) (idx int64, count float64) {
+	idx = t.UnixNano() / int64(w.window)
+	elapsed := float64(t.UnixNano()-idx*int64(w.window)) / float64(w.window)
+	return idx, float64(w.counts[idx-1])*(1-elapsed) + float64(w.counts[idx])
+}
+
+// gc 删除不再参与计算的旧窗口，调用方需持有锁
+func (w *SlidingWindow) gc(now time.Time) {
+	cur := now.UnixNano() / int64(w.window)
+	for idx := range w.counts {
+		if idx < cur-1 {
+			delete(w.counts, idx)
+		}
+	}
+}
+
+// Allow 当前估算值未达上限时放行
+func (w *SlidingWindow) Allow() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	w.gc(now)
+	idx, count := w.estimate(now)
+	if count+1 > float64(w.limit) {
+		return false
+	}
+	w.counts[idx]++
+	return true
+}
+
+// Reserve 找到最早可以放行的时刻并记入该时刻所在窗口
+func (w *SlidingWindow) Reserve() *Reservation {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.limit <= 0 {
+		return &Reservation{ok: false}
+	}
+
+	now := w.now()
+	w.gc(now)
+	at := now
+	for {
+		idx, count := w.estimate(at)
+		if count+1 <= float64(w.limit) {
+			w.counts[idx]++
+			return &Reservation{
+				ok:    true,
+				delay: at.Sub(now),
+				cancel: func() {
+					w.mu.Lock()
+					defer w.mu.Unlock()
+					if w.counts[idx] > 0 {
+						w.counts[idx]--
+					}
+				},
+			}
+		}
+
+		start := time.Unix(0, idx*int64(w.window))
+		next := start.Add(w.window)
+		cur, prev := float64(w.counts[idx]), float64(w.counts[idx-1])
+		if cur+1 <= float64(w.limit) && prev > 0 {
+			// 当前窗口内前一窗口的权重持续下降，求出恰好满足上限的时刻
+			frac := 1 - (float64(w.limit)-1-cur)/prev
+			candidate := start.Add(time.Duration(frac*float64(w.window)) + 1)
+			if candidate.After(at) && candidate.Before(next) {
+				at = candidate
+				continue
+			}
+		}
+		at = next
+	}
+}
+
+// Wait 阻塞直到滑动窗口允许放行
+func (w *SlidingWindow) Wait(ctx context.Context) error {
+	return wait(ctx, w)
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"Standard_Library/clock"
+)
+
+// fakeWindow 滑动窗口的时间来源换成 FakeClock，起点对齐窗口边界
+func fakeWindow(t *testing.T, limit int, window time.Duration) (*SlidingWindow, *clock.FakeClock) {
+	t.Helper()
+	w, err := NewSlidingWindow(limit, window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := clock.NewFake(time.Unix(1000, 0))
+	w.now = f.Now
+	return w, f
+}
+
+// allowN 连续调用 Allow，返回放行的次数
+func allowN(l Limiter, n int) int {
+	allowed := 0
+	for range n {
+		if l.Allow() {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestNewSlidingWindowInvalid(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		if w, err := NewSlidingWindow(10, d); err != ErrInvalidWindow || w != nil {
+			t.Errorf("NewSlidingWindow(10, %v) = %v, %v; want ErrInvalidWindow", d, w, err)
+		}
+	}
+}
+
+func TestSlidingWindowBoundaries(t *testing.T) {
+	w, f := fakeWindow(t, 10, time.Second)
+
+	steps := []struct {
+		advance time.Duration
+		tries   int
+		want    int
+	}{
+		{0, 15, 10},                     // 第一个窗口放满
+		{500 * time.Millisecond, 5, 0},  // 同一窗口内不再放行
+		{500 * time.Millisecond, 5, 0},  // 新窗口开始时前一窗口权重为 1
+		{500 * time.Millisecond, 10, 5}, // 过半：10×0.5 + 0，还能放行 5 个
+		{500 * time.Millisecond, 10, 5}, // 下一窗口开始：前一窗口 5 个，权重为 1
+		{2 * time.Second, 20, 10},       // 空闲两个窗口后完全恢复
+		{999 * time.Millisecond, 1, 0},  // 10×0.001 + 10 > 10
+	}
+	for i, s := range steps {
+		f.Advance(s.advance)
+		if got := allowN(w, s.tries); got != s.want {
+			t.Errorf("step %d (+%v): allowed %d of %d, want %d", i, s.advance, got, s.tries, s.want)
+		}
+	}
+
+	// 旧窗口被清理，只保留当前和前一个窗口
+	f.Advance(10 * time.Second)
+	w.Allow()
+	if n := len(w.counts); n > 2 {
+		t.Errorf("tracking %d windows, want <= 2", n)
+	}
+}
+
+func TestSlidingWindowReserve(t *testing.T) {
+	w, f := fakeWindow(t, 10, time.Second)
+	allowN(w, 10)
+
+	// 当前窗口已满：下一窗口中前一窗口的权重降到 0.9 时恰好放得下
+	r := w.Reserve()
+	want := 1100 * time.Millisecond
+	if !r.OK() || r.Delay() != want {
+		t.Fatalf("Reserve = %t, %v; want %v", r.OK(), r.Delay(), want)
+	}
+	// 预订记入将来的窗口，Cancel 后归还
+	r.Cancel()
+	f.Advance(want)
+	if got := allowN(w, 2); got != 1 {
+		t.Errorf("allowed %d at reserved time, want 1", got)
+	}
+
+	zero, _ := fakeWindow(t, 0, time.Second)
+	if r := zero.Reserve(); r.OK() {
+		t.Error("Reserve with limit 0 = ok")
+	}
+}
@@ -1,10 +1,28 @@
 package main
 
 import (
+	"context"
+	_ "embed"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
+
+	"Standard_Library/clock"
+	"Standard_Library/cron"
+	"Standard_Library/ratelimit"
+	"Standard_Library/timewheel"
+	"Standard_Library/timex"
 )
 
+// clk 计时器、延时都通过 Clock 接口获取，测试时可以换成 clock.NewFake
+var clk clock.Clock = clock.Real()
+
+// holidaysYAML 节假日表随程序一起编译，也可以用 timex.LoadCalendar 从文件加载
+//
+//go:embed holidays.yaml
+var holidaysYAML []byte
+
 func main() {
 	// ============================= 1. 时间获取与基础操作 ====================
 
@@ -56,9 +74,9 @@ func main() {
 
 	// 5.1 一次性计时器
 	fmt.Println("启动2秒计时器...")
-	timer := time.NewTimer(2 * time.Second)
+	timer := clk.NewTimer(2 * time.Second)
 	go func() {
-		<-timer.C // 阻塞直到计时器触发
+		<-timer.C() // 阻塞直到计时器触发
 		fmt.Println("计时器触发!")
 	}()
 	// 停止计时器(如果还没触发)
@@ -67,11 +85,11 @@ func main() {
 
 	// 6.1 周期性定时器
 	fmt.Println("启动定时器(3次触发)...")
-	ticker := time.NewTicker(1 * time.Second)
+	ticker := clk.NewTicker(1 * time.Second)
 
 	go func() {
 		count := 0
-		for t := range ticker.C {
+		for t := range ticker.C() {
 			fmt.Printf("定时器触发: %v\n", t.Format("15:04:05"))
 			count++
 			if count >= 3 {
@@ -83,15 +101,15 @@ func main() {
 	// ============================= 7. 延时操作 ====================
 
 	// 7.1 使用Sleep延时
-	start := time.Now()
+	start := clk.Now()
 	fmt.Printf("开始Sleep: %v\n", start.Format("15:04:05.000"))
-	time.Sleep(1500 * time.Millisecond)
-	end := time.Now()
+	clk.Sleep(1500 * time.Millisecond)
+	end := clk.Now()
 	fmt.Printf("结束Sleep: %v, 实际休眠: %v\n",
 		end.Format("15:04:05.000"), end.Sub(start))
 	// 7.2 使用After延时 - 返回一个channel
 	fmt.Println("等待1秒...")
-	<-time.After(1 * time.Second)
+	<-clk.After(1 * time.Second)
 	fmt.Println("1秒后!")
 	//============================= 8. 时间戳操作 ====================
 
@@ -105,6 +123,54 @@ func main() {
 	// 8.2 从时间戳创建时间
 	fromUnix := time.Unix(unixSec, 0)
 	fmt.Printf("从时间戳创建: %v\n", fromUnix)
+	// ============================= 9. 限流控制节奏 ====================
+
+	// 9.1 令牌桶代替Ticker：每秒4个令牌，允许2个突发
+	limiter := ratelimit.NewTokenBucket(4, 2)
+	paceStart := time.Now()
+	for i := 1; i <= 5; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			fmt.Printf("限流等待失败: %v\n", err)
+			break
+		}
+		fmt.Printf("第%d个任务, 距开始: %v\n", i, time.Since(paceStart).Round(10*time.Millisecond))
+	}
+	// 9.2 非阻塞判断
+	fmt.Printf("立即放行: %t\n", limiter.Allow())
+	// ============================= 10. 假时钟测试超时逻辑 ====================
+
+	// 10.1 任务耗时3秒、超时2秒：推进假时间即可得到结果，不需要真的等待
+	fake := clock.NewFake(time.Time{})
+	realStart := time.Now()
+	timeoutCtx, cancel := fake.WithTimeout(context.Background(), 2*time.Second)
+	result := make(chan error, 1)
+	go func() {
+		result <- processWithTimeout(timeoutCtx, fake, "slow-task", 3*time.Second)
+	}()
+	fake.BlockUntil(2) // 超时计时器和任务计时器都已注册
+	fake.Advance(2 * time.Second)
+	fmt.Printf("假时钟超时: %v, 假时间: %v, 实际耗时: %v\n",
+		<-result, fake.Now().Format("15:04:05"), time.Since(realStart).Round(time.Millisecond))
+	cancel()
+
+	// 10.2 假Ticker：每次 Advance 一分钟触发一次
+	fakeTicker := fake.NewTicker(time.Minute)
+	for range 3 {
+		fake.Advance(time.Minute)
+		fmt.Printf("假定时器触发: %v\n", (<-fakeTicker.C()).Format("15:04:05"))
+	}
+	fakeTicker.Stop()
+	// ============================= 11. Cron定时任务 ====================
+
+	cronSchedulerDemo()
+	// ============================= 12. 时间轮管理大量超时 ====================
+
+	timingWheelDemo()
+	// 100万个等待中的超时下时间轮与 time.AfterFunc 的对比见 timewheel/wheel_test.go：
+	//   go test -bench . -benchmem ./timewheel
+	// ============================= 13. 工作日与人性化时长 ====================
+
+	timexDemo()
 	// 等待所有goroutine完成
 	time.Sleep(5 * time.Second)
 	fmt.Println("程序结束")
@@ -120,11 +186,219 @@ func main() {
 	   8. 定时器Ticker: 周期性定时器，需要手动Stop()停止
 	   9. 延时操作: Sleep() 阻塞当前goroutine，After() 返回触发channel
 	   10. 时间戳: Unix()系列方法获取时间戳，Unix()从时间戳创建时间
+	   11. 限流: ratelimit令牌桶的Wait()按速率放行，比Ticker更适合突发+平均速率控制
+	   12. 时钟抽象: 通过clock.Clock获取时间和计时器，测试用FakeClock.Advance()推进时间
+	   13. Cron调度: 5/6段表达式、@every、CRON_TZ时区，重叠策略(跳过/排队)、抖动和panic恢复
+	   14. 时间轮: 大量超时按tick分槽，添加/取消O(1)，精度换取远低于time.AfterFunc的开销
+	   15. timex: 按时区求日/周/月边界，YAML节假日日历做工作日加减，"1d2h"式时长和strftime布局
 
 	   注意:
 	   - 格式化必须使用Go特定时间模板
 	   - Timer和Ticker使用后要及时Stop避免资源泄露
 	   - 时间操作要考虑时区影响
 	   - 所有时间操作都是线程安全的
+	   - 使用假时钟时先BlockUntil()等待计时器注册，再Advance()
+	   - 按天/按月递增用AddDate或timex.DateRange，夏令时切换日不是24小时
 	*/
 }
+
+// processWithTimeout 模拟耗时 work 的任务，ctx 先结束则放弃
+func processWithTimeout(ctx context.Context, clk clock.Clock, taskName string, work time.Duration) error {
+	timer := clk.NewTimer(work)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		fmt.Printf("%s: 任务完成\n", taskName)
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", taskName, ctx.Err())
+	}
+}
+
+// cronSchedulerDemo 在假时钟上运行调度器，几十秒的调度在瞬间完成
+func cronSchedulerDemo() {
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	// 2024-01-05 是周五
+	fake := clock.NewFake(time.Date(2024, 1, 5, 8, 59, 0, 0, shanghai))
+	scheduler := cron.New(cron.Options{
+		Clock:    fake,
+		Location: shanghai,
+		OnError: func(name string, err error) {
+			var panicErr *cron.PanicError
+			if errors.As(err, &panicErr) {
+				fmt.Printf("任务 %s panic已恢复: %v\n", name, panicErr.Value)
+			}
+		},
+	})
+
+	// 11.1 工作日9点的日报，使用 CRON_TZ 指定时区
+	report, _ := scheduler.AddFunc("CRON_TZ=Asia/Shanghai 0 9 * * MON-FRI", func(ctx context.Context) error {
+		return nil
+	}, cron.JobOptions{Name: "日报"})
+	for _, t := range scheduler.NextRuns(report, 3) {
+		fmt.Printf("日报计划: %s\n", t.Format("2006-01-02 Mon 15:04"))
+	}
+
+	// 11.2 上一次还没结束：心跳跳过本次，导出排队执行
+	release := make(chan struct{})
+	var exports sync.WaitGroup
+	exports.Add(3)
+	scheduler.AddFunc("@every 20s", func(ctx context.Context) error {
+		<-release
+		return nil
+	}, cron.JobOptions{Name: "心跳", Overlap: cron.SkipIfRunning})
+	scheduler.AddFunc("*/20 * * * * *", func(ctx context.Context) error {
+		defer exports.Done()
+		<-release
+		return nil
+	}, cron.JobOptions{Name: "导出", Overlap: cron.Queue})
+
+	// 11.3 panic的任务不影响调度器
+	scheduler.AddFunc("30 * * * * *", func(ctx context.Context) error {
+		panic("磁盘已满")
+	}, cron.JobOptions{Name: "清理"})
+
+	// 所有任务添加完成后再启动，每次推进前等待调度器注册好计时器
+	scheduler.Start()
+	for range 6 {
+		fake.BlockUntil(1)
+		fake.Advance(10 * time.Second)
+	}
+	close(release)
+	exports.Wait()
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := scheduler.Stop(stopCtx); err != nil {
+		fmt.Printf("停止调度器: %v\n", err)
+	}
+	for _, e := range scheduler.Entries() {
+		fmt.Printf("%s: 运行%d次, 跳过%d次, 下次 %s\n", e.Name, e.Runs, e.Skipped, e.Next.Format("01-02 15:04:05"))
+	}
+}
+
+// timingWheelDemo 每个请求一个超时：请求完成时取消，未完成的由时间轮批量触发
+func timingWheelDemo() {
+	var (
+		mu       sync.Mutex
+		timedOut []string
+		expired  sync.WaitGroup
+	)
+	wheel := timewheel.New(timewheel.Options{
+		Tick: 10 * time.Millisecond,
+		OnExpire: func(fns []func()) {
+			fmt.Printf("本tick到期 %d 个超时\n", len(fns))
+			for _, fn := range fns {
+				fn()
+			}
+		},
+	})
+	defer wheel.Stop()
+
+	// 12.1 5个请求超时100ms，其中偶数请求在超时前完成
+	handles := make([]timewheel.Handle, 5)
+	for i := range handles {
+		name := fmt.Sprintf("req-%d", i)
+		expired.Add(1)
+		h, err := wheel.Schedule(100*time.Millisecond, func() {
+			defer expired.Done()
+			mu.Lock()
+			timedOut = append(timedOut, name)
+			mu.Unlock()
+		})
+		if err != nil {
+			expired.Done()
+			fmt.Println("添加超时失败:", err)
+			continue
+		}
+		handles[i] = h
+	}
+	for i := 0; i < len(handles); i += 2 {
+		if handles[i].Cancel() {
+			expired.Done()
+		}
+	}
+	fmt.Printf("等待中的超时: %d\n", wheel.Len())
+
+	expired.Wait()
+	fmt.Printf("超时的请求: %v\n", timedOut)
+}
+
+// timexDemo 报表边界、工作日计算、时长解析与格式化
+func timexDemo() {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		fmt.Println("加载时区失败:", err)
+		return
+	}
+
+	// 13.1 同一时刻在不同时区属于不同的一天
+	instant := time.Date(2024, 9, 30, 18, 30, 0, 0, time.UTC)
+	fmt.Printf("UTC当天开始: %v\n", timex.StartOfDay(instant, time.UTC).Format(time.DateTime))
+	fmt.Printf("上海当天开始: %v\n", timex.StartOfDay(instant, shanghai).Format(time.DateTime))
+	fmt.Printf("上海本周(周一开始): %v ~ %v\n",
+		timex.StartOfWeek(instant, shanghai, time.Monday).Format(time.DateOnly),
+		timex.EndOfWeek(instant, shanghai, time.Monday).Format(time.DateOnly))
+	fmt.Printf("上海本月结束: %v\n", timex.EndOfMonth(instant, shanghai).Format("2006-01-02 15:04:05.000"))
+
+	// 13.2 节假日日历：国庆放假、调休上班
+	cal, err := timex.ParseCalendar(holidaysYAML)
+	if err != nil {
+		fmt.Println("加载日历失败:", err)
+		return
+	}
+	submitted := time.Date(2024, 9, 27, 15, 0, 0, 0, shanghai)
+	deadline, err := cal.AddBusinessDays(submitted, 3)
+	if err != nil {
+		fmt.Println("计算截止日失败:", err)
+		return
+	}
+	fmt.Printf("%s 提交，3个工作日后截止: %v\n", cal.Name, deadline.Format("2006-01-02 Mon 15:04"))
+	for d := range timex.DateRange(time.Date(2024, 9, 28, 0, 0, 0, 0, shanghai), time.Date(2024, 10, 9, 0, 0, 0, 0, shanghai), 0, 0, 1) {
+		status := "上班"
+		if name, ok := cal.Holiday(d); ok {
+			status = "放假 " + name
+		} else if !cal.IsBusinessDay(d) {
+			status = "周末"
+		}
+		fmt.Printf("  %v %s\n", d.Format("01-02 Mon"), status)
+	}
+	fmt.Printf("9月的工作日: %d 天\n", cal.BusinessDaysBetween(
+		time.Date(2024, 9, 1, 0, 0, 0, 0, shanghai), time.Date(2024, 10, 1, 0, 0, 0, 0, shanghai)))
+
+	// 13.3 配置里的时长：支持天和周
+	for _, s := range []string{"1d2h", "3 weeks", "2 days 4 hours", "1.5h", "1 hour, 30 minutes", "2x"} {
+		d, err := timex.ParseDuration(s)
+		if err != nil {
+			fmt.Printf("  %-20q 错误: %v\n", s, err)
+			continue
+		}
+		fmt.Printf("  %-20q = %-12v 紧凑: %-10s 展示: %s\n", s, d, timex.FormatDuration(d), timex.Humanize(d, 2))
+	}
+
+	// 13.4 每月最后一天对账：1月31日按月递增不会漂移到3月2日
+	var monthEnds []string
+	for d := range timex.DateRange(time.Date(2024, 1, 31, 23, 0, 0, 0, shanghai), time.Date(2024, 6, 1, 0, 0, 0, 0, shanghai), 0, 1, 0) {
+		monthEnds = append(monthEnds, d.Format("01-02"))
+	}
+	fmt.Println("月末对账:", monthEnds)
+	var slots []string
+	for t := range timex.Range(submitted, submitted.Add(2*time.Hour), 30*time.Minute) {
+		slots = append(slots, t.Format("15:04"))
+	}
+	fmt.Println("每30分钟:", slots)
+
+	// 13.5 Go布局和strftime布局都可以使用
+	for _, layout := range []string{"2006-01-02 15:04:05", "%Y-%m-%d %H:%M:%S", "%a %d %b %Y %I:%M %p"} {
+		s, err := timex.Format(deadline, layout)
+		if err != nil {
+			fmt.Println("格式化失败:", err)
+			continue
+		}
+		fmt.Printf("  %-24q -> %s\n", layout, s)
+	}
+	if t, err := timex.ParseInLocation("%Y/%m/%d %H:%M", "2024/10/08 09:30", shanghai); err == nil {
+		fmt.Println("strftime解析:", t)
+	}
+}
@@ -0,0 +1,301 @@
+// ============================= 1. 分层时间轮 ====================
+// 每个连接/请求一个 time.Timer 在百万级别时开销很大（每个Timer都进入运行时的堆）
+// 时间轮把到期时间按 tick 取整后放入槽位，添加、取消和重置都是 O(1)：
+// - 第0层有64个槽，每槽代表1个tick；第 i 层每槽代表 64^i 个tick
+// - 第0层转完一圈时，把上一层对应槽里的任务重新分配到下层（级联）
+// - 超出最大范围的任务先放在最高层，级联时重新计算
+// - 同一个tick到期的任务一次性交给 OnExpire 批量处理
+// 精度为一个tick：任务在到期后的下一个tick内触发，不会提前
+
+package timewheel
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"Standard_Library/clock"
+)
+
+const (
+	slotBits = 6
+	slots    = 1 << slotBits
+	slotMask = slots - 1
+)
+
+// ErrStopped 时间轮停止后再调度任务
+var ErrStopped = errors.New("timewheel: wheel stopped")
+
+// Options 时间轮选项
+type Options struct {
+	Tick   time.Duration // 时间精度，默认10ms
+	Levels int           // 层数，默认5层（10ms精度时约124天）
+	Clock  clock.Clock   // 默认 clock.Real()
+
+	// OnExpire 批量处理同一tick到期的任务，例如交给工作池
+	// 为 nil 时在时间轮的goroutine中依次调用，任务应尽快返回
+	OnExpire func(fns []func())
+}
+
+// Wheel 分层时间轮，并发安全
+type Wheel struct {
+	opts   Options
+	clock  clock.Clock
+	start  time.Time
+	levels [][slots]bucket
+
+	mu      sync.Mutex
+	current uint64 // 已处理的tick数
+	pending int
+	stopped bool
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// bucket 槽位：侵入式双向链表的哨兵节点
+type bucket struct {
+	head entry
+}
+
+type entry struct {
+	prev, next *entry
+	bucket     *bucket
+	expire     uint64 // 到期的tick
+	fn         func()
+}
+
+// Handle 已调度的任务
+type Handle struct {
+	w *Wheel
+	e *entry
+}
+
+// New 创建并启动时间轮
+func New(opts Options) *Wheel {
+	if opts.Tick <= 0 {
+		opts.Tick = 10 * time.Millisecond
+	}
+	if opts.Levels <= 0 {
+		opts.Levels = 5
+	}
+	opts.Levels = min(opts.Levels, 64/slotBits)
+	if opts.Clock == nil {
+		opts.Clock = clock.Real()
+	}
+
+	w := &Wheel{
+		opts:   opts,
+		clock:  opts.Clock,
+		levels: make([][slots]bucket, opts.Levels),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	for l := range w.levels {
+		for s := range w.levels[l] {
+			b := &w.levels[l][s]
+			b.head.prev, b.head.next = &b.head, &b.head
+		}
+	}
+	w.start = w.clock.Now()
+
+	go w.run(w.clock.NewTicker(opts.Tick))
+	return w
+}
+
+// Schedule 在 d 之后执行 fn；时间轮已停止时返回 ErrStopped，fn 永远不会执行
+func (w *Wheel) Schedule(d time.Duration, fn func()) (Handle, error) {
+	e := &entry{fn: fn, expire: w.expireAfter(d)}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return Handle{}, ErrStopped
+	}
+	e.expire = max(e.expire, w.current+1)
+	w.addLocked(e)
+	w.pending++
+	return Handle{w: w, e: e}, nil
+}
+
+// expireAfter 从现在起 d 之后对应的tick
+func (w *Wheel) expireAfter(d time.Duration) uint64 {
+	// 按实际经过的时间向上取整，推进goroutine落后时也不会提前触发
+	elapsed := w.clock.Now().Sub(w.start) + max(d, 0)
+	return uint64((elapsed + w.opts.Tick - 1) / w.opts.Tick)
+}
+
+// Reset 把等待中的任务改为从现在起 d 之后到期；任务已经到期或已被取消时返回 false，不会重新调度
+func (h Handle) Reset(d time.Duration) bool {
+	if h.e == nil {
+		return false
+	}
+	expire := h.w.expireAfter(d)
+
+	h.w.mu.Lock()
+	defer h.w.mu.Unlock()
+	if h.e.bucket == nil {
+		return false
+	}
+	h.e.unlink()
+	h.e.expire = max(expire, h.w.current+1)
+	h.w.addLocked(h.e)
+	return true
+}
+
+// Cancel 取消任务，任务已经到期或已被取消时返回 false
+func (h Handle) Cancel() bool {
+	if h.e == nil {
+		return false
+	}
+	h.w.mu.Lock()
+	defer h.w.mu.Unlock()
+	if h.e.bucket == nil {
+		return false
+	}
+	h.e.unlink()
+	h.w.pending--
+	return true
+}
+
+// Len 等待中的任务数
+func (w *Wheel) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.pending
+}
+
+// Stop 停止时间轮，返回被丢弃的等待任务数；之后的 Schedule 返回 ErrStopped
+func (w *Wheel) Stop() int {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopped = true
+	dropped := w.pending
+	for l := range w.levels {
+		for s := range w.levels[l] {
+			b := &w.levels[l][s]
+			for e := b.head.next; e != &b.head; {
+				next := e.next
+				e.prev, e.next, e.bucket = nil, nil, nil
+				e = next
+			}
+			b.head.prev, b.head.next = &b.head, &b.head
+		}
+	}
+	w.pending = 0
+	return dropped
+}
+
+// run 每个tick按实际经过的时间推进，goroutine被延迟调度时会追赶
+func (w *Wheel) run(ticker clock.Ticker) {
+	defer close(w.done)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C():
+			// 使用当前时间而不是tick携带的时间：落后时丢弃的tick也能一并追上
+			target := uint64(w.clock.Since(w.start) / w.opts.Tick)
+			w.advanceTo(target)
+		}
+	}
+}
+
+// advanceTo 逐tick推进到 target，每个tick的到期任务批量执行
+func (w *Wheel) advanceTo(target uint64) {
+	for {
+		w.mu.Lock()
+		if w.current >= target {
+			w.mu.Unlock()
+			return
+		}
+		w.current++
+		w.cascadeLocked()
+		expired := w.expireLocked()
+		w.mu.Unlock()
+
+		if len(expired) == 0 {
+			continue
+		}
+		if w.opts.OnExpire != nil {
+			w.opts.OnExpire(expired)
+			continue
+		}
+		for _, fn := range expired {
+			fn()
+		}
+	}
+}
+
+// cascadeLocked 低层转完一圈时，把上一层当前槽的任务重新分配
+func (w *Wheel) cascadeLocked() {
+	for l := 1; l < len(w.levels); l++ {
+		if (w.current>>(slotBits*(l-1)))&slotMask != 0 {
+			return
+		}
+		idx := (w.current >> (slotBits * l)) & slotMask
+		b := &w.levels[l][idx]
+		for e := b.head.next; e != &b.head; {
+			next := e.next
+			e.unlink()
+			w.addLocked(e)
+			e = next
+		}
+	}
+}
+
+// expireLocked 取出第0层当前槽的所有任务
+func (w *Wheel) expireLocked() []func() {
+	b := &w.levels[0][w.current&slotMask]
+	var fns []func()
+	for e := b.head.next; e != &b.head; {
+		next := e.next
+		e.unlink()
+		if e.expire > w.current {
+			w.addLocked(e) // 超出范围的任务只有一层时会落到这里
+		} else {
+			fns = append(fns, e.fn)
+		}
+		e = next
+	}
+	w.pending -= len(fns)
+	return fns
+}
+
+// addLocked 按剩余tick数选择层，按到期tick的对应位选择槽
+func (w *Wheel) addLocked(e *entry) {
+	// 级联时恰好在当前tick到期：放入马上要处理的第0层当前槽
+	expire := max(e.expire, w.current)
+	delta := expire - w.current
+
+	level := 0
+	for level < len(w.levels)-1 && delta >= 1<<(slotBits*(level+1)) {
+		level++
+	}
+	if maxDelta := uint64(1)<<(slotBits*len(w.levels)) - 1; delta > maxDelta {
+		expire = w.current + maxDelta // 超出范围：先放在最高层，级联时重新计算
+	}
+
+	idx := (expire >> (slotBits * level)) & slotMask
+	w.levels[level][idx].push(e)
+}
+
+func (b *bucket) push(e *entry) {
+	e.bucket = b
+	e.prev = b.head.prev
+	e.next = &b.head
+	b.head.prev.next = e
+	b.head.prev = e
+}
+
+func (e *entry) unlink() {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.prev, e.next, e.bucket = nil, nil, nil
+}
@@ -0,0 +1,322 @@
+package timewheel
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"Standard_Library/clock"
+)
+
+func TestExpireWithFakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Time{})
+	w := New(Options{Tick: 10 * time.Millisecond, Clock: fake})
+	defer w.Stop()
+
+	fired := make(chan struct{})
+	if _, err := w.Schedule(25*time.Millisecond, func() { close(fired) }); err != nil {
+		t.Fatal(err)
+	}
+	canceled, _ := w.Schedule(25*time.Millisecond, func() { t.Error("canceled task ran") })
+	if !canceled.Cancel() {
+		t.Fatal("Cancel of pending task = false")
+	}
+
+	// 精度为一个tick：到期后的下一个tick内触发，不会提前
+	fake.Advance(20 * time.Millisecond)
+	select {
+	case <-fired:
+		t.Fatal("fired before its deadline")
+	case <-time.After(20 * time.Millisecond):
+	}
+	fake.Advance(10 * time.Millisecond)
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("not fired one tick after its deadline")
+	}
+	if canceled.Cancel() {
+		t.Fatal("second Cancel = true")
+	}
+}
+
+func TestScheduleAfterStop(t *testing.T) {
+	w := New(Options{Clock: clock.NewFake(time.Time{})})
+	w.Schedule(time.Hour, func() {})
+	if dropped := w.Stop(); dropped != 1 {
+		t.Fatalf("Stop dropped %d; want 1", dropped)
+	}
+
+	h, err := w.Schedule(time.Second, func() {})
+	if !errors.Is(err, ErrStopped) {
+		t.Fatalf("Schedule after Stop = %v; want ErrStopped", err)
+	}
+	if h.Cancel() {
+		t.Fatal("Cancel on handle from stopped wheel = true")
+	}
+	if w.Len() != 0 {
+		t.Fatalf("Len after Stop = %d; want 0", w.Len())
+	}
+}
+
+// manualClock 时间来自假时钟，但时间轮的ticker永远不会触发：
+// 测试用 step 在当前goroutine中同步推进，任务也在当前goroutine中执行
+type manualClock struct{ *clock.FakeClock }
+
+func (manualClock) NewTicker(d time.Duration) clock.Ticker {
+	return clock.NewFake(time.Time{}).NewTicker(d)
+}
+
+const tick = time.Millisecond
+
+func newManual(t *testing.T, opts Options) (*Wheel, *clock.FakeClock) {
+	fake := clock.NewFake(time.Time{})
+	opts.Tick, opts.Clock = tick, manualClock{fake}
+	w := New(opts)
+	t.Cleanup(func() { w.Stop() })
+	return w, fake
+}
+
+// step 时间前进 n 个tick，并同步处理到期的任务
+func step(w *Wheel, fake *clock.FakeClock, n int) {
+	fake.Advance(time.Duration(n) * tick)
+	w.advanceTo(uint64(fake.Since(w.start) / tick))
+}
+
+// recordAt 调度 delay 个tick后的任务，执行时记录当时的tick
+func recordAt(t *testing.T, w *Wheel, delay uint64, fired map[uint64]uint64) Handle {
+	t.Helper()
+	h, err := w.Schedule(time.Duration(delay)*tick, func() { fired[delay] = w.current })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// TestCascade 超过第0层范围的任务逐层级联，恰好在到期的tick触发
+func TestCascade(t *testing.T) {
+	w, fake := newManual(t, Options{Levels: 4})
+	delays := []uint64{1, 63, 64, 65, 100, 4095, 4096, 4097, 5000, 262143}
+	fired := make(map[uint64]uint64)
+	for _, d := range delays {
+		recordAt(t, w, d, fired)
+	}
+
+	// 从非对齐的起点调度，级联边界与到期tick不重合
+	step(w, fake, 37)
+	w.Schedule(5000*tick, func() { fired[0] = w.current })
+
+	step(w, fake, 270000)
+	for _, d := range delays {
+		if got, ok := fired[d]; !ok || got != d {
+			t.Errorf("delay %d ticks fired at tick %d (ran=%t)", d, got, ok)
+		}
+	}
+	if got := fired[0]; got != 5037 {
+		t.Errorf("task scheduled at tick 37 for 5000 ticks fired at %d, want 5037", got)
+	}
+	if n := w.Len(); n != 0 {
+		t.Errorf("Len = %d after all fired", n)
+	}
+}
+
+// TestOverflow 超出最高层范围的任务先放在最高层，级联时重新计算
+func TestOverflow(t *testing.T) {
+	for _, levels := range []int{1, 2} {
+		w, fake := newManual(t, Options{Levels: levels})
+		maxDelta := uint64(1)<<(slotBits*levels) - 1
+		delays := []uint64{maxDelta, maxDelta + 1, 3*maxDelta + 7, 10000}
+		fired := make(map[uint64]uint64)
+		for _, d := range delays {
+			recordAt(t, w, d, fired)
+		}
+		step(w, fake, 13000)
+		for _, d := range delays {
+			if got, ok := fired[d]; !ok || got != d {
+				t.Errorf("levels=%d: delay %d fired at %d (ran=%t)", levels, d, got, ok)
+			}
+		}
+	}
+}
+
+func TestCancelCascading(t *testing.T) {
+	w, fake := newManual(t, Options{Levels: 4})
+	fired := make(map[uint64]uint64)
+	h := recordAt(t, w, 5000, fired)
+
+	// 第4096个tick时任务从第2层级联到第1层，此时取消
+	step(w, fake, 4100)
+	if !h.Cancel() || w.Len() != 0 {
+		t.Fatalf("Cancel after cascade: Len = %d", w.Len())
+	}
+	step(w, fake, 2000)
+	if len(fired) != 0 {
+		t.Errorf("canceled task fired: %v", fired)
+	}
+	if h.Cancel() || h.Reset(tick) {
+		t.Error("Cancel/Reset of canceled task = true")
+	}
+}
+
+func TestResetCascading(t *testing.T) {
+	w, fake := newManual(t, Options{Levels: 4})
+	var at []uint64
+	h, _ := w.Schedule(5000*tick, func() { at = append(at, w.current) })
+
+	// 第4992个tick时任务已级联到第0层；推迟到 100 个tick之后
+	step(w, fake, 4993)
+	if !h.Reset(100 * tick) {
+		t.Fatal("Reset of pending task = false")
+	}
+	step(w, fake, 50) // 原到期时间 5000 已过
+	if len(at) != 0 {
+		t.Fatalf("fired at original deadline: %v", at)
+	}
+
+	// 再改为更远的到期时间：从第0层移回高层，之后重新级联
+	if !h.Reset(70000 * tick) {
+		t.Fatal("second Reset = false")
+	}
+	step(w, fake, 70000-1)
+	if len(at) != 0 {
+		t.Fatalf("fired early: %v", at)
+	}
+	step(w, fake, 1)
+	if want := uint64(5043 + 70000); len(at) != 1 || at[0] != want {
+		t.Fatalf("fired at %v, want [%d]", at, want)
+	}
+
+	// 已到期的任务不能重置
+	if h.Reset(tick) || w.Len() != 0 {
+		t.Errorf("Reset after fire = true or Len = %d", w.Len())
+	}
+
+	// 缩短：从高层移到第0层
+	var short []uint64
+	h, _ = w.Schedule(100000*tick, func() { short = append(short, w.current) })
+	h.Reset(3 * tick)
+	step(w, fake, 3)
+	if want := w.current; len(short) != 1 || short[0] != want {
+		t.Errorf("shortened task fired at %v, want [%d]", short, want)
+	}
+}
+
+// TestOnExpireBatching 同一tick到期的任务一次性交给 OnExpire
+func TestOnExpireBatching(t *testing.T) {
+	type batch struct {
+		tick uint64
+		size int
+	}
+	var (
+		batches []batch
+		ran     int
+		w       *Wheel
+	)
+	w, fake := newManual(t, Options{OnExpire: func(fns []func()) {
+		batches = append(batches, batch{w.current, len(fns)})
+		for _, fn := range fns {
+			fn()
+		}
+	}})
+	count := func() { ran++ }
+
+	// 9.2、9.9、10 个tick都向上取整为第10个tick
+	for _, d := range []time.Duration{9200 * time.Microsecond, 9900 * time.Microsecond, 10 * tick} {
+		w.Schedule(d, count)
+	}
+	w.Schedule(11*tick, count)
+	w.Schedule(200*tick, count) // 级联后到期的任务同样成批
+	w.Schedule(200*tick, count)
+
+	step(w, fake, 300)
+	want := []batch{{10, 3}, {11, 1}, {200, 2}}
+	if len(batches) != len(want) {
+		t.Fatalf("batches = %v, want %v", batches, want)
+	}
+	for i := range want {
+		if batches[i] != want[i] {
+			t.Errorf("batch %d = %+v, want %+v", i, batches[i], want[i])
+		}
+	}
+	if ran != 6 || w.Len() != 0 {
+		t.Errorf("ran %d tasks, Len %d; want 6, 0", ran, w.Len())
+	}
+}
+
+// 100万个等待中的超时，分布在 1~60 秒
+const pending = 1_000_000
+
+func durations() []time.Duration {
+	ds := make([]time.Duration, pending)
+	for i := range ds {
+		ds[i] = time.Duration(1+rand.IntN(60)) * time.Second
+	}
+	return ds
+}
+
+func noop() {}
+
+// BenchmarkWheel 已有100万个等待中的超时时，单次添加+取消的开销
+func BenchmarkWheel(b *testing.B) {
+	ds := durations()
+	w := New(Options{})
+	defer w.Stop()
+	for _, d := range ds {
+		w.Schedule(d, noop)
+	}
+
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		h, _ := w.Schedule(ds[i%pending], noop)
+		h.Cancel()
+	}
+}
+
+// BenchmarkAfterFunc 同样负载下 time.AfterFunc 的添加+取消，作为对照
+func BenchmarkAfterFunc(b *testing.B) {
+	ds := durations()
+	timers := make([]*time.Timer, pending)
+	for i, d := range ds {
+		timers[i] = time.AfterFunc(d, noop)
+	}
+	defer func() {
+		for _, t := range timers {
+			t.Stop()
+		}
+	}()
+
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		time.AfterFunc(ds[i%pending], noop).Stop()
+	}
+}
+
+// BenchmarkScheduleMillion 一次性添加再全部取消100万个超时
+func BenchmarkScheduleMillion(b *testing.B) {
+	ds := durations()
+	b.Run("timewheel", func(b *testing.B) {
+		handles := make([]Handle, pending)
+		for b.Loop() {
+			w := New(Options{})
+			for i, d := range ds {
+				handles[i], _ = w.Schedule(d, noop)
+			}
+			for _, h := range handles {
+				h.Cancel()
+			}
+			w.Stop()
+		}
+	})
+	b.Run("time.AfterFunc", func(b *testing.B) {
+		timers := make([]*time.Timer, pending)
+		for b.Loop() {
+			for i, d := range ds {
+				timers[i] = time.AfterFunc(d, noop)
+			}
+			for _, t := range timers {
+				t.Stop()
+			}
+		}
+	})
+}
@@ -0,0 +1,255 @@
+// ============================= 5. 工作日日历 ====================
+// 工作日 = 非周末 且 非节假日，或者被指定为调休上班的日子
+// 节假日表从YAML加载，格式：
+//
+//	name: CN-2024
+//	location: Asia/Shanghai
+//	weekend: [saturday, sunday]
+//	holidays:
+//	  - date: 2024-05-01
+//	    name: 劳动节
+//	  - from: 2024-10-01
+//	    to: 2024-10-07
+//	    name: 国庆节
+//	workdays: [2024-09-29, 2024-10-12]  # 调休上班
+//
+// 日期按日历的时区判断，时间部分在加减工作日时保持不变
+
+package timex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const dateLayout = "2006-01-02"
+
+// ErrNoBusinessDay 往前或往后10年内都找不到工作日，通常是节假日表配置错误
+var ErrNoBusinessDay = errors.New("no business day within 10 years")
+
+// date 不含时间和时区的日历日期
+type date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+// Calendar 工作日日历，创建后只读，可以并发使用
+type Calendar struct {
+	Name     string
+	Location *time.Location
+
+	weekend  [7]bool
+	holidays map[date]string
+	workdays map[date]bool
+}
+
+// NewCalendar 创建周六、周日休息且没有节假日的日历
+func NewCalendar(name string, loc *time.Location) *Calendar {
+	c := &Calendar{
+		Name:     name,
+		Location: loc,
+		holidays: make(map[date]string),
+		workdays: make(map[date]bool),
+	}
+	c.weekend[time.Saturday] = true
+	c.weekend[time.Sunday] = true
+	return c
+}
+
+// AddHoliday 添加节假日
+func (c *Calendar) AddHoliday(t time.Time, name string) {
+	c.holidays[c.dateOf(t)] = name
+}
+
+// AddWorkday 把周末或节假日标记为上班（调休）
+func (c *Calendar) AddWorkday(t time.Time) {
+	c.workdays[c.dateOf(t)] = true
+}
+
+// LoadCalendar 从YAML文件加载日历
+func LoadCalendar(path string) (*Calendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCalendar(data)
+}
+
+type calendarFile struct {
+	Name     string   `yaml:"name"`
+	Location string   `yaml:"location"`
+	Weekend  []string `yaml:"weekend"`
+	Holidays []struct {
+		Date string `yaml:"date"`
+		From string `yaml:"from"`
+		To   string `yaml:"to"`
+		Name string `yaml:"name"`
+	} `yaml:"holidays"`
+	Workdays []string `yaml:"workdays"`
+}
+
+// ParseCalendar 解析YAML格式的日历
+func ParseCalendar(data []byte) (*Calendar, error) {
+	var f calendarFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("timex: parse calendar: %w", err)
+	}
+
+	loc := time.UTC
+	if f.Location != "" {
+		var err error
+		if loc, err = time.LoadLocation(f.Location); err != nil {
+			return nil, fmt.Errorf("timex: calendar %s: %w", f.Name, err)
+		}
+	}
+	c := NewCalendar(f.Name, loc)
+
+	if f.Weekend != nil {
+		c.weekend = [7]bool{}
+		for _, name := range f.Weekend {
+			wd, err := parseWeekday(name)
+			if err != nil {
+				return nil, fmt.Errorf("timex: calendar %s: %w", f.Name, err)
+			}
+			c.weekend[wd] = true
+		}
+		if c.weekend == [7]bool{true, true, true, true, true, true, true} {
+			return nil, fmt.Errorf("timex: calendar %s: every day is a weekend", f.Name)
+		}
+	}
+
+	for _, h := range f.Holidays {
+		from, to := h.From, h.To
+		if h.Date != "" {
+			from, to = h.Date, h.Date
+		}
+		start, err := parseDate(from, loc)
+		if err != nil {
+			return nil, fmt.Errorf("timex: calendar %s: holiday %q: %w", f.Name, h.Name, err)
+		}
+		end, err := parseDate(to, loc)
+		if err != nil {
+			return nil, fmt.Errorf("timex: calendar %s: holiday %q: %w", f.Name, h.Name, err)
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("timex: calendar %s: holiday %q ends before it starts", f.Name, h.Name)
+		}
+		for d := start; !d.After(end); d = startOfDate(d.Year(), d.Month(), d.Day()+1, loc) {
+			c.AddHoliday(d, h.Name)
+		}
+	}
+
+	for _, s := range f.Workdays {
+		d, err := parseDate(s, loc)
+		if err != nil {
+			return nil, fmt.Errorf("timex: calendar %s: workday: %w", f.Name, err)
+		}
+		c.AddWorkday(d)
+	}
+	return c, nil
+}
+
+// parseDate 解析 2006-01-02 格式的日期，返回 loc 中该日的第一个时刻
+// time.ParseInLocation 在零点被夏令时跳过时会返回前一天
+func parseDate(s string, loc *time.Location) (time.Time, error) {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return startOfDate(t.Year(), t.Month(), t.Day(), loc), nil
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		full := strings.ToLower(wd.String())
+		if name == full || name == full[:3] {
+			return wd, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", name)
+}
+
+func (c *Calendar) dateOf(t time.Time) date {
+	y, m, d := t.In(c.Location).Date()
+	return date{y, m, d}
+}
+
+// IsBusinessDay t 所在日是否上班
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	d := c.dateOf(t)
+	if c.workdays[d] {
+		return true
+	}
+	if _, ok := c.holidays[d]; ok {
+		return false
+	}
+	return !c.weekend[t.In(c.Location).Weekday()]
+}
+
+// Holiday t 所在日的节假日名称
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+	name, ok := c.holidays[c.dateOf(t)]
+	return name, ok
+}
+
+// AddBusinessDays 前进（n>0）或后退（n<0）n 个工作日，保持时间部分不变
+// n==0 时若 t 不是工作日，顺延到下一个工作日；找不到工作日时返回 ErrNoBusinessDay
+func (c *Calendar) AddBusinessDays(t time.Time, n int) (time.Time, error) {
+	t = t.In(c.Location)
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	if n == 0 {
+		return c.roll(t, 1)
+	}
+	for ; n > 0; n-- {
+		var err error
+		if t, err = c.roll(t.AddDate(0, 0, step), step); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return t, nil
+}
+
+// NextBusinessDay t 之后的第一个工作日
+func (c *Calendar) NextBusinessDay(t time.Time) (time.Time, error) {
+	return c.AddBusinessDays(t, 1)
+}
+
+// BusinessDaysBetween [start, end) 之间的工作日数量，end 早于 start 时为负数
+func (c *Calendar) BusinessDaysBetween(start, end time.Time) int {
+	sign := 1
+	if end.Before(start) {
+		start, end, sign = end, start, -1
+	}
+	// 逐日取每天的第一个时刻，零点被夏令时跳过的日子也不会落到前一天
+	count := 0
+	last := StartOfDay(end, c.Location)
+	for d := StartOfDay(start, c.Location); d.Before(last); d = startOfDate(d.Year(), d.Month(), d.Day()+1, c.Location) {
+		if c.IsBusinessDay(d) {
+			count++
+		}
+	}
+	return sign * count
+}
+
+// roll 从 t 开始按 step 方向找到第一个工作日（包括 t 本身）
+// AddDate 按日历加减，夏令时切换日也保持时间部分不变
+func (c *Calendar) roll(t time.Time, step int) (time.Time, error) {
+	from := t
+	for range 3660 {
+		if c.IsBusinessDay(t) {
+			return t, nil
+		}
+		t = t.AddDate(0, 0, step)
+	}
+	return time.Time{}, fmt.Errorf("timex: calendar %s: %w of %s", c.Name, ErrNoBusinessDay, from.Format(dateLayout))
+}
@@ -0,0 +1,139 @@
+package timex
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddBusinessDays(t *testing.T) {
+	c, err := ParseCalendar([]byte(`
+name: test
+location: UTC
+holidays:
+  - from: 2024-10-01
+    to: 2024-10-07
+    name: 国庆节
+workdays: [2024-10-12]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 周五提交，跨过周末和国庆假期
+	got, err := c.AddBusinessDays(time.Date(2024, 9, 27, 15, 0, 0, 0, time.UTC), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 10, 9, 15, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("AddBusinessDays = %v; want %v", got, want)
+	}
+}
+
+// TestNoBusinessDay 节假日覆盖了整整10年时返回错误而不是panic
+func TestNoBusinessDay(t *testing.T) {
+	c, err := ParseCalendar([]byte(`
+name: closed
+location: UTC
+holidays:
+  - from: 2000-01-01
+    to: 2040-12-31
+    name: 停业
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := c.NextBusinessDay(from); !errors.Is(err, ErrNoBusinessDay) {
+		t.Fatalf("NextBusinessDay = %v; want ErrNoBusinessDay", err)
+	}
+	if _, err := c.AddBusinessDays(from, -1); !errors.Is(err, ErrNoBusinessDay) {
+		t.Fatalf("AddBusinessDays(-1) = %v; want ErrNoBusinessDay", err)
+	}
+}
+
+func TestParseCalendarWeekendWorkdays(t *testing.T) {
+	// 周五、周六休息；周六 2024-10-12 调休上班
+	c, err := ParseCalendar([]byte(`
+name: me
+location: UTC
+weekend: [fri, Saturday]
+workdays: [2024-10-12]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2024, 10, d, 12, 0, 0, 0, time.UTC) }
+	for d, want := range map[int]bool{
+		10: true,  // 周四
+		11: false, // 周五
+		12: true,  // 周六，调休
+		13: true,  // 周日
+		19: false, // 周六
+	} {
+		if got := c.IsBusinessDay(day(d)); got != want {
+			t.Errorf("IsBusinessDay(2024-10-%d) = %t; want %t", d, got, want)
+		}
+	}
+	// 周四往后一个工作日跳过周五，落在调休的周六
+	if got, err := c.AddBusinessDays(day(10), 1); err != nil || !got.Equal(day(12)) {
+		t.Errorf("AddBusinessDays = %v, %v; want %v", got, err, day(12))
+	}
+	if n := c.BusinessDaysBetween(day(10), day(20)); n != 7 {
+		t.Errorf("BusinessDaysBetween = %d; want 8", n)
+	}
+	if n := c.BusinessDaysBetween(day(20), day(10)); n != -7 {
+		t.Errorf("BusinessDaysBetween reversed = %d; want -8", n)
+	}
+}
+
+func TestParseCalendarErrors(t *testing.T) {
+	cases := []struct {
+		name, yaml, msg string
+	}{
+		{"AllWeekend", "weekend: [mon, tue, wed, thu, fri, sat, sun]", "every day is a weekend"},
+		{"BadWeekday", "weekend: [caturday]", `unknown weekday "caturday"`},
+		{"BadWorkday", "workdays: [2024-13-01]", "workday"},
+		{"BadHoliday", "holidays: [{date: tomorrow, name: x}]", `holiday "x"`},
+		{"HolidayReversed", "holidays: [{from: 2024-10-07, to: 2024-10-01, name: x}]", "ends before it starts"},
+		{"BadLocation", "location: Mars/Olympus", "Mars/Olympus"},
+		{"BadYAML", "weekend: {", "parse calendar"},
+	}
+	for _, c := range cases {
+		_, err := ParseCalendar([]byte(c.yaml))
+		if err == nil || !strings.Contains(err.Error(), c.msg) {
+			t.Errorf("%s: err = %v; want error containing %q", c.name, err, c.msg)
+		}
+	}
+}
+
+// TestCalendarSkippedMidnight 圣保罗 2018-11-04（周日）零点被跳过，日期仍对应到当天
+func TestCalendarSkippedMidnight(t *testing.T) {
+	sp := mustLoad(t, "America/Sao_Paulo")
+	c, err := ParseCalendar([]byte(`
+name: br
+location: America/Sao_Paulo
+holidays:
+  - from: 2018-11-03
+    to: 2018-11-05
+    name: 测试
+workdays: [2018-11-04]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2018, 11, d, 12, 0, 0, 0, sp) }
+	if name, ok := c.Holiday(day(5)); !ok || name != "测试" {
+		t.Errorf("Holiday(11-05) = %q, %t", name, ok)
+	}
+	if _, ok := c.Holiday(day(4)); !ok {
+		t.Error("holiday range skipped 11-04")
+	}
+	if !c.IsBusinessDay(day(4)) || c.IsBusinessDay(day(3)) {
+		t.Error("workday 2018-11-04 mapped to the wrong date")
+	}
+	// 11-01 周四、11-02 周五、11-04 调休、11-06 与 11-07
+	if n := c.BusinessDaysBetween(day(1), day(8)); n != 5 {
+		t.Errorf("BusinessDaysBetween = %d; want 5", n)
+	}
+}
@@ -0,0 +1,167 @@
+// ============================= 3. 人性化的时长解析和格式化 ====================
+// time.ParseDuration 不支持天和周，配置里常见的 "1d2h"、"3 weeks"、"2 days 4 hours" 都会报错
+// 这里的天固定为24小时、周为7天，不考虑夏令时
+
+package timex
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond, "nanosecond": time.Nanosecond, "nanoseconds": time.Nanosecond,
+	"us": time.Microsecond, "µs": time.Microsecond, "microsecond": time.Microsecond, "microseconds": time.Microsecond,
+	"ms": time.Millisecond, "millisecond": time.Millisecond, "milliseconds": time.Millisecond,
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": Day, "day": Day, "days": Day,
+	"w": Week, "week": Week, "weeks": Week,
+}
+
+// ParseDuration 解析时长，支持 time.ParseDuration 的所有格式以及：
+// "1d2h"、"1.5d"、"3 weeks"、"2 days 4 hours"、"1 hour, 30 minutes"、"-2w"
+func ParseDuration(s string) (time.Duration, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+
+	neg := false
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		neg, s = true, rest
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+
+	var total time.Duration
+	parts := 0
+	for {
+		s = strings.TrimLeft(s, " ,\t")
+		if s == "" {
+			break
+		}
+		if strings.HasPrefix(s, "and ") {
+			s = s[len("and "):]
+			continue
+		}
+
+		// 数字部分
+		i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+		if i <= 0 {
+			return 0, fmt.Errorf("timex: invalid duration %q", orig)
+		}
+		value, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("timex: invalid duration %q", orig)
+		}
+		s = strings.TrimLeft(s[i:], " ")
+
+		// 单位部分
+		j := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && r != 'µ' })
+		if j < 0 {
+			j = len(s)
+		}
+		unit, ok := durationUnits[strings.ToLower(s[:j])]
+		if !ok {
+			return 0, fmt.Errorf("timex: unknown unit %q in duration %q", s[:j], orig)
+		}
+		s = s[j:]
+
+		// float64(math.MaxInt64) 等于 2^63，乘积达到它就已经溢出
+		part := value * float64(unit)
+		if part >= math.MaxInt64 || total > math.MaxInt64-time.Duration(part) {
+			return 0, fmt.Errorf("timex: duration %q overflows", orig)
+		}
+		total += time.Duration(part)
+		parts++
+	}
+	if parts == 0 {
+		return 0, fmt.Errorf("timex: invalid duration %q", orig)
+	}
+	if neg {
+		total = -total
+	}
+	return total, nil
+}
+
+// FormatDuration 紧凑格式，可以被 ParseDuration 解析回来，例如 "1w2d3h4m5s"
+// 不足一秒的部分按 time.Duration.String 的方式附加，例如 "1d500ms"
+func FormatDuration(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+	}
+	abs := absDuration(d)
+	for _, u := range []struct {
+		unit time.Duration
+		name string
+	}{{Week, "w"}, {Day, "d"}, {time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"}} {
+		if n := abs / uint64(u.unit); n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, u.name)
+			abs -= n * uint64(u.unit)
+		}
+	}
+	if abs > 0 {
+		b.WriteString(time.Duration(abs).String())
+	}
+	return b.String()
+}
+
+// Humanize 只保留最大的 parts 个单位，适合展示，例如 Humanize(50*time.Hour, 2) == "2 days 2 hours"
+func Humanize(d time.Duration, parts int) string {
+	abs := absDuration(d)
+	if abs < uint64(time.Second) {
+		return d.String()
+	}
+	sign := ""
+	if d < 0 {
+		sign = "-"
+	}
+
+	var words []string
+	for _, u := range []struct {
+		unit time.Duration
+		name string
+	}{{Week, "week"}, {Day, "day"}, {time.Hour, "hour"}, {time.Minute, "minute"}, {time.Second, "second"}} {
+		if len(words) == parts {
+			break
+		}
+		n := abs / uint64(u.unit)
+		if n == 0 {
+			if len(words) > 0 {
+				break // 只输出相邻的单位，"1 week 3 seconds" 没有意义
+			}
+			continue
+		}
+		abs -= n * uint64(u.unit)
+		name := u.name
+		if n > 1 {
+			name += "s"
+		}
+		words = append(words, fmt.Sprintf("%d %s", n, name))
+	}
+	return sign + strings.Join(words, " ")
+}
+
+// absDuration d 的绝对值；math.MinInt64 取反会溢出，用无符号数表示
+func absDuration(d time.Duration) uint64 {
+	if d < 0 {
+		return -uint64(d)
+	}
+	return uint64(d)
+}
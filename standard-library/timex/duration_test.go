@@ -0,0 +1,125 @@
+package timex
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		err  string // 非空时期望错误信息包含它
+	}{
+		// time.ParseDuration 的格式
+		{in: "1h30m", want: 90 * time.Minute},
+		{in: "-1.5h", want: -90 * time.Minute},
+		{in: "300ms", want: 300 * time.Millisecond},
+		// 天和周
+		{in: "1d2h", want: 26 * time.Hour},
+		{in: "1.5d", want: 36 * time.Hour},
+		{in: "2w", want: 14 * Day},
+		{in: "1w2d3h4m5s", want: Week + 2*Day + 3*time.Hour + 4*time.Minute + 5*time.Second},
+		{in: "1d500ms", want: Day + 500*time.Millisecond},
+		// 单词单位、空格、逗号、and，大小写不敏感
+		{in: "3 weeks", want: 21 * Day},
+		{in: "2 days 4 hours", want: 52 * time.Hour},
+		{in: "1 hour, 30 minutes", want: 90 * time.Minute},
+		{in: "1 hour and 30 minutes", want: 90 * time.Minute},
+		{in: "  1 Day  ", want: Day},
+		{in: "1.5 hrs", want: 90 * time.Minute},
+		{in: "2us 3µs", want: 5 * time.Microsecond},
+		// 符号作用于整个时长
+		{in: "-2w", want: -14 * Day},
+		{in: "-1d 12h", want: -36 * time.Hour},
+		{in: "+1d", want: Day},
+		// 接近上限
+		{in: "15250w1d", want: 15250*Week + Day},
+		{in: "-15250w1d", want: -(15250*Week + Day)},
+		// 溢出：单个部分或累计值超过 math.MaxInt64
+		{in: "20000w", err: "overflows"},
+		{in: "1000000w", err: "overflows"},
+		{in: "-20000w", err: "overflows"},
+		{in: "15250w2d", err: "overflows"},
+		{in: "10000w 10000w", err: "overflows"},
+		{in: "9223372036854775807ns 1ns", err: "overflows"},
+		// 非法输入
+		{in: "", err: "invalid duration"},
+		{in: " , ", err: "invalid duration"},
+		{in: "abc", err: "invalid duration"},
+		{in: "d", err: "invalid duration"},
+		{in: "and", err: "invalid duration"},
+		{in: "--1d", err: "invalid duration"},
+		{in: "1d-", err: "invalid duration"},
+		{in: "1..5d", err: "invalid duration"},
+		{in: "1x", err: `unknown unit "x"`},
+		{in: "5 fortnights", err: `unknown unit "fortnights"`},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseDuration(%q) = %v, %v; want error containing %q", tt.in, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+// TestFormatParseRoundTrip FormatDuration 的输出可以被 ParseDuration 解析回来
+func TestFormatParseRoundTrip(t *testing.T) {
+	for _, d := range []time.Duration{
+		time.Nanosecond, 90 * time.Second, -36 * time.Hour,
+		Week + 2*Day + 3*time.Hour + 4*time.Minute + 5*time.Second + 6*time.Millisecond,
+		math.MaxInt64,
+	} {
+		s := FormatDuration(d)
+		if got, err := ParseDuration(s); err != nil || got != d {
+			t.Errorf("ParseDuration(FormatDuration(%d) = %q) = %d, %v", int64(d), s, int64(got), err)
+		}
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	tests := []struct {
+		d     time.Duration
+		parts int
+		want  string
+	}{
+		{50 * time.Hour, 2, "2 days 2 hours"},
+		{-90 * time.Second, 2, "-1 minute 30 seconds"},
+		{Week + 3*time.Second, 3, "1 week"},
+		{500 * time.Millisecond, 2, "500ms"},
+		{-500 * time.Millisecond, 2, "-500ms"},
+		{math.MinInt64, 2, "-15250 weeks 1 day"},
+		{math.MaxInt64, 2, "15250 weeks 1 day"},
+	}
+	for _, tt := range tests {
+		if got := Humanize(tt.d, tt.parts); got != tt.want {
+			t.Errorf("Humanize(%d, %d) = %q; want %q", int64(tt.d), tt.parts, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{Week + 2*Day + 3*time.Hour + 4*time.Minute + 5*time.Second, "1w2d3h4m5s"},
+		{Day + 500*time.Millisecond, "1d500ms"},
+		{-time.Hour, "-1h"},
+		{math.MinInt64, "-15250w1d23h47m16s854.775808ms"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.d); got != tt.want {
+			t.Errorf("FormatDuration(%d) = %q; want %q", int64(tt.d), got, tt.want)
+		}
+	}
+}
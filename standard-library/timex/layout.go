@@ -0,0 +1,84 @@
+// ============================= 4. Go布局与strftime布局 ====================
+// 除了 Go 的 "2006-01-02 15:04:05"，也接受其他语言常用的 "%Y-%m-%d %H:%M:%S"
+// 含 % 的布局按 strftime 转换，否则原样作为 Go 布局使用
+// 注意：Go 布局没有转义，strftime 布局中的普通文字若恰好是 "Jan"、"2006" 等会被当作占位符
+
+package timex
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var strftimeDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'e': "_2",
+	'j': "002",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'f': "000000", // 微秒，需写在小数点之后，例如 %S.%f
+	'L': "000",    // 毫秒
+	'p': "PM",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'z': "-0700",
+	'Z': "MST",
+	'F': "2006-01-02",
+	'T': "15:04:05",
+	'D': "01/02/06",
+	'R': "15:04",
+	'%': "%",
+}
+
+// ToGoLayout 把 strftime 布局转换为 Go 布局；不含 % 的布局原样返回
+func ToGoLayout(layout string) (string, error) {
+	if !strings.Contains(layout, "%") {
+		return layout, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(layout); i++ {
+		c := layout[i]
+		if c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		if i+1 == len(layout) {
+			return "", fmt.Errorf("timex: layout %q ends with %%", layout)
+		}
+		i++
+		directive, ok := strftimeDirectives[layout[i]]
+		if !ok {
+			return "", fmt.Errorf("timex: unsupported directive %%%c in layout %q", layout[i], layout)
+		}
+		b.WriteString(directive)
+	}
+	return b.String(), nil
+}
+
+// Format 按 Go 或 strftime 布局格式化
+func Format(t time.Time, layout string) (string, error) {
+	goLayout, err := ToGoLayout(layout)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(goLayout), nil
+}
+
+// ParseInLocation 按 Go 或 strftime 布局解析，没有时区信息的时间按 loc 解释
+func ParseInLocation(layout, value string, loc *time.Location) (time.Time, error) {
+	goLayout, err := ToGoLayout(layout)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation(goLayout, value, loc)
+}
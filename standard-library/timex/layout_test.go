@@ -0,0 +1,80 @@
+package timex
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToGoLayout(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"%Y-%m-%d %H:%M:%S", "2006-01-02 15:04:05"},
+		{"%F %T", "2006-01-02 15:04:05"},
+		{"%d/%b/%Y:%H:%M:%S %z", "02/Jan/2006:15:04:05 -0700"},
+		{"%a, %e %B %y %I:%M %p %Z", "Mon, _2 January 06 03:04 PM MST"},
+		{"%j %D %R", "002 01/02/06 15:04"},
+		{"%S.%f|%S.%L", "05.000000|05.000"},
+		{"100%%", "100%"},
+		// 不含 % 的布局原样作为 Go 布局
+		{"2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05Z07:00"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		got, err := ToGoLayout(c.in)
+		if err != nil || got != c.want {
+			t.Errorf("ToGoLayout(%q) = %q, %v; want %q", c.in, got, err, c.want)
+		}
+	}
+
+	for in, msg := range map[string]string{
+		"%Y-%m-%":  "ends with %",
+		"%Y %Q":    "unsupported directive %Q",
+		"at %H%":   "ends with %",
+		"%k:%M:%S": "unsupported directive %k",
+	} {
+		if _, err := ToGoLayout(in); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("ToGoLayout(%q) = %v; want error containing %q", in, err, msg)
+		}
+	}
+}
+
+func TestFormatParse(t *testing.T) {
+	shanghai := mustLoad(t, "Asia/Shanghai")
+	ts := time.Date(2024, 3, 5, 14, 7, 9, 123456000, shanghai)
+
+	cases := []struct {
+		layout, want string
+	}{
+		{"%Y-%m-%d %H:%M:%S.%f", "2024-03-05 14:07:09.123456"},
+		{"%d/%b/%Y:%H:%M:%S %z", "05/Mar/2024:14:07:09 +0800"},
+		{"%A %I%p", "Tuesday 02PM"},
+		{time.RFC3339, "2024-03-05T14:07:09+08:00"},
+	}
+	for _, c := range cases {
+		got, err := Format(ts, c.layout)
+		if err != nil || got != c.want {
+			t.Errorf("Format(%q) = %q, %v; want %q", c.layout, got, err, c.want)
+		}
+	}
+	if _, err := Format(ts, "%Q"); err == nil {
+		t.Error("Format with bad directive succeeded")
+	}
+
+	// 没有时区信息的时间按 loc 解释
+	got, err := ParseInLocation("%Y-%m-%d %H:%M:%S.%f", "2024-03-05 14:07:09.123456", shanghai)
+	if err != nil || !got.Equal(ts) {
+		t.Errorf("ParseInLocation = %v, %v; want %v", got, err, ts)
+	}
+	got, err = ParseInLocation("%d/%b/%Y:%H:%M:%S %z", "05/Mar/2024:06:07:09 +0000", shanghai)
+	if err != nil || !got.Equal(ts.Truncate(time.Second)) {
+		t.Errorf("ParseInLocation with offset = %v, %v; want %v", got, err, ts.Truncate(time.Second))
+	}
+	if _, err := ParseInLocation("%F", "2024-02-30", time.UTC); err == nil {
+		t.Error("ParseInLocation accepted 2024-02-30")
+	}
+	if _, err := ParseInLocation("%F %", "2024-02-03", time.UTC); err == nil {
+		t.Error("ParseInLocation accepted a bad layout")
+	}
+}
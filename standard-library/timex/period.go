@@ -0,0 +1,102 @@
+// ============================= 1. 日/周/月的起止时间 ====================
+// 报表按自然日、周、月统计时，边界必须按指定时区计算：
+// 同一时刻在 UTC 和 Asia/Shanghai 可能属于不同的一天
+// End* 返回区间内最后一个纳秒，区间为 [Start, End]
+
+package timex
+
+import (
+	"iter"
+	"time"
+)
+
+// StartOfDay loc 时区下 t 所在日的第一个时刻，通常是 00:00:00
+// 零点被夏令时跳过的日子（如 America/Sao_Paulo 2018-11-04）从跳变后的 01:00 开始
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return startOfDate(t.Year(), t.Month(), t.Day(), loc)
+}
+
+// EndOfDay loc 时区下 t 所在日的最后一纳秒
+func EndOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return startOfDate(t.Year(), t.Month(), t.Day()+1, loc).Add(-time.Nanosecond)
+}
+
+// StartOfWeek loc 时区下 t 所在周的第一天零点，weekStart 指定一周从周几开始
+func StartOfWeek(t time.Time, loc *time.Location, weekStart time.Weekday) time.Time {
+	t = t.In(loc)
+	offset := (int(t.Weekday()) - int(weekStart) + 7) % 7
+	return startOfDate(t.Year(), t.Month(), t.Day()-offset, loc)
+}
+
+// EndOfWeek loc 时区下 t 所在周的最后一纳秒
+func EndOfWeek(t time.Time, loc *time.Location, weekStart time.Weekday) time.Time {
+	start := StartOfWeek(t, loc, weekStart)
+	return startOfDate(start.Year(), start.Month(), start.Day()+7, loc).Add(-time.Nanosecond)
+}
+
+// StartOfMonth loc 时区下 t 所在月1日零点
+func StartOfMonth(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return startOfDate(t.Year(), t.Month(), 1, loc)
+}
+
+// EndOfMonth loc 时区下 t 所在月的最后一纳秒
+func EndOfMonth(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return startOfDate(t.Year(), t.Month()+1, 1, loc).Add(-time.Nanosecond)
+}
+
+// startOfDate loc 时区下 y-m-d 的第一个时刻，超出范围的月、日按 time.Date 的规则进位
+func startOfDate(y int, m time.Month, d int, loc *time.Location) time.Time {
+	y, m, d = time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	if sy, sm, sd := start.Date(); sy == y && sm == m && sd == d {
+		return start
+	}
+	// 零点被跳过时 time.Date 返回前一天的时刻；当天从跳变时刻开始，即按跳变前的偏移换算的零点
+	_, offset := start.Zone()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Add(-time.Duration(offset) * time.Second).In(loc)
+}
+
+// ============================= 2. 时间序列 ====================
+
+// Range 按 step 生成 [start, end) 内的时间点；step<=0 时不生成任何值
+// 按天、按月递增请使用 DateRange，避免夏令时切换日不是24小时
+func Range(start, end time.Time, step time.Duration) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		if step <= 0 {
+			return
+		}
+		for t := start; t.Before(end); t = t.Add(step) {
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// DateRange 按日历递增（年、月、日），生成 [start, end) 内的时间点
+// 每个值都从 start 计算，1月31日按月递增得到 2月29日/3月31日…… 而不会逐月漂移
+func DateRange(start, end time.Time, years, months, days int) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		if years < 0 || months < 0 || days < 0 || years+months+days == 0 {
+			return
+		}
+		for i := 0; ; i++ {
+			t := addDateClamped(start, years*i, months*i, days*i)
+			if !t.Before(end) || !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// addDateClamped 与 AddDate 相同，但月末日期不会溢出到下个月
+func addDateClamped(t time.Time, years, months, days int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y+years, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	return first.AddDate(0, 0, min(d, last)-1+days)
+}
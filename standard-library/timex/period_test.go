@@ -0,0 +1,143 @@
+package timex
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s unavailable: %v", name, err)
+	}
+	return loc
+}
+
+// TestDayBoundsDST 日的起止时间总是包含 t，且与 t 在同一天
+func TestDayBoundsDST(t *testing.T) {
+	sp := mustLoad(t, "America/Sao_Paulo")
+	ny := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name       string
+		t          time.Time
+		loc        *time.Location
+		start, end time.Time
+	}{
+		// 圣保罗 2018-11-04 零点跳到 01:00，当天从 01:00 开始
+		{"SkippedMidnight", time.Date(2018, 11, 4, 12, 0, 0, 0, sp), sp,
+			time.Date(2018, 11, 4, 1, 0, 0, 0, sp), time.Date(2018, 11, 4, 23, 59, 59, 999999999, sp)},
+		{"SkippedMidnightFirstInstant", time.Date(2018, 11, 4, 1, 0, 0, 0, sp), sp,
+			time.Date(2018, 11, 4, 1, 0, 0, 0, sp), time.Date(2018, 11, 4, 23, 59, 59, 999999999, sp)},
+		// 前一天结束于跳变前的最后一纳秒
+		{"DayBeforeSkip", time.Date(2018, 11, 3, 23, 30, 0, 0, sp), sp,
+			time.Date(2018, 11, 3, 0, 0, 0, 0, sp), time.Date(2018, 11, 3, 23, 59, 59, 999999999, sp)},
+		// 纽约回拨日有25小时
+		{"FallBack", time.Date(2024, 11, 3, 12, 0, 0, 0, ny), ny,
+			time.Date(2024, 11, 3, 0, 0, 0, 0, ny), time.Date(2024, 11, 3, 23, 59, 59, 999999999, ny)},
+		// 同一时刻在不同时区属于不同的一天
+		{"OtherZone", time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC), mustLoad(t, "Asia/Shanghai"),
+			time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 15, 59, 59, 999999999, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := StartOfDay(tt.t, tt.loc), EndOfDay(tt.t, tt.loc)
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Fatalf("day of %v = [%v, %v]; want [%v, %v]", tt.t, start, end, tt.start, tt.end)
+			}
+			if tt.t.Before(start) || tt.t.After(end) {
+				t.Errorf("[%v, %v] does not contain %v", start, end, tt.t)
+			}
+		})
+	}
+
+	if d := EndOfDay(tests[3].t, ny).Sub(StartOfDay(tests[3].t, ny)); d != 25*time.Hour-time.Nanosecond {
+		t.Errorf("fall-back day length = %v, want 25h", d)
+	}
+}
+
+func TestWeekAndMonth(t *testing.T) {
+	sp := mustLoad(t, "America/Sao_Paulo")
+	wed := time.Date(2024, 1, 3, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		got, want time.Time
+	}{
+		{"StartOfWeekMonday", StartOfWeek(wed, time.UTC, time.Monday), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"StartOfWeekSunday", StartOfWeek(wed, time.UTC, time.Sunday), time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"StartOfWeekSameDay", StartOfWeek(wed, time.UTC, time.Wednesday), time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"EndOfWeek", EndOfWeek(wed, time.UTC, time.Monday), time.Date(2024, 1, 7, 23, 59, 59, 999999999, time.UTC)},
+		{"StartOfMonth", StartOfMonth(wed, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"EndOfMonthLeap", EndOfMonth(time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC), time.UTC), time.Date(2024, 2, 29, 23, 59, 59, 999999999, time.UTC)},
+		{"EndOfMonthYearEnd", EndOfMonth(time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC), time.UTC), time.Date(2024, 12, 31, 23, 59, 59, 999999999, time.UTC)},
+		// 一周的第一天零点被跳过
+		{"StartOfWeekSkippedMidnight", StartOfWeek(time.Date(2018, 11, 6, 12, 0, 0, 0, sp), sp, time.Sunday), time.Date(2018, 11, 4, 1, 0, 0, 0, sp)},
+		{"EndOfWeekBeforeSkip", EndOfWeek(time.Date(2018, 11, 1, 12, 0, 0, 0, sp), sp, time.Sunday), time.Date(2018, 11, 3, 23, 59, 59, 999999999, sp)},
+	}
+	for _, tt := range tests {
+		if !tt.got.Equal(tt.want) {
+			t.Errorf("%s = %v; want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	got := slices.Collect(Range(start, start.Add(time.Hour), 20*time.Minute))
+	want := []time.Time{start, start.Add(20 * time.Minute), start.Add(40 * time.Minute)}
+	if !slices.Equal(got, want) {
+		t.Errorf("Range = %v; want %v", got, want)
+	}
+	if got := slices.Collect(Range(start, start.Add(time.Hour), 0)); len(got) != 0 {
+		t.Errorf("Range with step 0 = %v", got)
+	}
+	for v := range Range(start, start.Add(time.Hour), time.Minute) {
+		if !v.Equal(start) {
+			t.Fatalf("first value = %v", v)
+		}
+		break // 提前结束不会panic
+	}
+}
+
+func TestDateRange(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name                string
+		start, end          time.Time
+		years, months, days int
+		want                []time.Time
+	}{
+		// 月末按当月最后一天截断，每个值都从 start 计算，不会逐月漂移到28日
+		{"MonthEnd", date(2024, 1, 31), date(2024, 6, 1), 0, 1, 0,
+			[]time.Time{date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 31), date(2024, 4, 30), date(2024, 5, 31)}},
+		{"LeapDayYearly", date(2024, 2, 29), date(2029, 1, 1), 1, 0, 0,
+			[]time.Time{date(2024, 2, 29), date(2025, 2, 28), date(2026, 2, 28), date(2027, 2, 28), date(2028, 2, 29)}},
+		// 先按月截断再加天数：1月31日 + 1月1天 = 2月29日 + 1天
+		{"MonthPlusDays", date(2024, 1, 31), date(2024, 4, 1), 0, 1, 1,
+			[]time.Time{date(2024, 1, 31), date(2024, 3, 1)}},
+		// 按天递增时夏令时切换日仍保持 09:00
+		{"DailyAcrossDST", time.Date(2024, 3, 9, 9, 0, 0, 0, ny), time.Date(2024, 3, 12, 0, 0, 0, 0, ny), 0, 0, 1,
+			[]time.Time{time.Date(2024, 3, 9, 9, 0, 0, 0, ny), time.Date(2024, 3, 10, 9, 0, 0, 0, ny), time.Date(2024, 3, 11, 9, 0, 0, 0, ny)}},
+		{"ZeroStep", date(2024, 1, 1), date(2025, 1, 1), 0, 0, 0, nil},
+		{"NegativeStep", date(2024, 1, 1), date(2025, 1, 1), 0, -1, 0, nil},
+		{"EmptyRange", date(2024, 1, 1), date(2024, 1, 1), 0, 0, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slices.Collect(DateRange(tt.start, tt.end, tt.years, tt.months, tt.days))
+			if len(got) != len(tt.want) {
+				t.Fatalf("DateRange = %v; want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("value %d = %v; want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}